@@ -2,11 +2,20 @@ package ast
 
 import (
 	"bytes"
+
+	"weilang/token"
 )
 
+// FileLocation 节点在源码中的位置，行列都从 0 开始
 type FileLocation struct {
 	Filename string
 	Lineno   int
+	// Column 节点的起始列
+	Column int
+	// End 节点的结束位置（不包含）
+	End token.Position
+	// Lines 节点所在文件的源码，与词法分析器共享，用来输出错误栈
+	Lines []string
 }
 
 func NewFileLocation(filename string, lineno int) *FileLocation {
@@ -16,6 +25,19 @@ func NewFileLocation(filename string, lineno int) *FileLocation {
 	}
 }
 
+// Start 节点的起始位置
+func (fl *FileLocation) Start() token.Position {
+	return token.Position{Line: fl.Lineno, Column: fl.Column}
+}
+
+// Line 返回第 lineno 行的源码，没有源码时返回空字符串
+func (fl *FileLocation) Line(lineno int) string {
+	if lineno < 0 || lineno >= len(fl.Lines) {
+		return ""
+	}
+	return fl.Lines[lineno]
+}
+
 // The base Node interface
 type Node interface {
 	GetFileLocation() *FileLocation
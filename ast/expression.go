@@ -137,11 +137,15 @@ func (ce *CallExpression) GetFileLocation() *FileLocation {
 }
 
 type FunctionLiteral struct {
-	Location   *FileLocation
-	Token      token.Token // The 'fn' token
-	Name       string
-	Parameters []*Identifier
-	Body       *BlockStatement
+	Location *FileLocation
+	Token    token.Token // The 'fn' token
+	Name     string
+	// NameLocation 函数名的位置，匿名函数为 nil
+	NameLocation *FileLocation
+	Parameters   []*Identifier
+	Body         *BlockStatement
+	// IsGenerator 函数体中含有 yield ，调用时返回生成器
+	IsGenerator bool
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
@@ -195,10 +199,132 @@ func (al *ListLiteral) GetFileLocation() *FileLocation {
 	return al.Location
 }
 
+// Comprehension 推导式中的 for 子句，例如 "for k, v in d if v > 0"
+// 变量的绑定和解包规则与 for-in 语句相同
+type Comprehension struct {
+	Targets  []*Identifier
+	Iterable Expression
+	// Condition 没有 if 时为 nil
+	Condition Expression
+}
+
+func (c *Comprehension) String() string {
+	var targets []string
+	for _, target := range c.Targets {
+		targets = append(targets, target.String())
+	}
+	out := " for " + strings.Join(targets, ", ") + " in " + c.Iterable.String()
+	if c.Condition != nil {
+		out += " if " + c.Condition.String()
+	}
+	return out
+}
+
+// ListComprehension 列表推导式，例如 [x * 2 for x in items if x > 0]
+type ListComprehension struct {
+	Location      *FileLocation
+	Token         token.Token // the '[' token
+	Element       Expression
+	Comprehension *Comprehension
+}
+
+func (lc *ListComprehension) expressionNode()      {}
+func (lc *ListComprehension) TokenLiteral() string { return lc.Token.Literal }
+func (lc *ListComprehension) String() string {
+	return "[" + lc.Element.String() + lc.Comprehension.String() + "]"
+}
+func (lc *ListComprehension) GetFileLocation() *FileLocation {
+	return lc.Location
+}
+
+// SetComprehension 集合推导式，例如 {x % 3 for x in items}
+type SetComprehension struct {
+	Location      *FileLocation
+	Token         token.Token // the '{' token
+	Element       Expression
+	Comprehension *Comprehension
+}
+
+func (sc *SetComprehension) expressionNode()      {}
+func (sc *SetComprehension) TokenLiteral() string { return sc.Token.Literal }
+func (sc *SetComprehension) String() string {
+	return "{" + sc.Element.String() + sc.Comprehension.String() + "}"
+}
+func (sc *SetComprehension) GetFileLocation() *FileLocation {
+	return sc.Location
+}
+
+// DictComprehension 字典推导式，例如 {k: v for k, v in d}
+type DictComprehension struct {
+	Location      *FileLocation
+	Token         token.Token // the '{' token
+	Key           Expression
+	Value         Expression
+	Comprehension *Comprehension
+}
+
+func (dc *DictComprehension) expressionNode()      {}
+func (dc *DictComprehension) TokenLiteral() string { return dc.Token.Literal }
+func (dc *DictComprehension) String() string {
+	return "{" + dc.Key.String() + ":" + dc.Value.String() + dc.Comprehension.String() + "}"
+}
+func (dc *DictComprehension) GetFileLocation() *FileLocation {
+	return dc.Location
+}
+
+// YieldExpression yield 表达式，例如 "yield 1" ，值为 send 传入的值
+type YieldExpression struct {
+	Location *FileLocation
+	Token    token.Token // the 'yield' token
+	// Value 没有值时为 nil ，产生 null
+	Value Expression
+}
+
+func (ye *YieldExpression) expressionNode()      {}
+func (ye *YieldExpression) TokenLiteral() string { return ye.Token.Literal }
+func (ye *YieldExpression) String() string {
+	if ye.Value == nil {
+		return "(yield)"
+	}
+	return "(yield " + ye.Value.String() + ")"
+}
+func (ye *YieldExpression) GetFileLocation() *FileLocation {
+	return ye.Location
+}
+
+// SetLiteral 集合字面量，例如 {1, 2, 3} ，空的 {} 为字典
+type SetLiteral struct {
+	Location *FileLocation
+	Token    token.Token // the '{' token
+	Elements []Expression
+}
+
+func (sl *SetLiteral) expressionNode()      {}
+func (sl *SetLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *SetLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range sl.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+func (sl *SetLiteral) GetFileLocation() *FileLocation {
+	return sl.Location
+}
+
 type DictLiteral struct {
 	Location *FileLocation
 	Token    token.Token // the '{' token
 	Pairs    map[Expression]Expression
+	// Keys 按照源码中出现的顺序排列的 key
+	Keys []Expression
 }
 
 func (hl *DictLiteral) expressionNode()      {}
@@ -207,8 +333,8 @@ func (hl *DictLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
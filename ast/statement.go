@@ -404,10 +404,12 @@ func (cb *ClassBlockStatement) GetFileLocation() *FileLocation {
 type ClassDefineStatement struct {
 	Location *FileLocation
 	// "class" token
-	Token  token.Token
-	Name   string
-	Parent *Identifier
-	Body   *ClassBlockStatement
+	Token token.Token
+	Name  string
+	// NameLocation 类名的位置
+	NameLocation *FileLocation
+	Parent       *Identifier
+	Body         *ClassBlockStatement
 }
 
 func (cd *ClassDefineStatement) statementNode() {}
@@ -0,0 +1,133 @@
+package ast
+
+// Inspect 深度优先遍历语法树，对每个节点调用 f，f 返回 false 时不再遍历该节点的子节点
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *Program:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+	case *BlockStatement:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+	case *ClassBlockStatement:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+	case *VarStatement:
+		Inspect(n.Name, f)
+		Inspect(n.Value, f)
+	case *ConStatement:
+		Inspect(n.Name, f)
+		Inspect(n.Value, f)
+	case *AssignStatement:
+		Inspect(n.Left, f)
+		Inspect(n.Value, f)
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			Inspect(n.ReturnValue, f)
+		}
+	case *ExpressionStatement:
+		Inspect(n.Expression, f)
+	case *IfStatement:
+		for _, branch := range n.IfBranches {
+			Inspect(branch.Condition, f)
+			Inspect(branch.Body, f)
+		}
+		if n.ElseBody != nil {
+			Inspect(n.ElseBody, f)
+		}
+	case *WhileStatement:
+		Inspect(n.Condition, f)
+		Inspect(n.Body, f)
+	case *ForInStatement:
+		for _, target := range n.Targets {
+			Inspect(target, f)
+		}
+		Inspect(n.Expr, f)
+		Inspect(n.Body, f)
+	case *FunctionDefineStatement:
+		Inspect(n.Function, f)
+	case *ClassDefineStatement:
+		if n.Parent != nil {
+			Inspect(n.Parent, f)
+		}
+		Inspect(n.Body, f)
+	case *ClassVariableDeclarationStatement:
+		Inspect(n.Name, f)
+		if n.Expr != nil {
+			Inspect(n.Expr, f)
+		}
+	case *ClassMethodDefineStatement:
+		Inspect(n.Function, f)
+	case *WeiExportStatement:
+		for _, name := range n.Names {
+			Inspect(name, f)
+		}
+	case *UnaryExpression:
+		Inspect(n.Operand, f)
+	case *BinaryOpExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	case *SubscriptionExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Index, f)
+	case *AttributeExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Attribute, f)
+	case *CallExpression:
+		Inspect(n.Function, f)
+		for _, arg := range n.Arguments {
+			Inspect(arg, f)
+		}
+	case *FunctionLiteral:
+		for _, param := range n.Parameters {
+			Inspect(param, f)
+		}
+		Inspect(n.Body, f)
+	case *ListLiteral:
+		for _, element := range n.Elements {
+			Inspect(element, f)
+		}
+	case *SetLiteral:
+		for _, element := range n.Elements {
+			Inspect(element, f)
+		}
+	case *DictLiteral:
+		for _, key := range n.Keys {
+			Inspect(key, f)
+			Inspect(n.Pairs[key], f)
+		}
+	case *ListComprehension:
+		inspectComprehension(n.Comprehension, f)
+		Inspect(n.Element, f)
+	case *SetComprehension:
+		inspectComprehension(n.Comprehension, f)
+		Inspect(n.Element, f)
+	case *DictComprehension:
+		inspectComprehension(n.Comprehension, f)
+		Inspect(n.Key, f)
+		Inspect(n.Value, f)
+	case *YieldExpression:
+		if n.Value != nil {
+			Inspect(n.Value, f)
+		}
+	case *WeiAttributeExpression:
+		Inspect(n.Attribute, f)
+	}
+}
+
+// inspectComprehension 遍历推导式的 for 子句，顺序和 for-in 语句相同
+func inspectComprehension(c *Comprehension, f func(Node) bool) {
+	for _, target := range c.Targets {
+		Inspect(target, f)
+	}
+	Inspect(c.Iterable, f)
+	if c.Condition != nil {
+		Inspect(c.Condition, f)
+	}
+}
@@ -0,0 +1,115 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// errInvalidMessage 消息不是合法的 JSON ，跳过这条消息
+var errInvalidMessage = errors.New("invalid message")
+
+// conn 基于 Content-Length 分帧的 DAP 连接
+type conn struct {
+	reader *bufio.Reader
+	// mu 保证消息完整地写入，seq 按照写入的顺序递增
+	mu     sync.Mutex
+	writer io.Writer
+	seq    int
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{reader: bufio.NewReader(r), writer: w}
+}
+
+// read 读取一条消息，连接关闭时返回 io.EOF
+func (c *conn) read() (*message, error) {
+	header, err := textproto.NewReader(c.reader).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || (err == io.ErrUnexpectedEOF && len(header) == 0) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header '%s'", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return nil, err
+	}
+	msg := &message{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidMessage, err)
+	}
+	return msg, nil
+}
+
+func (c *conn) write(msg *message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	msg.Seq = c.seq
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.writer.Write(body)
+	return err
+}
+
+// respond 回复请求，err 不为空时回复失败
+func (c *conn) respond(request *message, body any, err error) error {
+	success := err == nil
+	msg := &message{Type: "response", RequestSeq: request.Seq, Command: request.Command, Success: &success}
+	if err != nil {
+		msg.Message = err.Error()
+		return c.write(msg)
+	}
+	if body != nil {
+		data, marshalErr := json.Marshal(body)
+		if marshalErr != nil {
+			success = false
+			msg.Message = marshalErr.Error()
+			return c.write(msg)
+		}
+		msg.Body = data
+	}
+	return c.write(msg)
+}
+
+// event 发送事件
+func (c *conn) event(event string, body any) error {
+	msg := &message{Type: "event", Event: event}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		msg.Body = data
+	}
+	return c.write(msg)
+}
+
+// outputWriter 把程序的输出转换为 output 事件
+type outputWriter struct {
+	conn     *conn
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	if err := w.conn.event("output", OutputEventBody{Category: w.category, Output: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,447 @@
+package dap
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testProgram = `var total = 0
+var items = [1, "two", {"k": 3}]
+
+fn add(a, b) {
+    var s = a + b
+    return s
+}
+
+var i = 0
+while (i < 3) {
+    total = add(total, i)
+    i = i + 1
+}
+print(total)
+`
+
+// testClient 在同一个进程中通过管道与服务器通信的 DAP 客户端
+type testClient struct {
+	t    *testing.T
+	conn *conn
+	// messages 服务器发来的所有消息
+	messages chan *message
+	// done Serve 的返回值
+	done chan error
+	// events 已经收到但是还没有被取走的事件
+	events []*message
+	// output 收到的 output 事件
+	output []OutputEventBody
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+	clientToServerR, clientToServerW := io.Pipe()
+	serverToClientR, serverToClientW := io.Pipe()
+	c := &testClient{
+		t:        t,
+		conn:     newConn(serverToClientR, clientToServerW),
+		messages: make(chan *message, 100),
+		done:     make(chan error, 1),
+	}
+	go func() {
+		c.done <- NewServer(clientToServerR, serverToClientW).Serve()
+		_ = serverToClientW.Close()
+	}()
+	go func() {
+		for {
+			msg, err := c.conn.read()
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() { _ = clientToServerW.Close() })
+	return c
+}
+
+func (c *testClient) next() *message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("connection closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timeout waiting for message")
+	}
+	return nil
+}
+
+// request 发送请求并等待响应，返回失败时的错误信息，body 为 nil 时不解析响应
+func (c *testClient) request(command string, args any, body any) string {
+	c.t.Helper()
+	msg := &message{Type: "request", Command: command}
+	if args != nil {
+		data, err := json.Marshal(args)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		msg.Arguments = data
+	}
+	if err := c.conn.write(msg); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		resp := c.next()
+		if resp.Type == "event" {
+			c.events = append(c.events, resp)
+			continue
+		}
+		if resp.RequestSeq != msg.Seq || resp.Command != command {
+			c.t.Fatalf("unexpected response %+v to %s", resp, command)
+		}
+		if resp.Success == nil || !*resp.Success {
+			return resp.Message
+		}
+		if body != nil {
+			if err := json.Unmarshal(resp.Body, body); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return ""
+	}
+}
+
+// mustRequest 发送请求，失败时结束测试
+func (c *testClient) mustRequest(command string, args any, body any) {
+	c.t.Helper()
+	if errMsg := c.request(command, args, body); errMsg != "" {
+		c.t.Fatalf("%s failed: %s", command, errMsg)
+	}
+}
+
+// event 等待指定的事件，跳过其他事件，output 事件保存在 output 中
+func (c *testClient) event(name string, body any) {
+	c.t.Helper()
+	for {
+		var msg *message
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Type != "event" {
+			c.t.Fatalf("unexpected message %+v while waiting for %s", msg, name)
+		}
+		if msg.Event == "output" {
+			var output OutputEventBody
+			_ = json.Unmarshal(msg.Body, &output)
+			c.output = append(c.output, output)
+		}
+		if msg.Event != name {
+			continue
+		}
+		if body != nil {
+			if err := json.Unmarshal(msg.Body, body); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return
+	}
+}
+
+func (c *testClient) wait() error {
+	c.t.Helper()
+	select {
+	case err := <-c.done:
+		return err
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timeout waiting for server")
+	}
+	return nil
+}
+
+func writeProgram(t *testing.T, source string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "main.wei")
+	if err := os.WriteFile(filename, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// start 初始化并执行 program
+func (c *testClient) start(program string, stopOnEntry bool, breakpoints ...SourceBreakpoint) SetBreakpointsResponseBody {
+	c.t.Helper()
+	var capabilities Capabilities
+	c.mustRequest("initialize", InitializeRequestArguments{AdapterID: "weilang"}, &capabilities)
+	if !capabilities.SupportsConfigurationDoneRequest || !capabilities.SupportsConditionalBreakpoints {
+		c.t.Errorf("unexpected capabilities %+v", capabilities)
+	}
+	c.event("initialized", nil)
+	c.mustRequest("launch", LaunchRequestArguments{Program: program, StopOnEntry: stopOnEntry}, nil)
+	var body SetBreakpointsResponseBody
+	c.mustRequest("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: program},
+		Breakpoints: breakpoints,
+	}, &body)
+	c.mustRequest("configurationDone", nil, nil)
+	return body
+}
+
+// stopped 等待 stopped 事件，返回暂停的原因和栈顶的函数名、行号
+func (c *testClient) stopped() (StoppedEventBody, StackFrame) {
+	c.t.Helper()
+	var stopped StoppedEventBody
+	c.event("stopped", &stopped)
+	var trace StackTraceResponseBody
+	c.mustRequest("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace)
+	if len(trace.StackFrames) == 0 {
+		c.t.Fatal("empty stack trace")
+	}
+	return stopped, trace.StackFrames[0]
+}
+
+func (c *testClient) variables(ref int) map[string]Variable {
+	c.t.Helper()
+	var body VariablesResponseBody
+	c.mustRequest("variables", VariablesArguments{VariablesReference: ref}, &body)
+	variables := map[string]Variable{}
+	for _, v := range body.Variables {
+		variables[v.Name] = v
+	}
+	return variables
+}
+
+func TestBreakpointsAndInspection(t *testing.T) {
+	program := writeProgram(t, testProgram)
+	c := newTestClient(t)
+	breakpoints := c.start(program, false,
+		SourceBreakpoint{Line: 6, Condition: "i == 2"},
+		SourceBreakpoint{Line: 14},
+		SourceBreakpoint{Line: 5, Condition: "a =="},
+	)
+	if len(breakpoints.Breakpoints) != 3 || !breakpoints.Breakpoints[0].Verified ||
+		!breakpoints.Breakpoints[1].Verified || breakpoints.Breakpoints[2].Verified {
+		t.Fatalf("unexpected breakpoints %+v", breakpoints)
+	}
+
+	stopped, frame := c.stopped()
+	if stopped.Reason != "breakpoint" || len(stopped.HitBreakpointIDs) != 1 ||
+		stopped.HitBreakpointIDs[0] != breakpoints.Breakpoints[0].ID {
+		t.Errorf("unexpected stopped event %+v", stopped)
+	}
+	if frame.Name != "add" || frame.Line != 6 || frame.Column != 5 || frame.Source.Path != program {
+		t.Errorf("unexpected top frame %+v", frame)
+	}
+
+	var threads ThreadsResponseBody
+	c.mustRequest("threads", nil, &threads)
+	if len(threads.Threads) != 1 || threads.Threads[0].ID != threadID {
+		t.Errorf("unexpected threads %+v", threads)
+	}
+	var trace StackTraceResponseBody
+	c.mustRequest("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace)
+	if trace.TotalFrames != 2 || trace.StackFrames[1].Name != "<module>" || trace.StackFrames[1].Line != 11 {
+		t.Errorf("unexpected stack trace %+v", trace)
+	}
+	c.mustRequest("stackTrace", StackTraceArguments{ThreadID: threadID, StartFrame: 1, Levels: 1}, &trace)
+	if len(trace.StackFrames) != 1 || trace.StackFrames[0].ID != 2 {
+		t.Errorf("unexpected stack trace %+v", trace)
+	}
+
+	var scopes ScopesResponseBody
+	c.mustRequest("scopes", ScopesArguments{FrameID: 1}, &scopes)
+	if len(scopes.Scopes) != 2 || scopes.Scopes[0].Name != "Locals" || scopes.Scopes[1].Name != "Globals" {
+		t.Fatalf("unexpected scopes %+v", scopes)
+	}
+	locals := c.variables(scopes.Scopes[0].VariablesReference)
+	if len(locals) != 3 || locals["a"].Value != "1" || locals["b"].Value != "2" ||
+		locals["s"].Value != "3" || locals["s"].Type != "int" {
+		t.Errorf("unexpected locals %+v", locals)
+	}
+	globals := c.variables(scopes.Scopes[1].VariablesReference)
+	if globals["total"].Value != "1" || globals["i"].Value != "2" || globals["items"].VariablesReference == 0 {
+		t.Fatalf("unexpected globals %+v", globals)
+	}
+	items := c.variables(globals["items"].VariablesReference)
+	if len(items) != 3 || items["[0]"].Value != "1" || items["[1]"].Value != "two" || items["[2]"].VariablesReference == 0 {
+		t.Fatalf("unexpected items %+v", items)
+	}
+	dict := c.variables(items["[2]"].VariablesReference)
+	if len(dict) != 1 || dict["k"].Value != "3" {
+		t.Errorf("unexpected dict %+v", dict)
+	}
+	if errMsg := c.request("variables", VariablesArguments{VariablesReference: 100}, nil); errMsg == "" {
+		t.Errorf("expected error for invalid variables reference")
+	}
+
+	var result EvaluateResponseBody
+	c.mustRequest("evaluate", EvaluateArguments{Expression: "a * 10 + s", FrameID: 1}, &result)
+	if result.Result != "13" {
+		t.Errorf("evaluate = %+v", result)
+	}
+	c.mustRequest("evaluate", EvaluateArguments{Expression: "total + i", FrameID: 2}, &result)
+	if result.Result != "3" {
+		t.Errorf("evaluate = %+v", result)
+	}
+	if errMsg := c.request("evaluate", EvaluateArguments{Expression: "a", FrameID: 2}, nil); errMsg != "undefined: 'a'" {
+		t.Errorf("evaluate error = %q", errMsg)
+	}
+
+	c.mustRequest("next", nil, nil)
+	stopped, frame = c.stopped()
+	if stopped.Reason != "step" || frame.Name != "<module>" || frame.Line != 12 {
+		t.Errorf("after next: %+v %+v", stopped, frame)
+	}
+	c.mustRequest("continue", nil, nil)
+	stopped, frame = c.stopped()
+	if stopped.Reason != "breakpoint" || frame.Line != 14 {
+		t.Errorf("after continue: %+v %+v", stopped, frame)
+	}
+	c.mustRequest("continue", nil, nil)
+	var exited ExitedEventBody
+	c.event("exited", &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("exit code = %d", exited.ExitCode)
+	}
+	c.event("terminated", nil)
+	if len(c.output) != 1 || c.output[0].Category != "stdout" || c.output[0].Output != "3\n" {
+		t.Errorf("unexpected output %+v", c.output)
+	}
+	if errMsg := c.request("stackTrace", StackTraceArguments{ThreadID: threadID}, nil); errMsg != errNotPaused.Error() {
+		t.Errorf("stackTrace after exit: %q", errMsg)
+	}
+	c.mustRequest("disconnect", nil, nil)
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve() = %v", err)
+	}
+}
+
+func TestStepping(t *testing.T) {
+	program := writeProgram(t, testProgram)
+	c := newTestClient(t)
+	c.start(program, true)
+	steps := []struct {
+		command string
+		reason  string
+		name    string
+		line    int
+	}{
+		{"", "entry", "<module>", 1},
+		{"next", "step", "<module>", 2},
+		{"next", "step", "<module>", 4},
+		{"next", "step", "<module>", 9},
+		{"next", "step", "<module>", 10},
+		{"next", "step", "<module>", 11},
+		{"stepIn", "step", "add", 5},
+		{"stepOut", "step", "<module>", 12},
+	}
+	for _, step := range steps {
+		if step.command != "" {
+			c.mustRequest(step.command, nil, nil)
+		}
+		stopped, frame := c.stopped()
+		if stopped.Reason != step.reason || frame.Name != step.name || frame.Line != step.line {
+			t.Errorf("after %s: got %s %s:%d, want %s %s:%d", step.command,
+				stopped.Reason, frame.Name, frame.Line, step.reason, step.name, step.line)
+		}
+	}
+	c.mustRequest("disconnect", nil, nil)
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve() = %v", err)
+	}
+}
+
+// TestStepOverSameDepth 从被调用函数的 return 单步执行，同一个表达式中对同一个函数的下一次调用不算当前函数
+func TestStepOverSameDepth(t *testing.T) {
+	program := writeProgram(t, "fn f(n) {\n    return n\n}\n\nvar a = f(1) + f(2)\nprint(a)\n")
+	c := newTestClient(t)
+	c.start(program, false, SourceBreakpoint{Line: 2, Condition: "n == 1"})
+	stopped, frame := c.stopped()
+	if stopped.Reason != "breakpoint" || frame.Name != "f" || frame.Line != 2 {
+		t.Fatalf("unexpected stop %+v %+v", stopped, frame)
+	}
+	c.mustRequest("next", nil, nil)
+	stopped, frame = c.stopped()
+	if stopped.Reason != "step" || frame.Name != "<module>" || frame.Line != 6 {
+		t.Errorf("after next: %+v %+v", stopped, frame)
+	}
+	var trace StackTraceResponseBody
+	c.mustRequest("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace)
+	if trace.TotalFrames != 1 {
+		t.Errorf("unexpected stack trace %+v", trace)
+	}
+	c.mustRequest("disconnect", nil, nil)
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve() = %v", err)
+	}
+}
+
+func TestPauseAndDisconnect(t *testing.T) {
+	program := writeProgram(t, "var x = 0\nwhile (true) {\n    x = x + 1\n}\n")
+	c := newTestClient(t)
+	c.start(program, false)
+	if errMsg := c.request("continue", nil, nil); errMsg != errNotPaused.Error() {
+		t.Errorf("continue while running: %q", errMsg)
+	}
+	c.mustRequest("pause", nil, nil)
+	stopped, frame := c.stopped()
+	if stopped.Reason != "pause" || frame.Line != 3 {
+		t.Errorf("unexpected stop %+v %+v", stopped, frame)
+	}
+	// 断开连接时结束暂停中的程序
+	c.mustRequest("disconnect", nil, nil)
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve() = %v", err)
+	}
+}
+
+func TestDisconnectWhileRunning(t *testing.T) {
+	program := writeProgram(t, "var x = 0\nwhile (true) {\n    x = x + 1\n}\n")
+	c := newTestClient(t)
+	c.start(program, false)
+	c.mustRequest("disconnect", nil, nil)
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve() = %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := newTestClient(t)
+	if errMsg := c.request("threads", nil, nil); errMsg != "not initialized" {
+		t.Errorf("request before initialize: %q", errMsg)
+	}
+	c.mustRequest("initialize", InitializeRequestArguments{AdapterID: "weilang"}, nil)
+	if errMsg := c.request("launch", LaunchRequestArguments{Program: "does-not-exist.wei"}, nil); !strings.Contains(errMsg, "can't open file") {
+		t.Errorf("launch missing file: %q", errMsg)
+	}
+	if errMsg := c.request("unknownCommand", nil, nil); !strings.Contains(errMsg, "unsupported command") {
+		t.Errorf("unknown command: %q", errMsg)
+	}
+	if errMsg := c.request("stackTrace", StackTraceArguments{ThreadID: threadID}, nil); errMsg != errNotPaused.Error() {
+		t.Errorf("stackTrace before launch: %q", errMsg)
+	}
+
+	// 运行出错时错误栈作为 stderr 输出，退出码为 1
+	program := writeProgram(t, "var x = 1\nx = y\n")
+	c.mustRequest("launch", LaunchRequestArguments{Program: program}, nil)
+	c.mustRequest("configurationDone", nil, nil)
+	var exited ExitedEventBody
+	c.event("exited", &exited)
+	if exited.ExitCode != 1 {
+		t.Errorf("exit code = %d", exited.ExitCode)
+	}
+	if len(c.output) == 0 || c.output[0].Category != "stderr" || !strings.Contains(c.output[0].Output, "Traceback") {
+		t.Errorf("unexpected output %+v", c.output)
+	}
+	_ = c.conn.writer.(io.Closer).Close()
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve() = %v", err)
+	}
+}
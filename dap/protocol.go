@@ -0,0 +1,171 @@
+package dap
+
+import "encoding/json"
+
+// 以下为 DAP 协议中用到的数据结构，只包含本服务器使用的字段
+// https://microsoft.github.io/debug-adapter-protocol/specification
+
+// message DAP 消息，请求、响应、事件共用一个结构
+type message struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"`
+	// 请求
+	Command   string          `json:"command,omitempty"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	// 响应
+	RequestSeq int             `json:"request_seq,omitempty"`
+	Success    *bool           `json:"success,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	// 事件
+	Event string `json:"event,omitempty"`
+}
+
+type InitializeRequestArguments struct {
+	ClientID  string `json:"clientID,omitempty"`
+	AdapterID string `json:"adapterID"`
+	// LinesStartAt1 ColumnsStartAt1 没有设置时为 true
+	LinesStartAt1   *bool `json:"linesStartAt1,omitempty"`
+	ColumnsStartAt1 *bool `json:"columnsStartAt1,omitempty"`
+}
+
+type Capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest,omitempty"`
+	SupportsConditionalBreakpoints   bool `json:"supportsConditionalBreakpoints,omitempty"`
+	SupportsEvaluateForHovers        bool `json:"supportsEvaluateForHovers,omitempty"`
+	SupportsTerminateRequest         bool `json:"supportsTerminateRequest,omitempty"`
+}
+
+// LaunchRequestArguments launch 请求的参数，对应 launch.json 中的配置
+type LaunchRequestArguments struct {
+	// Program 要执行的文件
+	Program string `json:"program"`
+	// Args 通过 os.args 传给脚本的参数
+	Args []string `json:"args,omitempty"`
+	// StopOnEntry 在执行第一条语句之前暂停
+	StopOnEntry bool `json:"stopOnEntry,omitempty"`
+	// NoDebug 不调试，忽略断点
+	NoDebug bool `json:"noDebug,omitempty"`
+}
+
+type Source struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+type SourceBreakpoint struct {
+	Line      int    `json:"line"`
+	Condition string `json:"condition,omitempty"`
+}
+
+type SetBreakpointsArguments struct {
+	Source      Source             `json:"source"`
+	Breakpoints []SourceBreakpoint `json:"breakpoints,omitempty"`
+}
+
+type Breakpoint struct {
+	ID       int     `json:"id,omitempty"`
+	Verified bool    `json:"verified"`
+	Message  string  `json:"message,omitempty"`
+	Source   *Source `json:"source,omitempty"`
+	Line     int     `json:"line,omitempty"`
+}
+
+type SetBreakpointsResponseBody struct {
+	Breakpoints []Breakpoint `json:"breakpoints"`
+}
+
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ThreadsResponseBody struct {
+	Threads []Thread `json:"threads"`
+}
+
+type StackTraceArguments struct {
+	ThreadID   int `json:"threadId"`
+	StartFrame int `json:"startFrame,omitempty"`
+	// Levels 返回的栈帧个数，为 0 时返回所有栈帧
+	Levels int `json:"levels,omitempty"`
+}
+
+type StackFrame struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Source *Source `json:"source,omitempty"`
+	Line   int     `json:"line"`
+	Column int     `json:"column"`
+}
+
+type StackTraceResponseBody struct {
+	StackFrames []StackFrame `json:"stackFrames"`
+	TotalFrames int          `json:"totalFrames"`
+}
+
+type ScopesArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+type Scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type ScopesResponseBody struct {
+	Scopes []Scope `json:"scopes"`
+}
+
+type VariablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+type Variable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+	// VariablesReference 大于 0 时可以继续展开查看元素、属性
+	VariablesReference int `json:"variablesReference"`
+}
+
+type VariablesResponseBody struct {
+	Variables []Variable `json:"variables"`
+}
+
+type EvaluateArguments struct {
+	Expression string `json:"expression"`
+	// FrameID 没有设置时在正在执行的栈帧中求值
+	FrameID int    `json:"frameId,omitempty"`
+	Context string `json:"context,omitempty"`
+}
+
+type EvaluateResponseBody struct {
+	Result             string `json:"result"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+type ContinueResponseBody struct {
+	AllThreadsContinued bool `json:"allThreadsContinued"`
+}
+
+type StoppedEventBody struct {
+	Reason            string `json:"reason"`
+	Description       string `json:"description,omitempty"`
+	ThreadID          int    `json:"threadId"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+	HitBreakpointIDs  []int  `json:"hitBreakpointIds,omitempty"`
+	Text              string `json:"text,omitempty"`
+}
+
+type OutputEventBody struct {
+	// Category stdout 或 stderr
+	Category string `json:"category"`
+	Output   string `json:"output"`
+}
+
+type ExitedEventBody struct {
+	ExitCode int `json:"exitCode"`
+}
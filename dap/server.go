@@ -0,0 +1,343 @@
+// Package dap 实现 Weilang 的 Debug Adapter Protocol 服务器，通过标准输入输出与编辑器通信
+//
+// 支持的请求
+//
+//	initialize launch configurationDone disconnect terminate
+//	setBreakpoints （支持条件断点）
+//	threads stackTrace scopes variables evaluate
+//	continue next stepIn stepOut pause
+//
+// 程序在单独的 goroutine 中执行，暂停时查看变量、求值等请求交给程序的 goroutine 执行
+package dap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"weilang/debugger"
+	"weilang/interpreter"
+)
+
+// threadID Weilang 只有一个线程
+const threadID = 1
+
+var errNotPaused = errors.New("program is not paused")
+
+// command 在暂停的程序的 goroutine 中执行的命令，resume 为 true 时按照 action 继续执行
+type command func(d *debugger.Debugger) (action debugger.Action, resume bool)
+
+// Server DAP 服务器，一次只调试一个程序
+type Server struct {
+	conn     *conn
+	debugger *debugger.Debugger
+
+	initialized bool
+	// lineBase columnBase 客户端的行号、列号从 1 还是从 0 开始
+	lineBase   int
+	columnBase int
+
+	launch     *LaunchRequestArguments
+	configured bool
+	started    bool
+	// done 程序结束时关闭
+	done chan struct{}
+
+	// paused 程序暂停，正在等待 commands
+	paused      atomic.Bool
+	terminating atomic.Bool
+	commands    chan command
+	// references 暂停时可以展开的变量，编号为下标加 1 ，只在程序的 goroutine 中访问
+	references []reference
+}
+
+// NewServer 创建从 in 读取请求、向 out 写入响应和事件的服务器
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{
+		conn:       newConn(in, out),
+		lineBase:   1,
+		columnBase: 1,
+		done:       make(chan struct{}),
+		commands:   make(chan command),
+	}
+	s.debugger = debugger.New(s, false)
+	return s
+}
+
+// Serve 处理请求直到收到 disconnect 请求或者连接关闭，返回之前结束正在调试的程序
+func (s *Server) Serve() error {
+	defer s.terminate()
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if errors.Is(err, errInvalidMessage) {
+				continue
+			}
+			return err
+		}
+		if msg.Type != "request" {
+			continue
+		}
+		body, after, err := s.handleRequest(msg)
+		if err := s.conn.respond(msg, body, err); err != nil {
+			return err
+		}
+		if after != nil {
+			after()
+		}
+		if msg.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// handleRequest 处理请求，after 不为空时在回复之后调用
+func (s *Server) handleRequest(msg *message) (body any, after func(), err error) {
+	if msg.Command == "initialize" {
+		var args InitializeRequestArguments
+		if err := unmarshalArguments(msg, &args); err != nil {
+			return nil, nil, err
+		}
+		if args.LinesStartAt1 != nil && !*args.LinesStartAt1 {
+			s.lineBase = 0
+		}
+		if args.ColumnsStartAt1 != nil && !*args.ColumnsStartAt1 {
+			s.columnBase = 0
+		}
+		s.initialized = true
+		// 回复之后通知客户端可以设置断点了
+		return s.capabilities(), func() { _ = s.conn.event("initialized", nil) }, nil
+	}
+	if !s.initialized {
+		return nil, nil, errors.New("not initialized")
+	}
+	switch msg.Command {
+	case "launch":
+		var args LaunchRequestArguments
+		if err := unmarshalArguments(msg, &args); err != nil {
+			return nil, nil, err
+		}
+		return nil, s.start, s.launchProgram(args)
+	case "configurationDone":
+		s.configured = true
+		return nil, s.start, nil
+	case "disconnect", "terminate":
+		return nil, s.terminate, nil
+	case "setBreakpoints":
+		var args SetBreakpointsArguments
+		if err := unmarshalArguments(msg, &args); err != nil {
+			return nil, nil, err
+		}
+		return s.setBreakpoints(args), nil, nil
+	case "threads":
+		return ThreadsResponseBody{Threads: []Thread{{ID: threadID, Name: "main"}}}, nil, nil
+	case "stackTrace":
+		var args StackTraceArguments
+		if err := unmarshalArguments(msg, &args); err != nil {
+			return nil, nil, err
+		}
+		body, err := s.inspect(func(d *debugger.Debugger) (any, error) {
+			return s.stackTrace(d, args), nil
+		})
+		return body, nil, err
+	case "scopes":
+		var args ScopesArguments
+		if err := unmarshalArguments(msg, &args); err != nil {
+			return nil, nil, err
+		}
+		body, err := s.inspect(func(d *debugger.Debugger) (any, error) {
+			return s.scopes(d, args)
+		})
+		return body, nil, err
+	case "variables":
+		var args VariablesArguments
+		if err := unmarshalArguments(msg, &args); err != nil {
+			return nil, nil, err
+		}
+		body, err := s.inspect(func(d *debugger.Debugger) (any, error) {
+			return s.variables(d, args)
+		})
+		return body, nil, err
+	case "evaluate":
+		var args EvaluateArguments
+		if err := unmarshalArguments(msg, &args); err != nil {
+			return nil, nil, err
+		}
+		body, err := s.inspect(func(d *debugger.Debugger) (any, error) {
+			return s.evaluate(d, args)
+		})
+		return body, nil, err
+	case "continue":
+		return s.resume(debugger.Continue, ContinueResponseBody{AllThreadsContinued: true})
+	case "next":
+		return s.resume(debugger.StepOver, nil)
+	case "stepIn":
+		return s.resume(debugger.StepIn, nil)
+	case "stepOut":
+		return s.resume(debugger.StepOut, nil)
+	case "pause":
+		s.debugger.Pause()
+		return nil, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported command: %s", msg.Command)
+	}
+}
+
+func unmarshalArguments(msg *message, v any) error {
+	if len(msg.Arguments) == 0 {
+		return errors.New("missing arguments")
+	}
+	return json.Unmarshal(msg.Arguments, v)
+}
+
+func (s *Server) capabilities() Capabilities {
+	return Capabilities{
+		SupportsConfigurationDoneRequest: true,
+		SupportsConditionalBreakpoints:   true,
+		SupportsEvaluateForHovers:        true,
+		SupportsTerminateRequest:         true,
+	}
+}
+
+func (s *Server) launchProgram(args LaunchRequestArguments) error {
+	if s.launch != nil {
+		return errors.New("program already launched")
+	}
+	if args.Program == "" {
+		return errors.New("missing program")
+	}
+	if _, err := os.Stat(args.Program); err != nil {
+		return fmt.Errorf("can't open file '%s'", args.Program)
+	}
+	s.launch = &args
+	s.debugger.SetStopOnEntry(args.StopOnEntry && !args.NoDebug)
+	return nil
+}
+
+// start 收到 launch 和 configurationDone 之后开始执行程序
+func (s *Server) start() {
+	if s.started || s.launch == nil || !s.configured {
+		return
+	}
+	s.started = true
+	launch := s.launch
+	go func() {
+		code := interpreter.Run(launch.Program, interpreter.Options{
+			Args:   launch.Args,
+			Stdout: outputWriter{conn: s.conn, category: "stdout"},
+			Stderr: outputWriter{conn: s.conn, category: "stderr"},
+			Tracer: s.debugger,
+		})
+		_ = s.conn.event("exited", ExitedEventBody{ExitCode: code})
+		_ = s.conn.event("terminated", nil)
+		close(s.done)
+	}()
+}
+
+// terminate 结束正在执行的程序，等待程序结束
+func (s *Server) terminate() {
+	if !s.started {
+		return
+	}
+	s.terminating.Store(true)
+	s.debugger.Pause()
+	if s.paused.CompareAndSwap(true, false) {
+		s.commands <- func(d *debugger.Debugger) (debugger.Action, bool) {
+			return debugger.Quit, true
+		}
+	}
+	<-s.done
+}
+
+// Stopped 实现 debugger.Handler ，通知客户端程序暂停，在程序的 goroutine 中执行客户端的命令直到继续执行
+func (s *Server) Stopped(d *debugger.Debugger, stop *debugger.Stop) debugger.Action {
+	if s.terminating.Load() {
+		return debugger.Quit
+	}
+	if s.launch.NoDebug {
+		return debugger.Continue
+	}
+	s.references = nil
+	s.paused.Store(true)
+	// 设置 paused 的同时 terminate 可能已经在等待程序暂停
+	if s.terminating.Load() && s.paused.CompareAndSwap(true, false) {
+		return debugger.Quit
+	}
+	body := StoppedEventBody{Reason: stop.Reason, ThreadID: threadID, AllThreadsStopped: true}
+	if stop.Breakpoint != nil {
+		body.HitBreakpointIDs = []int{stop.Breakpoint.ID}
+	}
+	if stop.ConditionErr != nil {
+		body.Text = fmt.Sprintf("error in condition '%s': %v", stop.Breakpoint.Condition, stop.ConditionErr)
+	}
+	_ = s.conn.event("stopped", body)
+	for cmd := range s.commands {
+		if action, resume := cmd(d); resume {
+			return action
+		}
+	}
+	return debugger.Quit
+}
+
+// inspect 在暂停的程序的 goroutine 中执行 f
+func (s *Server) inspect(f func(d *debugger.Debugger) (any, error)) (any, error) {
+	if !s.paused.Load() {
+		return nil, errNotPaused
+	}
+	type result struct {
+		body any
+		err  error
+	}
+	results := make(chan result, 1)
+	s.commands <- func(d *debugger.Debugger) (debugger.Action, bool) {
+		body, err := f(d)
+		results <- result{body, err}
+		return debugger.Continue, false
+	}
+	r := <-results
+	return r.body, r.err
+}
+
+// resume 回复请求之后按照 action 继续执行程序
+func (s *Server) resume(action debugger.Action, body any) (any, func(), error) {
+	if !s.paused.CompareAndSwap(true, false) {
+		return nil, nil, errNotPaused
+	}
+	return body, func() {
+		s.commands <- func(d *debugger.Debugger) (debugger.Action, bool) {
+			return action, true
+		}
+	}, nil
+}
+
+func (s *Server) setBreakpoints(args SetBreakpointsArguments) SetBreakpointsResponseBody {
+	path, _ := filepath.Abs(args.Source.Path)
+	s.debugger.ClearBreakpoints(path)
+	body := SetBreakpointsResponseBody{Breakpoints: []Breakpoint{}}
+	source := &Source{Name: filepath.Base(path), Path: path}
+	for _, sb := range args.Breakpoints {
+		bp, err := s.debugger.SetBreakpoint(path, sb.Line-s.lineBase, sb.Condition)
+		if err != nil {
+			body.Breakpoints = append(body.Breakpoints, Breakpoint{
+				Message: err.Error(),
+				Source:  source,
+				Line:    sb.Line,
+			})
+			continue
+		}
+		body.Breakpoints = append(body.Breakpoints, Breakpoint{
+			ID:       bp.ID,
+			Verified: true,
+			Source:   source,
+			Line:     sb.Line,
+		})
+	}
+	return body
+}
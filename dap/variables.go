@@ -0,0 +1,150 @@
+package dap
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"weilang/debugger"
+	"weilang/object"
+)
+
+// reference 可以展开的变量，scope 不为空时为栈帧的局部变量或全局变量，否则为 value 的元素、属性
+type reference struct {
+	frame int
+	scope string
+	value object.Object
+}
+
+const (
+	scopeLocals  = "Locals"
+	scopeGlobals = "Globals"
+)
+
+// stackTrace 返回调用栈，栈帧的编号为调用栈中的下标加 1 ，只在程序暂停期间有效
+func (s *Server) stackTrace(d *debugger.Debugger, args StackTraceArguments) StackTraceResponseBody {
+	frames := d.Frames()
+	body := StackTraceResponseBody{StackFrames: []StackFrame{}, TotalFrames: len(frames)}
+	for i := args.StartFrame; i < len(frames); i++ {
+		if args.Levels > 0 && len(body.StackFrames) >= args.Levels {
+			break
+		}
+		frame := frames[i]
+		body.StackFrames = append(body.StackFrames, StackFrame{
+			ID:     i + 1,
+			Name:   frame.Name,
+			Source: &Source{Name: filepath.Base(frame.Filename), Path: frame.Filename},
+			Line:   frame.Line + s.lineBase,
+			Column: frame.Column + s.columnBase,
+		})
+	}
+	return body
+}
+
+func (s *Server) scopes(d *debugger.Debugger, args ScopesArguments) (ScopesResponseBody, error) {
+	frame := args.FrameID - 1
+	if frame < 0 || frame >= len(d.Frames()) {
+		return ScopesResponseBody{}, fmt.Errorf("invalid frame id %d", args.FrameID)
+	}
+	return ScopesResponseBody{Scopes: []Scope{
+		{Name: scopeLocals, VariablesReference: s.reference(reference{frame: frame, scope: scopeLocals})},
+		{Name: scopeGlobals, VariablesReference: s.reference(reference{frame: frame, scope: scopeGlobals})},
+	}}, nil
+}
+
+func (s *Server) variables(d *debugger.Debugger, args VariablesArguments) (VariablesResponseBody, error) {
+	body := VariablesResponseBody{Variables: []Variable{}}
+	n := args.VariablesReference - 1
+	if n < 0 || n >= len(s.references) {
+		return body, fmt.Errorf("invalid variables reference %d", args.VariablesReference)
+	}
+	ref := s.references[n]
+	if ref.scope != "" {
+		var variables []debugger.Variable
+		var err error
+		if ref.scope == scopeLocals {
+			variables, err = d.Locals(ref.frame)
+		} else {
+			variables, err = d.Globals(ref.frame)
+		}
+		if err != nil {
+			return body, err
+		}
+		for _, v := range variables {
+			body.Variables = append(body.Variables, s.variable(v.Name, v.Value))
+		}
+		return body, nil
+	}
+	switch value := ref.value.(type) {
+	case *object.List:
+		for i, element := range value.Elements {
+			body.Variables = append(body.Variables, s.variable(fmt.Sprintf("[%d]", i), element))
+		}
+	case *object.Tuple:
+		for i, element := range value.Elements {
+			body.Variables = append(body.Variables, s.variable(fmt.Sprintf("[%d]", i), element))
+		}
+	case object.SetLike:
+		for i, element := range value.Elements() {
+			body.Variables = append(body.Variables, s.variable(fmt.Sprintf("[%d]", i), element))
+		}
+	case *object.Dict:
+		for _, pair := range value.Pairs() {
+			body.Variables = append(body.Variables, s.variable(pair.Key.String(), pair.Value))
+		}
+	case *object.Instance:
+		for _, name := range value.MemberNames() {
+			if name == "__class__" {
+				continue
+			}
+			body.Variables = append(body.Variables, s.variable(name, value.GetAttribute(name)))
+		}
+	}
+	return body, nil
+}
+
+func (s *Server) evaluate(d *debugger.Debugger, args EvaluateArguments) (EvaluateResponseBody, error) {
+	frame := 0
+	if args.FrameID > 0 {
+		frame = args.FrameID - 1
+	}
+	value, err := d.Evaluate(frame, args.Expression)
+	if err != nil {
+		return EvaluateResponseBody{}, err
+	}
+	v := s.variable("", value)
+	return EvaluateResponseBody{Result: v.Value, Type: v.Type, VariablesReference: v.VariablesReference}, nil
+}
+
+// variable 转换为 DAP 的变量，列表、元组、字典、集合、实例可以展开
+func (s *Server) variable(name string, value object.Object) Variable {
+	if value == nil {
+		return Variable{Name: name, Value: "<uninitialized>"}
+	}
+	v := Variable{Name: name, Value: value.String(), Type: string(value.Type())}
+	switch value := value.(type) {
+	case *object.List:
+		if len(value.Elements) > 0 {
+			v.VariablesReference = s.reference(reference{value: value})
+		}
+	case *object.Tuple:
+		if len(value.Elements) > 0 {
+			v.VariablesReference = s.reference(reference{value: value})
+		}
+	case *object.Dict:
+		if value.Len() > 0 {
+			v.VariablesReference = s.reference(reference{value: value})
+		}
+	case object.SetLike:
+		if value.Len() > 0 {
+			v.VariablesReference = s.reference(reference{value: value})
+		}
+	case *object.Instance:
+		v.VariablesReference = s.reference(reference{value: value})
+	}
+	return v
+}
+
+func (s *Server) reference(ref reference) int {
+	s.references = append(s.references, ref)
+	return len(s.references)
+}
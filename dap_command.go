@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"weilang/dap"
+	"weilang/interpreter"
+)
+
+// dapCommand 执行 weilang dap ，通过标准输入输出提供 Debug Adapter Protocol 服务
+func dapCommand(args []string) int {
+	if len(args) > 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "Usage:\n    weilang dap                             启动 Debug Adapter Protocol 服务器")
+		return interpreter.ExitUsageError
+	}
+	if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "weilang: %v\n", err)
+		return interpreter.ExitRuntimeError
+	}
+	return interpreter.ExitOK
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"weilang/debugger"
+	"weilang/interpreter"
+)
+
+const debugUsage = `Usage:
+    weilang debug [options] <filename> [args...] 在调试器中执行文件，输入 help 查看调试命令
+
+Options:
+`
+
+// debugCommand 执行 weilang debug ，在第一条语句之前暂停，从标准输入读取调试命令
+func debugCommand(args []string) int {
+	flags := flag.NewFlagSet("weilang debug", flag.ContinueOnError)
+	errorFormat := flags.String("error-format", interpreter.ErrorFormatText,
+		"错误信息的输出格式: text 或 json")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(flags.Output(), debugUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return interpreter.ExitOK
+		}
+		return interpreter.ExitUsageError
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return interpreter.ExitUsageError
+	}
+	if *errorFormat != interpreter.ErrorFormatText && *errorFormat != interpreter.ErrorFormatJSON {
+		_, _ = fmt.Fprintf(os.Stderr, "weilang: invalid error format '%s'\n", *errorFormat)
+		flags.Usage()
+		return interpreter.ExitUsageError
+	}
+	d := debugger.New(debugger.NewConsole(os.Stdin, os.Stdout), true)
+	return interpreter.Run(flags.Arg(0), interpreter.Options{
+		Args:        flags.Args()[1:],
+		ErrorFormat: *errorFormat,
+		Tracer:      d,
+	})
+}
@@ -0,0 +1,272 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const prompt = "(wdb) "
+
+const consoleHelp = `Commands:
+    b, break [file:]line [if cond]  设置断点，没有参数时列出所有断点
+    d, delete [id...]               删除断点，没有参数时删除所有断点
+    c, continue                     继续执行到下一个断点
+    s, step                         执行到下一条语句，进入调用的函数
+    n, next                         执行到下一条语句，不进入调用的函数
+    finish, out                     执行到当前函数返回
+    bt, backtrace, where            输出调用栈
+    f, frame [n]                    选择第 n 个栈帧，没有参数时输出当前栈帧
+    up, down                        选择外层、内层的栈帧
+    locals                          输出当前栈帧的局部变量
+    globals                         输出当前栈帧所在模块的全局变量
+    p, print expr                   在当前栈帧中求值表达式
+    l, list                         输出当前栈帧附近的源码
+    q, quit                         结束程序
+    h, help                         输出帮助
+空行重复上一条命令`
+
+// Console 命令行调试界面，暂停时从 in 读取命令，向 out 输出结果
+type Console struct {
+	in  *bufio.Scanner
+	out io.Writer
+	// frame 当前选择的栈帧，每次暂停时重置为正在执行的栈帧
+	frame int
+	// last 上一条命令，输入空行时重复执行
+	last string
+}
+
+// NewConsole 创建命令行调试界面
+func NewConsole(in io.Reader, out io.Writer) *Console {
+	return &Console{in: bufio.NewScanner(in), out: out}
+}
+
+// Stopped 实现 Handler ，输出暂停的位置，执行命令直到继续执行
+func (c *Console) Stopped(d *Debugger, stop *Stop) Action {
+	c.frame = 0
+	frames := d.Frames()
+	switch stop.Reason {
+	case ReasonBreakpoint:
+		c.printf("Breakpoint %d, ", stop.Breakpoint.ID)
+		if stop.ConditionErr != nil {
+			c.printf("error in condition '%s': %v\n", stop.Breakpoint.Condition, stop.ConditionErr)
+		}
+	case ReasonPause:
+		c.printf("Paused, ")
+	}
+	if len(frames) > 0 {
+		c.printFrame(frames[0])
+	}
+	for {
+		c.printf(prompt)
+		if !c.in.Scan() {
+			c.printf("\n")
+			return Quit
+		}
+		line := strings.TrimSpace(c.in.Text())
+		if line == "" {
+			line = c.last
+		}
+		c.last = line
+		if line == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+		switch name {
+		case "c", "continue":
+			return Continue
+		case "s", "step":
+			return StepIn
+		case "n", "next":
+			return StepOver
+		case "finish", "out":
+			return StepOut
+		case "q", "quit":
+			return Quit
+		case "b", "break":
+			c.breakCommand(d, arg)
+		case "d", "delete":
+			c.deleteCommand(d, arg)
+		case "bt", "backtrace", "where":
+			c.backtrace(d)
+		case "f", "frame":
+			if arg == "" {
+				c.selectFrame(d, c.frame)
+				break
+			}
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				c.printf("invalid frame number '%s'\n", arg)
+				break
+			}
+			c.selectFrame(d, n)
+		case "up":
+			c.selectFrame(d, c.frame+1)
+		case "down":
+			c.selectFrame(d, c.frame-1)
+		case "locals":
+			variables, err := d.Locals(c.frame)
+			c.printVariables(variables, err)
+		case "globals":
+			variables, err := d.Globals(c.frame)
+			c.printVariables(variables, err)
+		case "p", "print":
+			if arg == "" {
+				c.printf("usage: print expr\n")
+				break
+			}
+			value, err := d.Evaluate(c.frame, arg)
+			if err != nil {
+				c.printf("error: %v\n", err)
+				break
+			}
+			c.printf("%s\n", value.String())
+		case "l", "list":
+			c.list(d)
+		case "h", "help":
+			c.printf("%s\n", consoleHelp)
+		default:
+			c.printf("unknown command '%s', type help for a list of commands\n", name)
+		}
+	}
+}
+
+func (c *Console) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(c.out, format, args...)
+}
+
+// printFrame 输出栈帧的位置和正在执行的那一行源码
+func (c *Console) printFrame(frame Frame) {
+	c.printf("%s at %s:%d\n", frame.Name, displayName(frame.Filename), frame.Line+1)
+	if source := frame.Source(frame.Line); source != "" {
+		c.printf("%4d\t%s\n", frame.Line+1, source)
+	}
+}
+
+// breakCommand 设置断点，参数为 [file:]line [if cond]
+func (c *Console) breakCommand(d *Debugger, arg string) {
+	if arg == "" {
+		breakpoints := d.Breakpoints()
+		if len(breakpoints) == 0 {
+			c.printf("No breakpoints.\n")
+		}
+		for _, bp := range breakpoints {
+			c.printf("%d\t%s:%d", bp.ID, displayName(bp.Filename), bp.Line+1)
+			if bp.Condition != "" {
+				c.printf(" if %s", bp.Condition)
+			}
+			c.printf("\thit %d times\n", bp.Hits)
+		}
+		return
+	}
+	spec, condition, _ := strings.Cut(arg, " if ")
+	spec = strings.TrimSpace(spec)
+	condition = strings.TrimSpace(condition)
+	var filename string
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		filename, spec = spec[:i], spec[i+1:]
+	} else if frames := d.Frames(); len(frames) > c.frame {
+		filename = frames[c.frame].Filename
+	}
+	line, err := strconv.Atoi(spec)
+	if err != nil || line < 1 || filename == "" {
+		c.printf("usage: break [file:]line [if cond]\n")
+		return
+	}
+	bp, err := d.SetBreakpoint(filename, line-1, condition)
+	if err != nil {
+		c.printf("error: %v\n", err)
+		return
+	}
+	c.printf("Breakpoint %d at %s:%d\n", bp.ID, displayName(bp.Filename), bp.Line+1)
+}
+
+func (c *Console) deleteCommand(d *Debugger, arg string) {
+	if arg == "" {
+		d.ClearBreakpoints("")
+		return
+	}
+	for _, field := range strings.Fields(arg) {
+		id, err := strconv.Atoi(field)
+		if err != nil || !d.ClearBreakpoint(id) {
+			c.printf("No breakpoint number %s.\n", field)
+		}
+	}
+}
+
+func (c *Console) backtrace(d *Debugger) {
+	for i, frame := range d.Frames() {
+		marker := " "
+		if i == c.frame {
+			marker = "*"
+		}
+		c.printf("%s #%d  %s at %s:%d\n", marker, i, frame.Name, displayName(frame.Filename), frame.Line+1)
+	}
+}
+
+func (c *Console) selectFrame(d *Debugger, n int) {
+	frames := d.Frames()
+	if n < 0 || n >= len(frames) {
+		c.printf("No frame %d.\n", n)
+		return
+	}
+	c.frame = n
+	c.printf("#%d  ", n)
+	c.printFrame(frames[n])
+}
+
+func (c *Console) printVariables(variables []Variable, err error) {
+	if err != nil {
+		c.printf("error: %v\n", err)
+		return
+	}
+	if len(variables) == 0 {
+		c.printf("No variables.\n")
+	}
+	for _, v := range variables {
+		c.printf("%s = %s\n", v.Name, v.Value.String())
+	}
+}
+
+// list 输出当前栈帧正在执行的那一行前后 5 行源码
+func (c *Console) list(d *Debugger) {
+	frames := d.Frames()
+	if c.frame >= len(frames) {
+		return
+	}
+	frame := frames[c.frame]
+	location := frame.frame.GetLocation()
+	if location == nil {
+		return
+	}
+	start := frame.Line - 5
+	if start < 0 {
+		start = 0
+	}
+	for line := start; line <= frame.Line+5 && line < len(location.Lines); line++ {
+		source := frame.Source(line)
+		marker := "  "
+		if line == frame.Line {
+			marker = "->"
+		}
+		c.printf("%s%4d\t%s\n", marker, line+1, source)
+	}
+}
+
+// displayName 在当前目录下的文件输出相对路径
+func displayName(filename string) string {
+	wd, err := os.Getwd()
+	if err != nil || !filepath.IsAbs(filename) {
+		return filename
+	}
+	rel, err := filepath.Rel(wd, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filename
+	}
+	return rel
+}
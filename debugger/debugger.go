@@ -0,0 +1,420 @@
+// Package debugger 实现 Weilang 的调试器
+//
+// Debugger 实现 evaluator.Tracer ，在执行每条语句之前检查断点和单步执行的状态，
+// 需要暂停时调用 Handler ，Handler 返回之前可以查看调用栈、变量，在暂停的栈帧中求值表达式
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"weilang/ast"
+	"weilang/evaluator"
+	"weilang/lexer"
+	"weilang/object"
+	"weilang/parser"
+)
+
+// Action 暂停之后继续执行的方式
+type Action int
+
+const (
+	// Continue 继续执行到下一个断点
+	Continue Action = iota
+	// StepIn 执行到下一条语句，会进入调用的函数
+	StepIn
+	// StepOver 执行到当前函数的下一条语句，不进入调用的函数
+	StepOver
+	// StepOut 执行到当前函数返回之后的下一条语句
+	StepOut
+	// Quit 结束程序
+	Quit
+)
+
+// 暂停的原因
+const (
+	ReasonEntry      = "entry"      // 执行第一条语句之前
+	ReasonBreakpoint = "breakpoint" // 到达断点
+	ReasonStep       = "step"       // 单步执行
+	ReasonPause      = "pause"      // 脚本调用了 breakpoint() 或者调用了 Pause
+)
+
+// Handler 调试器暂停时调用，Stopped 返回之后按照返回的 Action 继续执行
+type Handler interface {
+	Stopped(d *Debugger, stop *Stop) Action
+}
+
+// Stop 暂停时的信息
+type Stop struct {
+	Reason string
+	// Breakpoint 因为断点暂停时为到达的断点
+	Breakpoint *Breakpoint
+	// ConditionErr 断点的条件求值出错，出错时也会暂停
+	ConditionErr error
+}
+
+// Breakpoint 断点，执行到 Filename 文件第 Line 行开始的语句时暂停
+type Breakpoint struct {
+	ID int
+	// Filename 文件名，不包含目录时匹配所有同名的文件
+	Filename string
+	// Line 从 0 开始的行号
+	Line int
+	// Condition 条件表达式，为空时总是暂停，否则只在条件为真时暂停
+	Condition string
+	// Hits 暂停的次数
+	Hits int
+
+	condition ast.Expression
+}
+
+func (bp *Breakpoint) matches(filename string, line int) bool {
+	if bp.Line != line {
+		return false
+	}
+	if filepath.Base(bp.Filename) == bp.Filename {
+		return filepath.Base(filename) == bp.Filename
+	}
+	return filepath.Clean(bp.Filename) == filepath.Clean(filename)
+}
+
+// Frame 调用栈中的一个栈帧
+type Frame struct {
+	Name     string
+	Filename string
+	// Line Column 从 0 开始的正在执行的位置
+	Line   int
+	Column int
+
+	frame *object.Frame
+}
+
+// Source 返回栈帧所在文件第 line 行的源码，没有源码时返回空字符串
+func (f Frame) Source(line int) string {
+	location := f.frame.GetLocation()
+	if location == nil {
+		return ""
+	}
+	return location.Line(line)
+}
+
+// Variable 变量的名字和值
+type Variable struct {
+	Name  string
+	Value object.Object
+}
+
+// Debugger 调试器，暂停时调用 Handler
+type Debugger struct {
+	handler     Handler
+	stopOnEntry bool
+
+	mu          sync.Mutex
+	breakpoints []*Breakpoint
+	nextID      int
+
+	// pause 在下一条语句暂停
+	pause atomic.Bool
+
+	started bool
+	action  Action
+	// frame caller depth 开始单步执行时正在执行的栈帧、调用它的栈帧和调用栈的深度
+	frame  *object.Frame
+	caller *object.Frame
+	depth  int
+
+	// ctx state 暂停时正在执行的程序
+	ctx   context.Context
+	state *evaluator.WeiState
+	// evaluating 正在求值表达式，不检查断点
+	evaluating bool
+}
+
+// New 创建调试器，stopOnEntry 为 true 时在执行第一条语句之前暂停
+func New(handler Handler, stopOnEntry bool) *Debugger {
+	return &Debugger{handler: handler, stopOnEntry: stopOnEntry, nextID: 1, action: Continue}
+}
+
+// SetStopOnEntry 设置是否在执行第一条语句之前暂停，需要在开始执行之前调用
+func (d *Debugger) SetStopOnEntry(stopOnEntry bool) {
+	d.stopOnEntry = stopOnEntry
+}
+
+// SetBreakpoint 在 filename 文件从 0 开始的第 line 行设置断点，condition 不为空时为断点的条件
+func (d *Debugger) SetBreakpoint(filename string, line int, condition string) (*Breakpoint, error) {
+	bp := &Breakpoint{Filename: filename, Line: line, Condition: condition}
+	if filepath.Base(filename) != filename {
+		bp.Filename, _ = filepath.Abs(filename)
+	}
+	if condition != "" {
+		expr, err := parseExpression(condition)
+		if err != nil {
+			return nil, err
+		}
+		bp.condition = expr
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bp.ID = d.nextID
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, bp)
+	return bp, nil
+}
+
+// ClearBreakpoint 删除编号为 id 的断点，断点不存在时返回 false
+func (d *Debugger) ClearBreakpoint(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, bp := range d.breakpoints {
+		if bp.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ClearBreakpoints 删除 filename 文件中的所有断点，filename 为空时删除所有断点
+func (d *Debugger) ClearBreakpoints(filename string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if filename == "" {
+		d.breakpoints = nil
+		return
+	}
+	kept := d.breakpoints[:0]
+	for _, bp := range d.breakpoints {
+		if !bp.matches(filename, bp.Line) {
+			kept = append(kept, bp)
+		}
+	}
+	d.breakpoints = kept
+}
+
+// Breakpoints 返回所有断点，按编号排序
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*Breakpoint{}, d.breakpoints...)
+}
+
+// Pause 在下一条语句暂停，可以在其他 goroutine 中调用
+func (d *Debugger) Pause() {
+	d.pause.Store(true)
+}
+
+// Breakpoint 实现 evaluator.Tracer ，脚本调用 breakpoint() 时在下一条语句暂停
+func (d *Debugger) Breakpoint() {
+	d.Pause()
+}
+
+// Trace 实现 evaluator.Tracer ，需要暂停时调用 Handler
+func (d *Debugger) Trace(
+	ctx context.Context,
+	state *evaluator.WeiState,
+	stmt ast.Statement,
+	env *object.Environment,
+) *object.Error {
+	if d.evaluating {
+		return nil
+	}
+	d.ctx, d.state = ctx, state
+	defer func() {
+		d.ctx, d.state = nil, nil
+	}()
+	frames := state.Frames()
+	frame, depth := frames[len(frames)-1], len(frames)
+	stop := d.check(stmt.GetFileLocation(), frame, depth, env)
+	if stop == nil {
+		return nil
+	}
+	d.action = d.handler.Stopped(d, stop)
+	d.frame, d.caller, d.depth = frame, nil, depth
+	if depth > 1 {
+		d.caller = frames[depth-2]
+	}
+	if d.action == Quit {
+		return object.NewExit(0)
+	}
+	return nil
+}
+
+// check 判断是否需要在语句之前暂停，不需要时返回 nil
+//
+// 单步执行时用栈帧区分同一个函数的不同调用：调用栈深度相同的其他调用不是开始单步执行的栈帧，
+// 只有回到开始单步执行的栈帧（或者它的调用者），或者调用栈比它更浅，才会暂停
+func (d *Debugger) check(location *ast.FileLocation, frame *object.Frame, depth int, env *object.Environment) *Stop {
+	if !d.started {
+		d.started = true
+		if d.stopOnEntry {
+			return &Stop{Reason: ReasonEntry}
+		}
+	}
+	if d.pause.CompareAndSwap(true, false) {
+		return &Stop{Reason: ReasonPause}
+	}
+	for _, bp := range d.Breakpoints() {
+		if !bp.matches(location.Filename, location.Lineno) {
+			continue
+		}
+		if bp.condition != nil {
+			value, err := d.eval(bp.condition, env)
+			if err != nil {
+				return &Stop{Reason: ReasonBreakpoint, Breakpoint: bp, ConditionErr: err}
+			}
+			if !evaluator.IsTruthy(value) {
+				continue
+			}
+		}
+		d.mu.Lock()
+		bp.Hits++
+		d.mu.Unlock()
+		return &Stop{Reason: ReasonBreakpoint, Breakpoint: bp}
+	}
+	switch d.action {
+	case StepIn:
+		return &Stop{Reason: ReasonStep}
+	case StepOver:
+		if frame == d.frame || depth < d.depth {
+			return &Stop{Reason: ReasonStep}
+		}
+	case StepOut:
+		if frame == d.caller || depth < d.depth-1 {
+			return &Stop{Reason: ReasonStep}
+		}
+	}
+	return nil
+}
+
+// Frames 返回暂停时的调用栈，第一个为正在执行的栈帧，只能在 Handler.Stopped 中调用
+func (d *Debugger) Frames() []Frame {
+	if d.state == nil {
+		return nil
+	}
+	frames := d.state.Frames()
+	result := make([]Frame, 0, len(frames))
+	for i := len(frames) - 1; i >= 0; i-- {
+		f := frames[i]
+		result = append(result, Frame{
+			Name:     f.GetFuncName(),
+			Filename: f.GetFilename(),
+			Line:     f.GetLineno(),
+			Column:   f.GetColumn(),
+			frame:    f,
+		})
+	}
+	return result
+}
+
+// env 返回第 n 个栈帧正在执行的语句所在的环境
+func (d *Debugger) env(n int) (*object.Environment, error) {
+	frames := d.Frames()
+	if n < 0 || n >= len(frames) {
+		return nil, fmt.Errorf("no frame %d", n)
+	}
+	env := frames[n].frame.GetEnv()
+	if env == nil {
+		return nil, fmt.Errorf("frame %d has no environment", n)
+	}
+	return env, nil
+}
+
+// Locals 返回第 n 个栈帧的局部变量，从内层的代码块向外查找，包括闭包捕获的变量，不包括模块的全局变量；
+// 内层的变量遮蔽了外层的同名变量时只返回内层的变量
+func (d *Debugger) Locals(n int) ([]Variable, error) {
+	env, err := d.env(n)
+	if err != nil {
+		return nil, err
+	}
+	var variables []Variable
+	seen := map[string]bool{}
+	for e := env; e != nil; e = e.Outer() {
+		// 模块的环境是最外层的环境，在模块中执行时局部变量就是全局变量
+		if e.Outer() == nil && e != env {
+			break
+		}
+		variables = appendVariables(variables, e, seen)
+	}
+	return variables, nil
+}
+
+// Globals 返回第 n 个栈帧所在模块的全局变量
+func (d *Debugger) Globals(n int) ([]Variable, error) {
+	env, err := d.env(n)
+	if err != nil {
+		return nil, err
+	}
+	for env.Outer() != nil {
+		env = env.Outer()
+	}
+	return appendVariables(nil, env, map[string]bool{}), nil
+}
+
+func appendVariables(variables []Variable, env *object.Environment, seen map[string]bool) []Variable {
+	for _, name := range env.Names() {
+		// wei 是每个模块都有的内置对象
+		if seen[name] || name == "wei" {
+			continue
+		}
+		seen[name] = true
+		value, _ := env.Get(name)
+		variables = append(variables, Variable{Name: name, Value: value})
+	}
+	return variables
+}
+
+// Evaluate 在第 n 个栈帧中求值表达式，只能在 Handler.Stopped 中调用
+func (d *Debugger) Evaluate(n int, source string) (object.Object, error) {
+	env, err := d.env(n)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := parseExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	return d.eval(expr, env)
+}
+
+// eval 在 env 中求值表达式，求值不会影响程序的错误栈和当前位置，也不会触发断点
+func (d *Debugger) eval(expr ast.Expression, env *object.Environment) (object.Object, error) {
+	frame := d.state.Frame()
+	location := frame.GetLocation()
+	pause := d.pause.Load()
+	d.evaluating = true
+	value := evaluator.Eval(d.ctx, d.state, expr, env)
+	d.evaluating = false
+	d.pause.Store(pause)
+	if location != nil {
+		frame.SetLocation(location)
+	}
+	d.state.ClearExc()
+	if evaluator.IsError(value) {
+		return nil, errors.New(value.(*object.Error).Message)
+	}
+	if value == nil {
+		return object.NULL, nil
+	}
+	return value, nil
+}
+
+// parseExpression 解析一个表达式
+func parseExpression(source string) (ast.Expression, error) {
+	program, err := parser.New(lexer.New(source)).ParseProgram()
+	if err != nil {
+		return nil, err
+	}
+	if len(program.Statements) != 1 {
+		return nil, fmt.Errorf("want an expression: %s", strings.TrimSpace(source))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return nil, fmt.Errorf("want an expression: %s", strings.TrimSpace(source))
+	}
+	return stmt.Expression, nil
+}
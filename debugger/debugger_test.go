@@ -0,0 +1,374 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"weilang/interpreter"
+)
+
+const testProgram = `var total = 0
+
+fn add(a, b) {
+    var s = a + b
+    return s
+}
+
+var i = 0
+while (i < 3) {
+    total = add(total, i)
+    i = i + 1
+}
+print(total)
+`
+
+// recorder 记录每次暂停的位置，按照 actions 的顺序继续执行，用完之后一直继续执行
+type recorder struct {
+	actions []Action
+	stops   []string
+	// inspect 暂停时调用，用来查看变量和求值
+	inspect func(d *Debugger)
+}
+
+func (r *recorder) Stopped(d *Debugger, stop *Stop) Action {
+	frame := d.Frames()[0]
+	r.stops = append(r.stops, fmt.Sprintf("%s %s:%d", stop.Reason, frame.Name, frame.Line+1))
+	if r.inspect != nil {
+		r.inspect(d)
+	}
+	if len(r.actions) == 0 {
+		return Continue
+	}
+	action := r.actions[0]
+	r.actions = r.actions[1:]
+	return action
+}
+
+func writeProgram(t *testing.T, source string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "main.wei")
+	if err := os.WriteFile(filename, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func run(t *testing.T, filename string, d *Debugger) int {
+	t.Helper()
+	var stderr bytes.Buffer
+	code := interpreter.Run(filename, interpreter.Options{Stdout: io.Discard, Stderr: &stderr, Tracer: d})
+	if stderr.Len() != 0 {
+		t.Errorf("unexpected stderr: %s", stderr.String())
+	}
+	return code
+}
+
+func TestStepping(t *testing.T) {
+	tests := []struct {
+		name        string
+		breakpoints []int
+		actions     []Action
+		expected    []string
+	}{
+		{
+			"step over",
+			nil,
+			[]Action{StepOver, StepOver, StepOver, StepOver},
+			[]string{"entry <module>:1", "step <module>:3", "step <module>:8", "step <module>:9", "step <module>:10"},
+		},
+		{
+			"step in and out",
+			[]int{10},
+			[]Action{StepIn, StepIn, StepOut, StepOver},
+			[]string{"breakpoint <module>:10", "step add:4", "step add:5", "step <module>:11", "breakpoint <module>:10"},
+		},
+		{
+			"step over at end of function",
+			[]int{5},
+			[]Action{StepOver, StepOver},
+			[]string{"breakpoint add:5", "step <module>:11", "step <module>:10"},
+		},
+		{
+			"continue",
+			[]int{4, 13},
+			nil,
+			[]string{"breakpoint add:4", "breakpoint add:4", "breakpoint add:4", "breakpoint <module>:13"},
+		},
+	}
+	filename := writeProgram(t, testProgram)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{actions: tt.actions}
+			d := New(r, tt.breakpoints == nil)
+			for _, line := range tt.breakpoints {
+				if _, err := d.SetBreakpoint(filename, line-1, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if code := run(t, filename, d); code != interpreter.ExitOK {
+				t.Fatalf("exit code = %d", code)
+			}
+			n := len(tt.expected)
+			if len(r.stops) < n {
+				n = len(r.stops)
+			}
+			if len(r.stops) < len(tt.expected) || strings.Join(r.stops[:n], ", ") != strings.Join(tt.expected, ", ") {
+				t.Errorf("stops = %q, want prefix %q", r.stops, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSteppingSameDepth 同一个表达式中多次调用同一个函数，其他调用的栈帧和开始单步执行的栈帧深度相同
+func TestSteppingSameDepth(t *testing.T) {
+	filename := writeProgram(t, `fn f(n) {
+    return n
+}
+
+fn fib(n) {
+    if (n < 2) {
+        return n
+    }
+    return fib(n - 1) + fib(n - 2)
+}
+
+var a = f(1) + f(2)
+var b = fib(2) + f(3)
+print(a + b)
+`)
+	tests := []struct {
+		name      string
+		line      int
+		condition string
+		actions   []Action
+		expected  []string
+	}{
+		{"step over", 2, "n == 1", []Action{StepOver}, []string{"breakpoint f:2", "step <module>:13"}},
+		{"step out", 2, "n == 1", []Action{StepOut}, []string{"breakpoint f:2", "step <module>:13"}},
+		// fib(2) 中的 fib(1) 返回之后，fib(2) 调用 fib(0) ，然后 <module> 调用 f(3) ，都不是 fib(2) 的栈帧
+		{"step out recursion", 7, "n == 1", []Action{StepOut}, []string{"breakpoint fib:7", "step <module>:14"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{actions: tt.actions}
+			d := New(r, false)
+			if _, err := d.SetBreakpoint(filename, tt.line-1, tt.condition); err != nil {
+				t.Fatal(err)
+			}
+			if code := run(t, filename, d); code != interpreter.ExitOK {
+				t.Fatalf("exit code = %d", code)
+			}
+			if strings.Join(r.stops, ", ") != strings.Join(tt.expected, ", ") {
+				t.Errorf("stops = %q, want %q", r.stops, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConditionalBreakpoint(t *testing.T) {
+	filename := writeProgram(t, testProgram)
+	tests := []struct {
+		condition string
+		expected  []string
+		hits      int
+	}{
+		{"a == 1", []string{"breakpoint add:5"}, 1},
+		{"s > 0", []string{"breakpoint add:5", "breakpoint add:5"}, 2},
+		{"false", nil, 0},
+	}
+	for _, tt := range tests {
+		r := &recorder{}
+		d := New(r, false)
+		// 用文件名设置断点，匹配所有同名的文件
+		bp, err := d.SetBreakpoint("main.wei", 4, tt.condition)
+		if err != nil {
+			t.Fatal(err)
+		}
+		run(t, filename, d)
+		if strings.Join(r.stops, ", ") != strings.Join(tt.expected, ", ") {
+			t.Errorf("condition %q: stops = %q, want %q", tt.condition, r.stops, tt.expected)
+		}
+		if bp.Hits != tt.hits {
+			t.Errorf("condition %q: hits = %d, want %d", tt.condition, bp.Hits, tt.hits)
+		}
+	}
+
+	if _, err := New(&recorder{}, false).SetBreakpoint(filename, 4, "a =="); err == nil {
+		t.Errorf("expected syntax error in condition")
+	}
+
+	r := &recorder{}
+	d := New(r, false)
+	bp, _ := d.SetBreakpoint(filename, 4, "undefinedName")
+	var stop *Stop
+	d.handler = handlerFunc(func(d *Debugger, s *Stop) Action {
+		stop = s
+		return Quit
+	})
+	run(t, filename, d)
+	if stop == nil || stop.Breakpoint != bp || stop.ConditionErr == nil {
+		t.Errorf("expected stop with condition error, got %+v", stop)
+	}
+}
+
+type handlerFunc func(d *Debugger, stop *Stop) Action
+
+func (f handlerFunc) Stopped(d *Debugger, stop *Stop) Action {
+	return f(d, stop)
+}
+
+func TestInspect(t *testing.T) {
+	filename := writeProgram(t, testProgram)
+	var (
+		frames  []string
+		locals  []string
+		globals []string
+		values  []string
+	)
+	format := func(variables []Variable, err error) string {
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		var parts []string
+		for _, v := range variables {
+			if v.Name == "add" {
+				continue
+			}
+			parts = append(parts, v.Name+"="+v.Value.String())
+		}
+		return strings.Join(parts, " ")
+	}
+	evaluate := func(d *Debugger, frame int, expr string) string {
+		value, err := d.Evaluate(frame, expr)
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return value.String()
+	}
+	r := &recorder{actions: []Action{Quit}, inspect: func(d *Debugger) {
+		for _, f := range d.Frames() {
+			frames = append(frames, fmt.Sprintf("%s:%d", f.Name, f.Line+1))
+		}
+		locals = append(locals, format(d.Locals(0)), format(d.Locals(1)), format(d.Locals(2)))
+		globals = append(globals, format(d.Globals(0)))
+		values = append(values,
+			evaluate(d, 0, "a * 10 + s"),
+			evaluate(d, 1, "i"),
+			evaluate(d, 1, "a"),
+			evaluate(d, 0, "a ="),
+			evaluate(d, 0, "var x = 1"),
+		)
+	}}
+	d := New(r, false)
+	if _, err := d.SetBreakpoint(filename, 4, "i == 2"); err != nil {
+		t.Fatal(err)
+	}
+	if code := run(t, filename, d); code != interpreter.ExitOK {
+		t.Fatalf("exit code = %d", code)
+	}
+
+	expectedFrames := []string{"add:5", "<module>:10"}
+	if strings.Join(frames, " ") != strings.Join(expectedFrames, " ") {
+		t.Errorf("frames = %q, want %q", frames, expectedFrames)
+	}
+	expectedLocals := []string{"s=3 a=1 b=2", "", "error: no frame 2"}
+	if strings.Join(locals, "|") != strings.Join(expectedLocals, "|") {
+		t.Errorf("locals = %q, want %q", locals, expectedLocals)
+	}
+	expectedGlobals := []string{"i=2 total=1"}
+	if strings.Join(globals, "|") != strings.Join(expectedGlobals, "|") {
+		t.Errorf("globals = %q, want %q", globals, expectedGlobals)
+	}
+	if len(values) != 5 || values[0] != "13" || values[1] != "2" ||
+		values[2] != "error: undefined: 'a'" ||
+		!strings.HasPrefix(values[3], "error: ") || !strings.HasPrefix(values[4], "error: want an expression") {
+		t.Errorf("values = %q", values)
+	}
+}
+
+func TestBreakpointBuiltin(t *testing.T) {
+	filename := writeProgram(t, `var x = 1
+breakpoint()
+x = 2
+fn f() {
+    breakpoint()
+}
+f()
+x = 3
+`)
+	r := &recorder{}
+	d := New(r, false)
+	run(t, filename, d)
+	expected := []string{"pause <module>:3", "pause <module>:8"}
+	if strings.Join(r.stops, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("stops = %q, want %q", r.stops, expected)
+	}
+}
+
+func TestQuit(t *testing.T) {
+	filename := writeProgram(t, "var x = 1\nos.exit(5)\n")
+	d := New(&recorder{actions: []Action{Quit}}, true)
+	if code := run(t, filename, d); code != interpreter.ExitOK {
+		t.Errorf("exit code = %d, want %d", code, interpreter.ExitOK)
+	}
+}
+
+func TestConsole(t *testing.T) {
+	filename := writeProgram(t, testProgram)
+	commands := strings.Join([]string{
+		"b 5 if i == 1",
+		"b main.wei:13",
+		"b",
+		"c",
+		"bt",
+		"locals",
+		"p a + b",
+		"up",
+		"p total",
+		"frame 5",
+		"finish",
+		"n",
+		"",
+		"d 1",
+		"c",
+		"list",
+		"unknown",
+		"c",
+	}, "\n")
+	var out bytes.Buffer
+	d := New(NewConsole(strings.NewReader(commands), &out), true)
+	if code := run(t, filename, d); code != interpreter.ExitOK {
+		t.Fatalf("exit code = %d", code)
+	}
+	base := displayName(filename)
+	expected := []string{
+		"<module> at " + base + ":1\n   1\tvar total = 0\n",
+		"Breakpoint 1 at " + base + ":5\n",
+		"Breakpoint 2 at main.wei:13\n",
+		"1\t" + base + ":5 if i == 1\thit 0 times\n2\tmain.wei:13\thit 0 times\n",
+		"Breakpoint 1, add at " + base + ":5\n   5\t    return s\n",
+		"* #0  add at " + base + ":5\n  #1  <module> at " + base + ":10\n",
+		"s = 1\na = 0\nb = 1\n",
+		"(wdb) 1\n",
+		"#1  <module> at " + base + ":10\n",
+		"(wdb) 0\n",
+		"No frame 5.\n",
+		"(wdb) <module> at " + base + ":11\n",
+		"(wdb) <module> at " + base + ":10\n",
+		"(wdb) <module> at " + base + ":11\n  11\t    i = i + 1\n(wdb) (wdb) Breakpoint 2",
+		"Breakpoint 2, <module> at " + base + ":13\n",
+		"->  13\tprint(total)\n",
+		"unknown command 'unknown'",
+	}
+	output := out.String()
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q\noutput:\n%s", want, output)
+		}
+	}
+}
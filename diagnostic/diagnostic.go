@@ -0,0 +1,53 @@
+// Package diagnostic 定义编辑器、CI 等工具使用的结构化诊断信息
+package diagnostic
+
+import (
+	"encoding/json"
+	"io"
+	"weilang/token"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// 诊断代码
+const (
+	CodeSyntaxError  = "SyntaxError"
+	CodeRuntimeError = "RuntimeError"
+	CodeUsageError   = "UsageError"
+)
+
+// Frame 运行时错误的调用栈帧，Line Column 从 0 开始
+type Frame struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	FuncName string `json:"func_name"`
+}
+
+// Diagnostic 诊断信息，Start End 为出错的源码范围（行列从 0 开始），
+// 运行时错误的 Frames 从最外层调用开始排列，最后一个是出错的位置
+type Diagnostic struct {
+	Severity Severity       `json:"severity"`
+	Code     string         `json:"code"`
+	Message  string         `json:"message"`
+	Filename string         `json:"filename"`
+	Start    token.Position `json:"start"`
+	End      token.Position `json:"end"`
+	Frames   []Frame        `json:"frames,omitempty"`
+}
+
+// WriteJSON 以 JSON 数组的形式输出诊断信息，末尾带换行符
+func WriteJSON(w io.Writer, diagnostics []Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(diagnostics)
+}
@@ -2,8 +2,12 @@ package evaluator
 
 import (
 	"bytes"
-	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strconv"
+	"unicode"
+	"unicode/utf8"
 	"weilang/object"
 )
 
@@ -76,7 +80,13 @@ func _len(args ...object.Object) object.Object {
 	case *object.List:
 		return &object.Integer{Value: int64(len(arg.Elements))}
 	case *object.Dict:
-		return object.NewInteger(int64(len(arg.Pairs)))
+		return object.NewInteger(int64(arg.Len()))
+	case *object.Tuple:
+		return object.NewInteger(int64(len(arg.Elements)))
+	case object.SetLike:
+		return object.NewInteger(int64(arg.Len()))
+	case *object.Range:
+		return object.NewInteger(arg.Len())
 	default:
 		return object.NewError("wrong argument type for len(): '%s'", arg.Type())
 	}
@@ -101,7 +111,39 @@ func oct(args ...object.Object) object.Object {
 	}
 }
 
-func _print(args ...object.Object) object.Object {
+// ord 返回单个字符的 Unicode 码点
+func ord(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.WrongNumberArgument(len(args), 1)
+	}
+	arg, ok := args[0].(*object.String)
+	if !ok {
+		return object.NewError("wrong argument type for ord(): '%s'", args[0].Type())
+	}
+	if arg.Length != 1 {
+		return object.NewError("ord() expected a character, but string of length %d found", arg.Length)
+	}
+	r, _ := utf8.DecodeRuneInString(arg.Value)
+	return object.NewInteger(int64(r))
+}
+
+// chr 返回 Unicode 码点对应的字符，是 ord 的逆运算
+func chr(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.WrongNumberArgument(len(args), 1)
+	}
+	arg, ok := args[0].(*object.Integer)
+	if !ok {
+		return object.NewError("wrong argument type for chr(): '%s'", args[0].Type())
+	}
+	if arg.Value < 0 || arg.Value > unicode.MaxRune {
+		return object.NewError("chr() arg not in range(0x110000)")
+	}
+	return object.NewString(string(rune(arg.Value)))
+}
+
+// _print 输出到 WeiState 的标准输出
+func _print(rt object.Runtime, args ...object.Object) object.Object {
 	var out bytes.Buffer
 	count := len(args)
 	for i, arg := range args {
@@ -112,7 +154,22 @@ func _print(args ...object.Object) object.Object {
 		}
 	}
 	out.WriteString("\n")
-	fmt.Printf(out.String())
+	var w io.Writer = os.Stdout
+	if r, ok := rt.(*evalRuntime); ok {
+		w = r.state.stdout
+	}
+	_, _ = w.Write(out.Bytes())
+	return object.NULL
+}
+
+// _breakpoint 通知调试器在下一条语句暂停
+func _breakpoint(rt object.Runtime, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return object.WrongNumberArgument(len(args), 0)
+	}
+	if r, ok := rt.(*evalRuntime); ok && r.state.tracer != nil {
+		r.state.tracer.Breakpoint()
+	}
 	return object.NULL
 }
 
@@ -130,19 +187,251 @@ func _type(args ...object.Object) object.Object {
 	}
 }
 
+// newSet set() 和 frozenset() 的实现
+func newSet(rt object.Runtime, args []object.Object, frozen bool) object.Object {
+	switch len(args) {
+	case 0:
+		return object.NewSetFromIterable(object.NewList(nil), frozen)
+	case 1:
+		r := rt.(*evalRuntime)
+		values, err := iterValues(r.ctx, r.state, args[0])
+		if err != nil {
+			return err
+		}
+		return object.NewSetFromIterable(object.NewList(values), frozen)
+	default:
+		return object.WrongNumberArgument2(len(args), 0, 1)
+	}
+}
+
+// _iter 返回对象的迭代器
+func _iter(rt object.Runtime, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.WrongNumberArgument(len(args), 1)
+	}
+	r := rt.(*evalRuntime)
+	return evalIter(r.ctx, r.state, args[0])
+}
+
+// _next 返回迭代器的下一个值
+func _next(rt object.Runtime, args ...object.Object) object.Object {
+	if len(args) == 0 || len(args) > 2 {
+		return object.WrongNumberArgument2(len(args), 1, 2)
+	}
+	var defaultValue object.Object
+	if len(args) == 2 {
+		defaultValue = args[1]
+	}
+	r := rt.(*evalRuntime)
+	return evalNext(r.ctx, r.state, args[0], defaultValue)
+}
+
+// _range range(stop) range(start, stop[, step]) ，返回整数序列
+func _range(args ...object.Object) object.Object {
+	if len(args) == 0 || len(args) > 3 {
+		return object.WrongNumberArgument2(len(args), 1, 3)
+	}
+	values := make([]int64, len(args))
+	for i, arg := range args {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return object.WrongArgumentTypeAt(arg.Type(), i+1)
+		}
+		values[i] = integer.Value
+	}
+	start, stop, step := int64(0), values[0], int64(1)
+	if len(values) > 1 {
+		start, stop = values[0], values[1]
+	}
+	if len(values) > 2 {
+		step = values[2]
+	}
+	r, err := object.NewRange(start, stop, step)
+	if err != nil {
+		return err
+	}
+	return r
+}
+
+// valueIterators 返回每个参数的值迭代器
+func valueIterators(rt object.Runtime, args []object.Object) ([]object.Iterator, object.Object) {
+	r := rt.(*evalRuntime)
+	iterators := make([]object.Iterator, 0, len(args))
+	for _, arg := range args {
+		iterator, err := valueIterator(r.ctx, r.state, arg)
+		if err != nil {
+			return nil, err
+		}
+		iterators = append(iterators, iterator)
+	}
+	return iterators, nil
+}
+
+// _enumerate enumerate(iterable[, start]) ，产生 (下标, 值) 元组
+func _enumerate(rt object.Runtime, args ...object.Object) object.Object {
+	if len(args) == 0 || len(args) > 2 {
+		return object.WrongNumberArgument2(len(args), 1, 2)
+	}
+	start := int64(0)
+	if len(args) == 2 {
+		integer, ok := args[1].(*object.Integer)
+		if !ok {
+			return object.WrongArgumentTypeAt(args[1].Type(), 2)
+		}
+		start = integer.Value
+	}
+	iterators, err := valueIterators(rt, args[:1])
+	if err != nil {
+		return err
+	}
+	return object.NewEnumerate(iterators[0], start)
+}
+
+// _zip zip(*iterables) ，依次从每个可迭代对象中取一个值组成元组
+func _zip(rt object.Runtime, args ...object.Object) object.Object {
+	iterators, err := valueIterators(rt, args)
+	if err != nil {
+		return err
+	}
+	return object.NewZip(iterators)
+}
+
+// _map map(function, *iterables) ，产生用每个可迭代对象中的值调用 function 的结果
+func _map(rt object.Runtime, args ...object.Object) object.Object {
+	if len(args) < 2 {
+		return object.NewError("wrong number of arguments. got=%d, want at least 2", len(args))
+	}
+	iterators, err := valueIterators(rt, args[1:])
+	if err != nil {
+		return err
+	}
+	r := rt.(*evalRuntime)
+	return &mapIterator{ctx: r.ctx, state: r.state, function: args[0], iterators: iterators}
+}
+
+// _filter filter(function, iterable) ，产生 function 返回真值的值，function 为 null 时产生本身为真值的值
+func _filter(rt object.Runtime, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.WrongNumberArgument(len(args), 2)
+	}
+	iterators, err := valueIterators(rt, args[1:])
+	if err != nil {
+		return err
+	}
+	r := rt.(*evalRuntime)
+	return &filterIterator{ctx: r.ctx, state: r.state, function: args[0], iterator: iterators[0]}
+}
+
+// _reversed reversed(sequence) ，从后往前产生列表、元组、字符串、字典、range 中的值
+func _reversed(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.WrongNumberArgument(len(args), 1)
+	}
+	if r, ok := args[0].(*object.Range); ok {
+		return r.ReversedIter()
+	}
+	reversed, err := object.NewReversed(args[0])
+	if err != nil {
+		return err
+	}
+	return reversed
+}
+
+// builtinValues 返回可选参数 iterable 中的所有值，没有参数时为空
+func builtinValues(rt object.Runtime, args []object.Object) ([]object.Object, object.Object) {
+	switch len(args) {
+	case 0:
+		return []object.Object{}, nil
+	case 1:
+		r := rt.(*evalRuntime)
+		return iterValues(r.ctx, r.state, args[0])
+	default:
+		return nil, object.WrongNumberArgument2(len(args), 0, 1)
+	}
+}
+
+// _list list([iterable]) ，用可迭代对象中的值创建列表
+func _list(rt object.Runtime, args ...object.Object) object.Object {
+	values, err := builtinValues(rt, args)
+	if err != nil {
+		return err
+	}
+	return object.NewList(values)
+}
+
+// _sorted sorted(iterable[, key[, reverse]]) ，返回排好序的新列表，排序规则和 list.sort 相同
+func _sorted(rt object.Runtime, args ...object.Object) object.Object {
+	if len(args) == 0 || len(args) > 3 {
+		return object.WrongNumberArgument2(len(args), 1, 3)
+	}
+	key, reverse, err := object.SortOptions(args[1:], 2)
+	if err != nil {
+		return err
+	}
+	values, e := builtinValues(rt, args[:1])
+	if e != nil {
+		return e
+	}
+	sorted, err := object.Sort(rt, values, key, reverse)
+	if err != nil {
+		return err
+	}
+	return object.NewList(sorted)
+}
+
+// _tuple tuple([iterable]) ，用可迭代对象中的值创建元组
+func _tuple(rt object.Runtime, args ...object.Object) object.Object {
+	values, err := builtinValues(rt, args)
+	if err != nil {
+		return err
+	}
+	return object.NewTuple(values)
+}
+
+// _dict dict([iterable]) ，复制字典，或者用可迭代对象中的 (键, 值) 对创建字典
+func _dict(rt object.Runtime, args ...object.Object) object.Object {
+	d := object.NewDict()
+	if len(args) == 1 {
+		if other, ok := args[0].(*object.Dict); ok {
+			for _, pair := range other.Pairs() {
+				_ = d.Set(pair.Key, pair.Value)
+			}
+			return d
+		}
+	}
+	values, err := builtinValues(rt, args)
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		pair, err := object.IterValues(value)
+		if err != nil {
+			return object.NewError("cannot convert dictionary update sequence element #%d to a sequence", i)
+		}
+		if len(pair) != 2 {
+			return object.NewError("dictionary update sequence element #%d has length %d; 2 is required", i, len(pair))
+		}
+		if err := d.Set(pair[0], pair[1]); err != nil {
+			return err
+		}
+	}
+	return d
+}
+
 var builtins = map[string]*object.Builtin{
 	"abs": {
 		Name: "abs",
+		Doc:  "abs(x) -> int\n返回整数的绝对值",
 		Fn:   abs,
 	},
 	"bin": {
 		Name: "bin",
+		Doc:  "bin(x) -> str\n返回整数的二进制字符串，例如 bin(5) 得到 '0b101'",
 		Fn:   bin,
 	},
-	// bool(object) -> bool
-	// 将对象转化为 bool 值
 	"bool": {
 		Name: "bool",
+		Doc:  "bool(object) -> bool\n将对象转化为 bool 值",
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.WrongNumberArgument(len(args), 1)
@@ -150,10 +439,14 @@ var builtins = map[string]*object.Builtin{
 			return object.NativeBoolToBooleanObject(isTruthy(args[0]))
 		},
 	},
-	// ensure(condition, msg)
-	// condition 为假时报错，错误信息为传入的 msg
+	"chr": {
+		Name: "chr",
+		Doc:  "chr(i) -> str\n返回 Unicode 码点 i 对应的字符，例如 chr(20013) 得到 '中'",
+		Fn:   chr,
+	},
 	"ensure": {
 		Name: "ensure",
+		Doc:  "ensure(condition, msg)\ncondition 为假时报错，错误信息为传入的 msg",
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.WrongNumberArgument(len(args), 2)
@@ -166,12 +459,12 @@ var builtins = map[string]*object.Builtin{
 	},
 	"hex": {
 		Name: "hex",
+		Doc:  "hex(x) -> str\n返回整数的十六进制字符串，例如 hex(255) 得到 '0xff'",
 		Fn:   hex,
 	},
-	// int(object) -> int
-	// 将对象转化为整数，支持传入字符串、数字
 	"int": {
 		Name: "int",
+		Doc:  "int(object) -> int\n将对象转化为整数，支持传入字符串、数字",
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.WrongNumberArgument(len(args), 1)
@@ -192,18 +485,157 @@ var builtins = map[string]*object.Builtin{
 	},
 	"len": {
 		Name: "len",
+		Doc:  "len(object) -> int\n返回字符串、列表、字典、集合的长度",
 		Fn:   _len,
 	},
 	"oct": {
 		Name: "oct",
+		Doc:  "oct(x) -> str\n返回整数的八进制字符串，例如 oct(8) 得到 '0o10'",
 		Fn:   oct,
 	},
+	"ord": {
+		Name: "ord",
+		Doc:  "ord(c) -> int\n返回单个字符的 Unicode 码点，例如 ord('a') 得到 97",
+		Fn:   ord,
+	},
+	"range": {
+		Name: "range",
+		Doc:  "range(stop) -> range\nrange(start, stop[, step]) -> range\n返回从 start 到 stop （不包括 stop ）、间隔为 step 的整数序列，start 默认为 0 ，step 默认为 1",
+		Fn:   _range,
+	},
+	"reversed": {
+		Name: "reversed",
+		Doc:  "reversed(sequence) -> iterator\n返回从后往前遍历列表、元组、字符串、字典、range 的迭代器",
+		Fn:   _reversed,
+	},
 	"print": {
-		Name: "print",
-		Fn:   _print,
+		Name:      "print",
+		Doc:       "print(*objects)\n输出所有对象，对象之间用空格分隔，末尾换行",
+		RuntimeFn: _print,
+	},
+	"breakpoint": {
+		Name:      "breakpoint",
+		Doc:       "breakpoint()\n在 weilang debug 中运行时，在下一条语句暂停；没有调试器时什么也不做",
+		RuntimeFn: _breakpoint,
 	},
 	"type": {
 		Name: "type",
+		Doc:  "type(object) -> str\n返回对象的类型名，实例返回类名",
 		Fn:   _type,
 	},
 }
+
+// 这些内置函数会调用 Weilang 函数，在 init 中注册以避免初始化循环
+func init() {
+	builtins["dict"] = &object.Builtin{
+		Name:      "dict",
+		Doc:       "dict([iterable]) -> dict\n创建字典，传入字典时复制，传入可迭代对象时其中的值为 (键, 值) 对，例如 dict(zip(keys, values))",
+		RuntimeFn: _dict,
+	}
+	builtins["enumerate"] = &object.Builtin{
+		Name:      "enumerate",
+		Doc:       "enumerate(iterable[, start]) -> iterator\n返回产生 (下标, 值) 元组的迭代器，下标从 start 开始，默认为 0",
+		RuntimeFn: _enumerate,
+	}
+	builtins["filter"] = &object.Builtin{
+		Name:      "filter",
+		Doc:       "filter(function, iterable) -> iterator\n返回只产生 function(x) 为真值的 x 的迭代器，function 为 null 时只产生本身为真值的值",
+		RuntimeFn: _filter,
+	}
+	builtins["frozenset"] = &object.Builtin{
+		Name: "frozenset",
+		Doc:  "frozenset([iterable]) -> frozenset\n创建不可变集合，可以作为字典的键和集合的元素",
+		RuntimeFn: func(rt object.Runtime, args ...object.Object) object.Object {
+			return newSet(rt, args, true)
+		},
+	}
+	builtins["list"] = &object.Builtin{
+		Name:      "list",
+		Doc:       "list([iterable]) -> list\n创建列表，传入可迭代对象时列表包含其中的值，例如 list(range(3)) 得到 [0, 1, 2]",
+		RuntimeFn: _list,
+	}
+	builtins["map"] = &object.Builtin{
+		Name:      "map",
+		Doc:       "map(function, *iterables) -> iterator\n返回产生 function(x, y, ...) 的迭代器，x y 依次取自每个可迭代对象，最短的结束时结束",
+		RuntimeFn: _map,
+	}
+	builtins["iter"] = &object.Builtin{
+		Name:      "iter",
+		Doc:       "iter(object) -> iterator\n返回对象的迭代器，实例调用 __iter__ 方法",
+		RuntimeFn: _iter,
+	}
+	builtins["next"] = &object.Builtin{
+		Name:      "next",
+		Doc:       "next(iterator[, default])\n返回迭代器的下一个值，实例调用 __next__ 方法；迭代结束时返回 default ，没有 default 时报错",
+		RuntimeFn: _next,
+	}
+	builtins["set"] = &object.Builtin{
+		Name: "set",
+		Doc:  "set([iterable]) -> set\n创建集合，传入可迭代对象时集合包含其中的元素，例如 set([1, 2, 2]) 得到 {1, 2}",
+		RuntimeFn: func(rt object.Runtime, args ...object.Object) object.Object {
+			return newSet(rt, args, false)
+		},
+	}
+	builtins["sorted"] = &object.Builtin{
+		Name:      "sorted",
+		Doc:       "sorted(iterable[, key[, reverse]]) -> list\n返回排好序的新列表，排序是稳定的；key 不为 null 时按 key(x) 排序，reverse 为 true 时从大到小",
+		RuntimeFn: _sorted,
+	}
+	builtins["tuple"] = &object.Builtin{
+		Name:      "tuple",
+		Doc:       "tuple([iterable]) -> tuple\n创建元组，传入可迭代对象时元组包含其中的值",
+		RuntimeFn: _tuple,
+	}
+	builtins["zip"] = &object.Builtin{
+		Name:      "zip",
+		Doc:       "zip(*iterables) -> iterator\n返回产生元组的迭代器，元组依次由每个可迭代对象的值组成，最短的结束时结束",
+		RuntimeFn: _zip,
+	}
+}
+
+// builtinConstant 内置常量
+type builtinConstant struct {
+	value object.Object
+	doc   string
+}
+
+var builtinConstants = map[string]builtinConstant{
+	"StopIteration": {
+		value: object.STOP_ITERATION,
+		doc:   "StopIteration\n在 __next__ 方法中返回 StopIteration 表示迭代结束",
+	},
+}
+
+// BuiltinDoc 返回内置函数、内置常量的说明
+func BuiltinDoc(name string) (string, bool) {
+	if constant, ok := builtinConstants[name]; ok {
+		return constant.doc, true
+	}
+	builtin, ok := builtins[name]
+	if !ok {
+		return "", false
+	}
+	return builtin.Doc, true
+}
+
+// BuiltinNames 返回所有内置函数、内置常量的名字，按字母排序
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins)+len(builtinConstants))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range builtinConstants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBuiltin 判断 name 是否为内置函数、内置常量的名字
+func IsBuiltin(name string) bool {
+	if _, ok := builtinConstants[name]; ok {
+		return true
+	}
+	_, ok := builtins[name]
+	return ok
+}
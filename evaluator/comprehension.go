@@ -0,0 +1,129 @@
+package evaluator
+
+import (
+	"context"
+	"weilang/ast"
+	"weilang/object"
+)
+
+// evalComprehension 执行推导式的 for 子句，对每个满足条件的值调用 each
+// 和 for-in 语句一样，每次迭代都在新的环境中绑定变量，变量不会泄漏到推导式外面
+func evalComprehension(
+	ctx context.Context,
+	state *WeiState,
+	node ast.Expression,
+	comprehension *ast.Comprehension,
+	env *object.Environment,
+	each func(env *object.Environment) object.Object,
+) object.Object {
+	obj := Eval(ctx, state, comprehension.Iterable, env)
+	if IsError(obj) {
+		return obj
+	}
+	state.UpdateLocation(comprehension.Iterable)
+	iterator, err := getIterator(ctx, state, obj)
+	if err != nil {
+		return err
+	}
+	for {
+		next := iterator.Next()
+		if next == object.StopIteration {
+			return nil
+		}
+		if IsError(next) {
+			state.HandleError(next)
+			return next
+		}
+		enclosedEnv := object.NewEnclosedEnvironment(env)
+		if err := bindTargets(state, node, comprehension.Targets, next, enclosedEnv, false); err != nil {
+			return err
+		}
+		if comprehension.Condition != nil {
+			condition := Eval(ctx, state, comprehension.Condition, enclosedEnv)
+			if IsError(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				continue
+			}
+		}
+		if ret := each(enclosedEnv); ret != nil {
+			return ret
+		}
+	}
+}
+
+func evalListComprehension(
+	ctx context.Context,
+	state *WeiState,
+	node *ast.ListComprehension,
+	env *object.Environment,
+) object.Object {
+	elements := []object.Object{}
+	err := evalComprehension(ctx, state, node, node.Comprehension, env, func(env *object.Environment) object.Object {
+		element := Eval(ctx, state, node.Element, env)
+		if IsError(element) {
+			return element
+		}
+		elements = append(elements, element)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return object.NewList(elements)
+}
+
+func evalSetComprehension(
+	ctx context.Context,
+	state *WeiState,
+	node *ast.SetComprehension,
+	env *object.Environment,
+) object.Object {
+	set := object.NewSet()
+	err := evalComprehension(ctx, state, node, node.Comprehension, env, func(env *object.Environment) object.Object {
+		element := Eval(ctx, state, node.Element, env)
+		if IsError(element) {
+			return element
+		}
+		if err := set.Add(element); err != nil {
+			state.UpdateLocation(node.Element)
+			state.HandleError(err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return set
+}
+
+func evalDictComprehension(
+	ctx context.Context,
+	state *WeiState,
+	node *ast.DictComprehension,
+	env *object.Environment,
+) object.Object {
+	dict := object.NewDict()
+	err := evalComprehension(ctx, state, node, node.Comprehension, env, func(env *object.Environment) object.Object {
+		key := Eval(ctx, state, node.Key, env)
+		if IsError(key) {
+			return key
+		}
+		value := Eval(ctx, state, node.Value, env)
+		if IsError(value) {
+			return value
+		}
+		if err := dict.Set(key, value); err != nil {
+			state.UpdateLocation(node.Key)
+			state.HandleError(err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return dict
+}
@@ -0,0 +1,73 @@
+package evaluator
+
+import (
+	"testing"
+	"weilang/object"
+)
+
+func TestComprehensions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		// 列表推导式
+		{`[x * 2 for x in range(4)]`, "[0, 2, 4, 6]", false},
+		{`[v * 2 for i, v in [3, -1, 4] if v > 0]`, "[6, 8]", false},
+		{`[i for i, v in [3, -1, 4] if v > 0]`, "[0, 2]", false},
+		{`[c for i, c in "abc"]`, "[a, b, c]", false},
+		{`[x for x in []]`, "[]", false},
+		{`[[i, j] for i, j in zip("ab", range(2))]`, "[[a, 0], [b, 1]]", false},
+		{`[[y * x for y in range(x)] for x in range(3)]`, "[[], [0], [0, 2]]", false},
+		{`[x for x in Countdown(3) if x != 2]`, "[3, 1]", false},
+
+		// 字典推导式，字典迭代时返回 (键, 值)
+		{`{v: k for k, v in {"a": 1, "b": 2}}`, "{1: a, 2: b}", false},
+		{`{k: v * v for k, v in {1: 2, 3: 4} if k > 1}`, "{3: 16}", false},
+		{`{x: x for x in range(3)}`, "{0: 0, 1: 1, 2: 2}", false},
+		{`{[x]: 1 for x in range(3)}`, "unhashable type: 'list'", true},
+
+		// 集合推导式
+		{`{x % 3 for x in range(10)}`, "{0, 1, 2}", false},
+		{`{k for k, v in {1: 2, 3: 4}}`, "{1, 3}", false},
+		{`{[x] for x in range(3)}`, "unhashable type: 'list'", true},
+		{`type({x for x in []})`, "set", false},
+
+		// 变量不会泄漏到推导式外面
+		{`var x = 100; [x for x in range(3)]; x`, 100, false},
+		{`[y for y in range(3)]; y`, "undefined: 'y'", true},
+		{`var n = 10; [x + n for x in range(2)]`, "[10, 11]", false},
+		{`var fs = [fn() { return n } for n in range(3)]; fs[0]() + fs[2]()`, 2, false},
+
+		// 错误
+		{`[x for x in [1, 2]]`, "unpack got=2, want=1", true},
+		{`[x for x, y, z in {1: 2}]`, "unpack got=2, want=3", true},
+		{`[x for x in 1]`, "'int' object is not iterable", true},
+		{`[x for x in range(3) if missing]`, "undefined: 'missing'", true},
+		{`[x + "a" for x in range(3)]`, "unsupported operand type for +: 'int' and 'str'", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, countdownClass+tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if tt.isError {
+				errObj, ok := evaluated.(*object.Error)
+				if !ok {
+					t.Errorf("%s: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+					continue
+				}
+				if errObj.Message != expected {
+					t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+			if evaluated == nil || evaluated.String() != expected {
+				t.Errorf("%s: expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
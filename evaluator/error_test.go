@@ -1,8 +1,14 @@
 package evaluator
 
 import (
+	"bytes"
+	"context"
 	"testing"
+	"weilang/diagnostic"
+	"weilang/lexer"
 	"weilang/object"
+	"weilang/parser"
+	"weilang/token"
 )
 
 func TestErrorHandling(t *testing.T) {
@@ -127,3 +133,84 @@ if (1) {
 		}
 	}
 }
+
+func TestErrorDiagnostic(t *testing.T) {
+	input := `
+fn f(a) {
+  return a + 1
+}
+f("a")
+`
+	l := lexer.New(input)
+	p := parser.New(l)
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	mod := object.NewModule("<input>")
+	state := NewWeiState(mod)
+	state.CreateFrame("<input>", "<module>")
+	evaluated := Eval(context.Background(), state, program, mod.GetEnv())
+	if !IsError(evaluated) {
+		t.Fatalf("expected error. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	d := state.Diagnostic()
+	if d.Severity != diagnostic.SeverityError || d.Code != diagnostic.CodeRuntimeError {
+		t.Errorf("wrong severity or code. got=%s %s", d.Severity, d.Code)
+	}
+	if d.Message != "unsupported operand type for +: 'str' and 'int'" {
+		t.Errorf("wrong message. got=%q", d.Message)
+	}
+	// a + 1
+	if d.Filename != "<input>" || d.Start != (token.Position{Line: 2, Column: 9}) || d.End != (token.Position{Line: 2, Column: 14}) {
+		t.Errorf("wrong position. got=%s %+v-%+v", d.Filename, d.Start, d.End)
+	}
+	expected := []diagnostic.Frame{
+		{Filename: "<input>", Line: 4, Column: 0, FuncName: "<module>"},
+		{Filename: "<input>", Line: 2, Column: 9, FuncName: "f"},
+	}
+	if len(d.Frames) != len(expected) {
+		t.Fatalf("wrong number of frames. want=%d, got=%d", len(expected), len(d.Frames))
+	}
+	for i, frame := range expected {
+		if d.Frames[i] != frame {
+			t.Errorf("wrong frame %d. want=%+v, got=%+v", i, frame, d.Frames[i])
+		}
+	}
+}
+
+func TestPrintExc(t *testing.T) {
+	input := `fn f(a) {
+    var b = [1, 2]
+    return b[0] + a
+}
+var 结果 = f("s")`
+	expected := `Traceback
+  File "<input>", line 5, in <module>
+    var 结果 = f("s")
+             ^^^^^^
+  File "<input>", line 3, in f
+    return b[0] + a
+           ^^^^^^^^
+Error: unsupported operand type for +: 'int' and 'str'
+`
+	l := lexer.New(input)
+	p := parser.New(l)
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	mod := object.NewModule("<input>")
+	state := NewWeiState(mod)
+	state.CreateFrame("<input>", "<module>")
+	evaluated := Eval(context.Background(), state, program, mod.GetEnv())
+	if !IsError(evaluated) {
+		t.Fatalf("expected error. got=%T (%+v)", evaluated, evaluated)
+	}
+	var out bytes.Buffer
+	state.PrintExc(&out)
+	if out.String() != expected {
+		t.Errorf("wrong traceback. want=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
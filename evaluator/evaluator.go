@@ -3,6 +3,7 @@ package evaluator
 import (
 	"context"
 	"github.com/thinkeridea/go-extend/exunicode/exutf8"
+	"strings"
 	"weilang/ast"
 	"weilang/object"
 )
@@ -68,6 +69,10 @@ func Eval(
 		return Eval(ctx, state, node.Expression, env)
 
 	case *ast.ReturnStatement:
+		// 没有返回值的 return 返回 null
+		if node.ReturnValue == nil {
+			return &object.ReturnValue{Value: object.NULL}
+		}
 		val := Eval(ctx, state, node.ReturnValue, env)
 		if IsError(val) {
 			return val
@@ -178,6 +183,21 @@ func Eval(
 	case *ast.DictLiteral:
 		return evalDictLiteral(ctx, state, node, env)
 
+	case *ast.SetLiteral:
+		return evalSetLiteral(ctx, state, node, env)
+
+	case *ast.YieldExpression:
+		return evalYieldExpression(ctx, state, node, env)
+
+	case *ast.ListComprehension:
+		return evalListComprehension(ctx, state, node, env)
+
+	case *ast.SetComprehension:
+		return evalSetComprehension(ctx, state, node, env)
+
+	case *ast.DictComprehension:
+		return evalDictComprehension(ctx, state, node, env)
+
 	case *ast.ListLiteral:
 		elements := evalExpressions(ctx, state, node.Elements, env)
 		if len(elements) == 1 && IsError(elements[0]) {
@@ -220,6 +240,9 @@ func evalProgram(
 	var result object.Object
 
 	for _, statement := range program.Statements {
+		if err := state.trace(ctx, statement, env); err != nil {
+			return err
+		}
 		result = Eval(ctx, state, statement, env)
 
 		switch result := result.(type) {
@@ -242,6 +265,9 @@ func evalBlockStatements(
 
 	blockEnv := object.NewEnclosedEnvironment(env)
 	for _, statement := range block.Statements {
+		if err := state.trace(ctx, statement, blockEnv); err != nil {
+			return err
+		}
 		result = Eval(ctx, state, statement, blockEnv)
 
 		in := object.TypeIn(
@@ -382,11 +408,10 @@ func evalForInStatement(
 	obj := Eval(ctx, state, forInStmt.Expr, env)
 	// 设置 in 后表达式的行号
 	state.UpdateLocation(forInStmt.Expr)
-	iterable, ok := obj.(object.Iterable)
-	if !ok {
-		return state.NewError("'%s' object is not iterable", obj.Type())
+	iterator, err := getIterator(ctx, state, obj)
+	if err != nil {
+		return err
 	}
-	iterator := iterable.Iter()
 	for {
 		// 设置 in 后表达式的行号
 		state.UpdateLocation(forInStmt.Expr)
@@ -399,30 +424,43 @@ func evalForInStatement(
 			state.HandleError(nextVal)
 			return nextVal
 		}
-		var values []object.Object
-		switch nextVal := nextVal.(type) {
-		case *object.Tuple:
-			values = append(values, nextVal.Elements...)
-		default:
-			values = append(values, nextVal)
-		}
-		if len(forInStmt.Targets) != len(values) {
-			state.UpdateLocation(forInStmt)
-			ret := object.WrongNumberUnpack(len(values), len(forInStmt.Targets))
-			state.HandleError(ret)
-			return ret
-		}
-		for i, target := range forInStmt.Targets {
-			enclosedEnv.Add(target.Value, values[i], forInStmt.Con)
+		if err := bindTargets(state, forInStmt, forInStmt.Targets, nextVal, enclosedEnv, forInStmt.Con); err != nil {
+			return err
 		}
 		ret := Eval(ctx, state, forInStmt.Body, enclosedEnv)
-		if IsError(ret) {
+		switch ret.(type) {
+		case *object.ReturnValue, *object.Error:
 			return ret
 		}
 	}
 	return nil
 }
 
+// bindTargets 把迭代产生的值绑定到 for-in 语句和推导式的变量，元组按元素解包，个数必须和变量相同
+func bindTargets(
+	state *WeiState,
+	node ast.Node,
+	targets []*ast.Identifier,
+	value object.Object,
+	env *object.Environment,
+	constant bool,
+) object.Object {
+	values := []object.Object{value}
+	if tuple, ok := value.(*object.Tuple); ok {
+		values = tuple.Elements
+	}
+	if len(targets) != len(values) {
+		state.UpdateLocation(node)
+		ret := object.WrongNumberUnpack(len(values), len(targets))
+		state.HandleError(ret)
+		return ret
+	}
+	for i, target := range targets {
+		env.Add(target.Value, values[i], constant)
+	}
+	return nil
+}
+
 func evalFunction(
 	ctx context.Context,
 	state *WeiState,
@@ -435,6 +473,9 @@ func evalFunction(
 			return state.NewError("function expected %d arguments but got %d", len(fn.Parameters), len(args))
 		}
 		extendedEnv := extendFunctionEnv(fn, args)
+		if fn.IsGenerator {
+			return newGenerator(ctx, state, fn, extendedEnv)
+		}
 		location := fn.Body.GetFileLocation()
 		state.CreateFrame(location.Filename, fn.Name)
 		evaluated := Eval(ctx, state, fn.Body, extendedEnv)
@@ -444,13 +485,13 @@ func evalFunction(
 		}
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
-		ret := fn.Fn(args...)
+		ret := fn.Call(newEvalRuntime(ctx, state), args...)
 		if IsError(ret) {
 			state.HandleError(ret)
 		}
 		return ret
 	case *object.BoundBuiltinMethod:
-		ret := fn.Fn(fn.This, args...)
+		ret := fn.Call(newEvalRuntime(ctx, state), args...)
 		if IsError(ret) {
 			state.HandleError(ret)
 		}
@@ -466,6 +507,9 @@ func evalFunction(
 		extendedEnv.Pass("this", fn.This(), true)
 		extendedEnv.Pass("cls", fn.Class(), true)
 		extendedEnv.Pass("super", fn.Super(), true)
+		if function.IsGenerator {
+			return newGenerator(ctx, state, function, extendedEnv)
+		}
 		location := function.Body.GetFileLocation()
 		state.CreateFrame(location.Filename, function.Name)
 		evaluated := Eval(ctx, state, function.Body, extendedEnv)
@@ -482,6 +526,9 @@ func evalFunction(
 		extendedEnv := extendFunctionEnv(function, args)
 		extendedEnv.Pass("cls", fn.Class(), true)
 		extendedEnv.Pass("super", fn.Super(), true)
+		if function.IsGenerator {
+			return newGenerator(ctx, state, function, extendedEnv)
+		}
 		location := function.Body.GetFileLocation()
 		state.CreateFrame(location.Filename, function.Name)
 		evaluated := Eval(ctx, state, function.Body, extendedEnv)
@@ -566,22 +613,44 @@ func evalExpressions(
 	return result
 }
 
+func evalSetLiteral(
+	ctx context.Context,
+	state *WeiState,
+	node *ast.SetLiteral,
+	env *object.Environment,
+) object.Object {
+	set := object.NewSet()
+	for _, elementNode := range node.Elements {
+		element := Eval(ctx, state, elementNode, env)
+		if IsError(element) {
+			return element
+		}
+		if err := set.Add(element); err != nil {
+			state.UpdateLocation(elementNode)
+			state.HandleError(err)
+			return err
+		}
+	}
+	return set
+}
+
 func evalDictLiteral(
 	ctx context.Context,
 	state *WeiState,
 	node *ast.DictLiteral,
 	env *object.Environment,
 ) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	dict := object.NewDict()
 
-	for keyNode, valueNode := range node.Pairs {
+	// 按照源码中的顺序求值
+	for _, keyNode := range node.Keys {
+		valueNode := node.Pairs[keyNode]
 		key := Eval(ctx, state, keyNode, env)
 		if IsError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
+		if _, ok := key.(object.Hashable); !ok {
 			state.UpdateLocation(keyNode)
 			return state.NewError("unhashable type: '%s'", key.Type())
 		}
@@ -590,14 +659,9 @@ func evalDictLiteral(
 		if IsError(value) {
 			return value
 		}
-
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{
-			Key:   key,
-			Value: value,
-		}
+		dict.Set(key, value)
 	}
-	return object.NewDict(pairs)
+	return dict
 }
 
 func evalUnaryExpression(
@@ -708,22 +772,160 @@ func evalBinaryOpExpression(
 	operator string,
 	left, right object.Object,
 ) object.Object {
+	switch operator {
+	case "in":
+		return evalInExpression(ctx, state, left, right)
+	case "not in":
+		ret := evalInExpression(ctx, state, left, right)
+		if IsError(ret) {
+			return ret
+		}
+		return object.NativeBoolToBooleanObject(!isTruthy(ret))
+	}
+
+	if operable, ok := left.(object.BinaryOperable); ok {
+		ret := operable.BinaryOp(operator, right)
+		if ret != nil {
+			if IsError(ret) {
+				state.HandleError(ret)
+			}
+			return ret
+		}
+	}
+
 	switch {
 	case left.TypeIs(object.INTEGER_OBJ) && right.TypeIs(object.INTEGER_OBJ):
 		return evalIntegerBinaryOpExpression(ctx, operator, left, right)
 	case left.TypeIs(object.STRING_OBJ) && right.TypeIs(object.STRING_OBJ):
 		return evalStringBinaryOpExpression(ctx, operator, left, right)
-
+	case left.TypeIs(object.LIST_OBJ) && right.TypeIs(object.LIST_OBJ) && operator == "+":
+		return left.(*object.List).Concat(right.(*object.List))
+	case left.TypeIs(object.LIST_OBJ) && right.TypeIs(object.INTEGER_OBJ) && operator == "*":
+		return evalRepeatExpression(state, left, right.(*object.Integer).Value)
+	case left.TypeIs(object.INTEGER_OBJ) && right.TypeIs(object.LIST_OBJ) && operator == "*":
+		return evalRepeatExpression(state, right, left.(*object.Integer).Value)
+	case left.TypeIs(object.STRING_OBJ) && right.TypeIs(object.INTEGER_OBJ) && operator == "*":
+		return evalRepeatExpression(state, left, right.(*object.Integer).Value)
+	case left.TypeIs(object.INTEGER_OBJ) && right.TypeIs(object.STRING_OBJ) && operator == "*":
+		return evalRepeatExpression(state, right, left.(*object.Integer).Value)
+
+	// 列表、元组、字典比较其中的值，其他对象比较是否为同一个对象
 	case operator == "==":
-		return object.NativeBoolToBooleanObject(left == right)
+		return object.NativeBoolToBooleanObject(left == right || object.Equal(left, right))
 	case operator == "!=":
-		return object.NativeBoolToBooleanObject(left != right)
+		return object.NativeBoolToBooleanObject(left != right && !object.Equal(left, right))
 	default:
 		return state.NewError("unsupported operand type for %s: '%s' and '%s'",
 			operator, left.Type(), right.Type())
 	}
 }
 
+// evalRepeatExpression 计算 sequence * n ，结果太长时返回错误
+func evalRepeatExpression(state *WeiState, sequence object.Object, n int64) object.Object {
+	var ret object.Object
+	var err *object.Error
+	switch sequence := sequence.(type) {
+	case *object.List:
+		ret, err = sequence.Repeat(n)
+	case *object.String:
+		ret, err = sequence.Repeat(n)
+	default:
+		return state.Unreachable("repeat")
+	}
+	if err != nil {
+		state.HandleError(err)
+		return err
+	}
+	return ret
+}
+
+// evalInExpression 计算 element in container
+//
+// 字符串判断子串，列表、元组判断元素是否相等，字典、集合判断键，
+// range 直接计算，实例调用 __contains__ 方法，没有 __contains__ 时和其他可迭代对象一样，逐个比较迭代返回的值
+func evalInExpression(
+	ctx context.Context,
+	state *WeiState,
+	element, container object.Object,
+) object.Object {
+	switch container := container.(type) {
+	case *object.String:
+		sub, ok := element.(*object.String)
+		if !ok {
+			return state.NewError("'in <str>' requires str as left operand, not '%s'", element.Type())
+		}
+		return object.NativeBoolToBooleanObject(strings.Contains(container.Value, sub.Value))
+	case *object.List:
+		return object.NativeBoolToBooleanObject(containsEqual(container.Elements, element))
+	case *object.Tuple:
+		return object.NativeBoolToBooleanObject(containsEqual(container.Elements, element))
+	case *object.Dict:
+		_, ok, err := container.Get(element)
+		if err != nil {
+			state.HandleError(err)
+			return err
+		}
+		return object.NativeBoolToBooleanObject(ok)
+	case object.SetLike:
+		ok, err := container.Has(element)
+		if err != nil {
+			state.HandleError(err)
+			return err
+		}
+		return object.NativeBoolToBooleanObject(ok)
+	case *object.Instance:
+		method := container.GetMethod("__contains__")
+		if method == nil {
+			if container.GetMethod("__iter__") == nil {
+				return state.NewError("argument of type '%s' is not a container", container.ClassName())
+			}
+			iterator, err := getIterator(ctx, state, container)
+			if err != nil {
+				return err
+			}
+			return iteratorContains(state, iterator, element)
+		}
+		ret := evalFunction(ctx, state, method, []object.Object{element})
+		if IsError(ret) {
+			return ret
+		}
+		return object.NativeBoolToBooleanObject(isTruthy(ret))
+	case *object.Range:
+		return object.NativeBoolToBooleanObject(container.Has(element))
+	case object.Iterable:
+		return iteratorContains(state, container.Iter(), element)
+	default:
+		return state.NewError("argument of type '%s' is not a container", container.Type())
+	}
+}
+
+// iteratorContains 逐个比较迭代器产生的值，迭代器可能是惰性的，找到之后就不再继续迭代
+func iteratorContains(state *WeiState, iterator object.Iterator, element object.Object) object.Object {
+	for {
+		next := iterator.Next()
+		if next == object.StopIteration {
+			return object.FALSE
+		}
+		if IsError(next) {
+			state.HandleError(next)
+			return next
+		}
+		if next == element || object.Equal(next, element) {
+			return object.TRUE
+		}
+	}
+}
+
+// containsEqual 判断 elements 中是否有和 element 相等的值
+func containsEqual(elements []object.Object, element object.Object) bool {
+	for _, e := range elements {
+		if e == element || object.Equal(e, element) {
+			return true
+		}
+	}
+	return false
+}
+
 //goland:noinspection GoUnusedParameter
 func evalIntegerBinaryOpExpression(
 	ctx context.Context,
@@ -816,6 +1018,9 @@ func evalIdentifier(
 	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
+	if constant, ok := builtinConstants[node.Value]; ok {
+		return constant.value
+	}
 	return object.NewError("undefined: '%s'", node.Value)
 }
 
@@ -743,6 +743,20 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`oct(0o1234, 1)`, "wrong number of arguments. got=2, want=1", true},
 		{`oct('')`, "wrong argument type for oct(): 'str'", true},
 
+		{`ord('a')`, 97, false},
+		{`ord('中')`, 20013, false},
+		{`ord('')`, "ord() expected a character, but string of length 0 found", true},
+		{`ord('ab')`, "ord() expected a character, but string of length 2 found", true},
+		{`ord(1)`, "wrong argument type for ord(): 'int'", true},
+		{`ord()`, "wrong number of arguments. got=0, want=1", true},
+
+		{`chr(97)`, "a", false},
+		{`chr(20013)`, "中", false},
+		{`chr(ord('😀'))`, "😀", false},
+		{`chr(-1)`, "chr() arg not in range(0x110000)", true},
+		{`chr(0x110000)`, "chr() arg not in range(0x110000)", true},
+		{`chr('a')`, "wrong argument type for chr(): 'str'", true},
+
 		{`print()`, object.NULL, false},
 		{`print(1, -1, "abc", true, false, null, [], {})`, object.NULL, false},
 
@@ -982,26 +996,33 @@ func TestHashLiterals(t *testing.T) {
 		t.Fatalf("Eval didn't return Dict. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		object.TRUE.HashKey():                      5,
-		object.FALSE.HashKey():                     6,
+	expected := []struct {
+		key   object.Object
+		value int64
+	}{
+		{object.NewString("one"), 1},
+		{object.NewString("two"), 2},
+		{object.NewString("three"), 3},
+		{object.NewInteger(4), 4},
+		{object.TRUE, 5},
+		{object.FALSE, 6},
 	}
 
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Dict has wrong num of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Dict has wrong num of pairs. got=%d", result.Len())
 	}
 
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	// 按插入顺序排列
+	for i, pair := range result.Pairs() {
+		if pair.Key.String() != expected[i].key.String() {
+			t.Errorf("pairs[%d] has wrong key. want=%s, got=%s", i, expected[i].key, pair.Key)
+		}
+		value, ok, _ := result.Get(expected[i].key)
 		if !ok {
-			t.Errorf("no pair for given key in Pairs")
+			t.Errorf("no pair for key %s", expected[i].key)
+			continue
 		}
-
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, value, expected[i].value)
 	}
 }
 
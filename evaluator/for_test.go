@@ -48,6 +48,34 @@ n`, 9,
 		}
 		c`, "d",
 			false},
+		// return 结束整个函数，不只是当前这一轮循环
+		{`
+fn f() {
+    for (var i, x in [1, 2]) {
+        return x
+    }
+    return 99
+}
+f()`, 1, false},
+		{`
+fn f(n) {
+    for (var x in range(n)) {
+        if (x == 3) {
+            return x * 10
+        }
+    }
+    return -1
+}
+f(10) + f(2)`, 29, false},
+		{`
+fn f() {
+    for (var i, x in [1, 2]) {
+        for (var k, v in {"a": 1}) {
+            return k
+        }
+    }
+}
+f()`, "a", false},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,262 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"weilang/ast"
+	"weilang/object"
+)
+
+// generatorKey 上下文中保存正在执行函数体的生成器，yield 通过它把值交给调用者
+type generatorKey struct{}
+
+// generatorExit close 时 yield 返回的错误，让函数体结束执行，不会记录为异常
+var generatorExit = object.NewError("generator exit")
+
+// generatorResult 函数体交给调用者的结果，done 为 true 时函数体已经结束
+type generatorResult struct {
+	value object.Object
+	done  bool
+}
+
+// Generator 调用含有 yield 的函数返回的生成器
+//
+// 函数体在单独的 goroutine 中执行，第一次恢复执行时才启动。调用者和函数体通过 channel 交替执行，
+// 同一时刻只有一方在运行，所以可以共用 WeiState 。
+//
+// 函数体的 goroutine 只引用 generator ，不引用 Generator 。没有执行完的生成器不再被引用时，
+// Generator 的 finalizer 取消上下文，阻塞在 yield 的 goroutine 随之退出；
+// 执行程序的上下文取消时也是如此
+type Generator struct {
+	*generator
+}
+
+// generator 生成器的执行状态，由调用者和函数体的 goroutine 共享
+type generator struct {
+	function *object.Function
+	env      *object.Environment
+	state    *WeiState
+	// root 执行程序的上下文，嵌套的生成器也从它派生，外层的生成器结束不会影响里面创建的生成器
+	root   context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	// resume 调用者发送给函数体的值，作为 yield 表达式的值
+	resume chan object.Object
+	// yield 函数体产生的值，函数体结束时发送 StopIteration 或者错误
+	yield    chan generatorResult
+	started  bool
+	running  bool
+	finished bool
+}
+
+func newGenerator(ctx context.Context, state *WeiState, fn *object.Function, env *object.Environment) *Generator {
+	root := ctx
+	if parent, ok := ctx.Value(generatorKey{}).(*generator); ok {
+		root = parent.root
+	}
+	g := &generator{
+		function: fn,
+		env:      env,
+		state:    state,
+		root:     root,
+		resume:   make(chan object.Object),
+		yield:    make(chan generatorResult),
+	}
+	g.ctx, g.cancel = context.WithCancel(root)
+	wrapper := &Generator{generator: g}
+	runtime.SetFinalizer(wrapper, func(w *Generator) {
+		w.cancel()
+	})
+	return wrapper
+}
+
+func (g *Generator) Type() object.ObjectType {
+	return object.GENERATOR_OBJ
+}
+
+func (g *Generator) TypeIs(objectType object.ObjectType) bool {
+	return g.Type() == objectType
+}
+
+func (g *Generator) TypeNotIs(objectType object.ObjectType) bool {
+	return g.Type() != objectType
+}
+
+func (g *Generator) String() string {
+	return fmt.Sprintf("<generator object %s at %p>", g.function.Name, g)
+}
+
+func (g *Generator) Iter() object.Iterator {
+	return g
+}
+
+// Next 相当于 send(null) ，函数体结束后返回 object.StopIteration
+func (g *Generator) Next() object.Object {
+	return g.send(object.NULL)
+}
+
+// GetAttribute 生成器的方法
+//
+//	generator.send(value) 恢复执行，value 作为 yield 表达式的值，返回下一个产生的值
+//	generator.close() 结束生成器，正在 yield 的函数体不再继续执行
+func (g *Generator) GetAttribute(name string) object.Object {
+	switch name {
+	case "send":
+		return &object.Builtin{
+			Name: name,
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return object.WrongNumberArgument(len(args), 1)
+				}
+				if !g.started && args[0] != object.NULL {
+					return object.NewError("can't send non-null value to a just-started generator")
+				}
+				ret := g.send(args[0])
+				if ret == object.StopIteration {
+					return object.NewError("StopIteration")
+				}
+				return ret
+			},
+		}
+	case "close":
+		return &object.Builtin{
+			Name: name,
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return object.WrongNumberArgument(len(args), 0)
+				}
+				return g.close()
+			},
+		}
+	}
+	return object.NewError("'%s' object has not attribute '%s'", g.Type(), name)
+}
+
+//goland:noinspection GoUnusedParameter
+func (g *Generator) SetAttribute(name string, value object.Object) object.Object {
+	return object.NewError("'%s' object has not attribute '%s'", g.Type(), name)
+}
+
+// send 恢复函数体的执行，返回产生的值；函数体结束时返回 object.StopIteration ，出错时返回错误
+func (g *generator) send(value object.Object) object.Object {
+	if g.running {
+		return g.state.NewError("generator already executing")
+	}
+	if g.finished {
+		return object.StopIteration
+	}
+	result := g.switchTo(value)
+	if result.done {
+		g.finish()
+	}
+	return result.value
+}
+
+// close 结束生成器，还没有开始或者已经结束的生成器直接标记为结束
+func (g *generator) close() object.Object {
+	if g.running {
+		return g.state.NewError("generator already executing")
+	}
+	if !g.started || g.finished {
+		g.finish()
+		return object.NULL
+	}
+	result := g.switchTo(generatorExit)
+	g.finish()
+	if !result.done {
+		return g.state.NewError("generator ignored close")
+	}
+	if IsError(result.value) && result.value != generatorExit && result.value != object.StopIteration {
+		return result.value
+	}
+	return object.NULL
+}
+
+// switchTo 把 value 交给函数体，等待函数体产生下一个值或者结束
+// 函数体执行期间使用生成器的栈帧，回到调用者时销毁
+func (g *generator) switchTo(value object.Object) generatorResult {
+	g.running = true
+	defer func() { g.running = false }()
+	filename := g.function.Body.GetFileLocation().Filename
+	if g.started {
+		g.state.ResumeFrame(filename, g.function.Name)
+	} else {
+		g.state.CreateFrame(filename, g.function.Name)
+	}
+	defer g.state.DestroyFrame()
+
+	if !g.started {
+		g.started = true
+		go g.run()
+	} else {
+		select {
+		case g.resume <- value:
+		case <-g.ctx.Done():
+			return generatorResult{value: g.state.NewError("%s", g.ctx.Err()), done: true}
+		}
+	}
+	select {
+	case result := <-g.yield:
+		return result
+	case <-g.ctx.Done():
+		return generatorResult{value: g.state.NewError("%s", g.ctx.Err()), done: true}
+	}
+}
+
+// run 在单独的 goroutine 中执行函数体，return 的值会被丢弃
+func (g *generator) run() {
+	ctx := context.WithValue(g.ctx, generatorKey{}, g)
+	evaluated := Eval(ctx, g.state, g.function.Body, g.env)
+	if !IsError(evaluated) {
+		evaluated = object.StopIteration
+	}
+	select {
+	case g.yield <- generatorResult{value: evaluated, done: true}:
+	case <-g.ctx.Done():
+	}
+}
+
+// finish 标记生成器结束，释放上下文
+func (g *generator) finish() {
+	g.finished = true
+	g.cancel()
+}
+
+// suspend 在函数体中执行，把 value 交给调用者，然后等待调用者恢复执行，返回 send 传入的值
+// 生成器被关闭时返回 generatorExit ，函数体随之结束。
+// 上下文被取消时调用者可能还在运行，函数体不能再访问 WeiState ，直接退出 goroutine
+func (g *generator) suspend(value object.Object) object.Object {
+	select {
+	case g.yield <- generatorResult{value: value}:
+	case <-g.ctx.Done():
+		runtime.Goexit()
+	}
+	select {
+	case sent := <-g.resume:
+		return sent
+	case <-g.ctx.Done():
+		runtime.Goexit()
+	}
+	return nil
+}
+
+func evalYieldExpression(
+	ctx context.Context,
+	state *WeiState,
+	node *ast.YieldExpression,
+	env *object.Environment,
+) object.Object {
+	g, ok := ctx.Value(generatorKey{}).(*generator)
+	if !ok {
+		return state.NewError("'yield' outside function")
+	}
+	var value object.Object = object.NULL
+	if node.Value != nil {
+		value = Eval(ctx, state, node.Value, env)
+		if IsError(value) {
+			return value
+		}
+	}
+	return g.suspend(value)
+}
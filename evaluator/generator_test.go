@@ -0,0 +1,179 @@
+package evaluator
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+	"weilang/lexer"
+	"weilang/object"
+	"weilang/parser"
+)
+
+const countGenerator = `
+fn count(n) {
+    var i = 0
+    while (i < n) {
+        yield i
+        i = i + 1
+    }
+}
+fn accumulate() {
+    var total = 0
+    while (true) {
+        var v = yield total
+        if (v == null) {
+            return
+        }
+        total = total + v
+    }
+}
+`
+
+func TestGenerator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		{`var s = 0; for (var x in count(4)) { s = s * 10 + x }; s`, 123, false},
+		{`var n = 0; for (var x in count(0)) { n = n + 1 }; n`, 0, false},
+		{`var g = count(2); next(g) + next(g)`, 1, false},
+		{`var g = count(1); next(g); next(g, 100)`, 100, false},
+		{`var g = count(1); next(g); next(g)`, "StopIteration", true},
+		{`var g = count(1); next(g); next(g, 1); next(g, 2)`, 2, false},
+		{`len(set(count(5)))`, 5, false},
+		{`3 in count(5)`, true, false},
+		{`type(count(1))`, "generator", false},
+		{`var g = count(3); iter(g) == g`, true, false},
+
+		// return 结束生成器
+		{`fn f() { yield 1; return 5; yield 2 }; var n = 0; for (var x in f()) { n = n + x }; n`, 1, false},
+		{`fn f() { yield; yield }; var g = f(); next(g)`, nil, false},
+		{`fn f() { for (var i, x in [1, 2]) { yield x; return 5 }; yield 100 }; list(f())`, "[1]", false},
+		{`fn f() { for (var x in range(10)) { if (x == 2) { return }; yield x }; yield 100 }; list(f())`, "[0, 1]", false},
+
+		// send
+		{`var a = accumulate(); next(a); a.send(5); a.send(10)`, 15, false},
+		{`var a = accumulate(); a.send(null)`, 0, false},
+		{`var a = accumulate(); a.send(1)`, "can't send non-null value to a just-started generator", true},
+		{`var a = accumulate(); next(a); a.send(null)`, "StopIteration", true},
+		{`fn f() { var x = yield 1; yield x * 2 }; var g = f(); next(g); next(g)`, "unsupported operand type for *: 'null' and 'int'", true},
+
+		// close
+		{`var g = count(10); next(g); g.close(); next(g, "closed")`, "closed", false},
+		{`var g = count(10); g.close(); next(g, "closed")`, "closed", false},
+		{`var g = count(10); g.close()`, nil, false},
+
+		// 错误
+		{`fn f() { yield 1; missing }; var n = 0; for (var x in f()) { n = n + x }; n`, "undefined: 'missing'", true},
+		{`fn f() { yield next(g) }; var g = f(); next(g)`, "generator already executing", true},
+		{`count(1).foo`, "'generator' object has not attribute 'foo'", true},
+
+		// 方法和嵌套的生成器
+		{`class C { fn items() { yield 1; yield 2 } }; var s = 0; for (var x in C().items()) { s = s + x }; s`, 3, false},
+		{`fn outer() { yield count(3) }; var inner = next(outer()); next(inner); next(inner)`, 1, false},
+		{`fn outer() { for (var x in count(3)) { yield x * 10 } }; var s = 0; for (var x in outer()) { s = s + x }; s`, 30, false},
+		{`var f = fn() { yield 7 }; next(f())`, 7, false},
+		// 嵌套函数中的 yield 不会让外层函数成为生成器
+		{`fn f() { var g = fn() { yield 1 }; return g }; type(f())`, "function", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, countGenerator+tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if tt.isError {
+				errObj, ok := evaluated.(*object.Error)
+				if !ok {
+					t.Errorf("%s: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+					continue
+				}
+				if errObj.Message != expected {
+					t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+			if evaluated == nil || evaluated.String() != expected {
+				t.Errorf("%s: expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
+
+// TestGeneratorCancel 上下文取消后，阻塞在 yield 的生成器的 goroutine 都会结束
+func TestGeneratorCancel(t *testing.T) {
+	input := countGenerator + `
+var gens = []
+var i = 0
+while (i < 20) {
+    var g = count(100)
+    next(g)
+    gens.append(g)
+    i = i + 1
+}
+`
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mod := object.NewModule("")
+	state := NewWeiState(mod)
+	state.CreateFrame("", "<module>")
+	evaluated := Eval(ctx, state, program, mod.GetEnv())
+	if IsError(evaluated) {
+		t.Fatalf("eval error: %s", evaluated)
+	}
+	if n := runtime.NumGoroutine(); n < before+20 {
+		t.Fatalf("goroutines = %d, want at least %d suspended generators", n, before+20)
+	}
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: before=%d, after=%d", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestGeneratorDropped 没有执行完就不再被引用的生成器，goroutine 会在垃圾回收后结束
+func TestGeneratorDropped(t *testing.T) {
+	input := countGenerator + `
+var i = 0
+while (i < 200) {
+    var g = count(100)
+    next(g)
+    i = i + 1
+}
+var s = 0
+for (var a, b in zip(count(100), count(3))) {
+    s = s + a + b
+}
+var found = 2 in count(100)
+s
+`
+	before := runtime.NumGoroutine()
+	evaluated := testEval(t, input)
+	testIntegerObject(t, evaluated, 6)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: before=%d, after=%d", before, runtime.NumGoroutine())
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
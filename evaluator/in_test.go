@@ -0,0 +1,62 @@
+package evaluator
+
+import (
+	"testing"
+	"weilang/object"
+)
+
+func TestInExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"ell" in "hello"`, true},
+		{`"" in "hello"`, true},
+		{`"你" in "你好"`, true},
+		{`"x" not in "hello"`, true},
+		{`1 in "hello"`, "'in <str>' requires str as left operand, not 'int'"},
+		{`1 in [1, 2]`, true},
+		{`3 in [1, 2]`, false},
+		{`3 not in [1, 2]`, true},
+		{`[1, [2]] in [0, [1, [2]]]`, true},
+		{`{"a": 1} in [{"a": 1}]`, true},
+		{`"1" in [1]`, false},
+		{`var l = []; l.append(l); l in l`, true},
+		{`"a" in {"a": 1}`, true},
+		{`1 in {"a": 1}`, false},
+		{`[] in {"a": 1}`, "unhashable type: 'list'"},
+		{`2 in {1, 2}`, true},
+		{`2 not in frozenset([1, 2])`, false},
+		{`1 in 1`, "argument of type 'int' is not a container"},
+		{`not 1 in [1]`, false},
+		{`1 in [1] == true`, true},
+		{`
+class Evens {
+    fn __contains__(x) {
+        return x % 2 == 0
+    }
+}
+4 in Evens() and 3 not in Evens()`, true},
+		{`
+class Empty {
+}
+1 in Empty()`, "argument of type 'Empty' is not a container"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%s: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Message)
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package evaluator
+
+import (
+	"context"
+	"weilang/object"
+)
+
+// instanceIterator 定义了 __next__ 方法的实例，作为 Go 中的 object.Iterator 使用
+type instanceIterator struct {
+	ctx   context.Context
+	state *WeiState
+	next  *object.BoundMethod
+}
+
+// Next 调用 __next__ ，返回 StopIteration 时转换为 object.StopIteration
+func (it *instanceIterator) Next() object.Object {
+	ret := evalFunction(it.ctx, it.state, it.next, nil)
+	if ret == object.STOP_ITERATION {
+		return object.StopIteration
+	}
+	return ret
+}
+
+// isIterator 判断 obj 是否为迭代器：定义了 __next__ 方法的实例或者 Go 中的迭代器
+func isIterator(obj object.Object) bool {
+	if ins, ok := obj.(*object.Instance); ok {
+		return ins.GetMethod("__next__") != nil
+	}
+	_, ok := obj.(object.Iterator)
+	return ok
+}
+
+// typeName 类型名，实例返回类名
+func typeName(obj object.Object) string {
+	if ins, ok := obj.(*object.Instance); ok {
+		return ins.ClassName()
+	}
+	return string(obj.Type())
+}
+
+// evalIter 计算 iter(obj) ，实例调用 __iter__ 方法，其他可迭代对象返回 Go 中的迭代器
+func evalIter(ctx context.Context, state *WeiState, obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Instance:
+		method := obj.GetMethod("__iter__")
+		if method == nil {
+			return state.NewError("'%s' object is not iterable", obj.ClassName())
+		}
+		ret := evalFunction(ctx, state, method, nil)
+		if IsError(ret) {
+			return ret
+		}
+		if !isIterator(ret) {
+			return state.NewError("__iter__ returned non-iterator of type '%s'", typeName(ret))
+		}
+		return ret
+	case object.Iterable:
+		return obj.Iter().(object.Object)
+	default:
+		return state.NewError("'%s' object is not iterable", obj.Type())
+	}
+}
+
+// getIterator 返回 obj 的迭代器，用于 for-in 等需要遍历对象的地方
+func getIterator(ctx context.Context, state *WeiState, obj object.Object) (object.Iterator, object.Object) {
+	it := evalIter(ctx, state, obj)
+	if IsError(it) {
+		return nil, it
+	}
+	if ins, ok := it.(*object.Instance); ok {
+		return &instanceIterator{ctx: ctx, state: state, next: ins.GetMethod("__next__")}, nil
+	}
+	return it.(object.Iterator), nil
+}
+
+// evalNext 计算 next(it[, default]) ，迭代结束时返回 defaultValue ，defaultValue 为 nil 时报错
+func evalNext(ctx context.Context, state *WeiState, it object.Object, defaultValue object.Object) object.Object {
+	var ret object.Object
+	switch it := it.(type) {
+	case *object.Instance:
+		method := it.GetMethod("__next__")
+		if method == nil {
+			return state.NewError("'%s' object is not an iterator", it.ClassName())
+		}
+		ret = evalFunction(ctx, state, method, nil)
+		if ret == object.STOP_ITERATION {
+			ret = object.StopIteration
+		}
+	case object.Iterator:
+		ret = it.Next()
+		if IsError(ret) && ret != object.StopIteration {
+			state.HandleError(ret)
+		}
+	default:
+		return state.NewError("'%s' object is not an iterator", it.Type())
+	}
+	if ret != object.StopIteration {
+		return ret
+	}
+	if defaultValue != nil {
+		return defaultValue
+	}
+	// 不直接返回 object.StopIteration ，避免外层的 for-in 把它当作自己的迭代结束
+	return state.NewError("StopIteration")
+}
+
+// valueIterator 和 object.Values 相同，另外支持定义了 __iter__ 方法的实例
+func valueIterator(ctx context.Context, state *WeiState, obj object.Object) (object.Iterator, object.Object) {
+	if _, ok := obj.(*object.Instance); ok {
+		return getIterator(ctx, state, obj)
+	}
+	iterator, err := object.Values(obj)
+	if err != nil {
+		state.HandleError(err)
+		return nil, err
+	}
+	return iterator, nil
+}
+
+// iterValues 和 object.IterValues 相同，另外支持定义了 __iter__ 方法的实例
+func iterValues(ctx context.Context, state *WeiState, obj object.Object) ([]object.Object, object.Object) {
+	iterator, err := valueIterator(ctx, state, obj)
+	if err != nil {
+		return nil, err
+	}
+	values, e := object.Collect(iterator)
+	if e != nil {
+		state.HandleError(e)
+		return nil, e
+	}
+	return values, nil
+}
+
+// mapIterator map(function, *iterables) 返回的迭代器，依次从每个迭代器中取一个值作为参数调用 function
+type mapIterator struct {
+	ctx       context.Context
+	state     *WeiState
+	function  object.Object
+	iterators []object.Iterator
+}
+
+func (m *mapIterator) Type() object.ObjectType {
+	return object.MAP_OBJ
+}
+
+func (m *mapIterator) TypeIs(objectType object.ObjectType) bool {
+	return m.Type() == objectType
+}
+
+func (m *mapIterator) TypeNotIs(objectType object.ObjectType) bool {
+	return m.Type() != objectType
+}
+
+func (m *mapIterator) String() string {
+	return "<map>"
+}
+
+func (m *mapIterator) Iter() object.Iterator {
+	return m
+}
+
+func (m *mapIterator) Next() object.Object {
+	args := make([]object.Object, 0, len(m.iterators))
+	for _, iterator := range m.iterators {
+		next := iterator.Next()
+		if IsError(next) {
+			return next
+		}
+		args = append(args, next)
+	}
+	return evalFunction(m.ctx, m.state, m.function, args)
+}
+
+// filterIterator filter(function, iterable) 返回的迭代器，只产生 function 返回真值的值，
+// function 为 null 时只产生本身为真值的值
+type filterIterator struct {
+	ctx      context.Context
+	state    *WeiState
+	function object.Object
+	iterator object.Iterator
+}
+
+func (f *filterIterator) Type() object.ObjectType {
+	return object.FILTER_OBJ
+}
+
+func (f *filterIterator) TypeIs(objectType object.ObjectType) bool {
+	return f.Type() == objectType
+}
+
+func (f *filterIterator) TypeNotIs(objectType object.ObjectType) bool {
+	return f.Type() != objectType
+}
+
+func (f *filterIterator) String() string {
+	return "<filter>"
+}
+
+func (f *filterIterator) Iter() object.Iterator {
+	return f
+}
+
+func (f *filterIterator) Next() object.Object {
+	for {
+		next := f.iterator.Next()
+		if IsError(next) {
+			return next
+		}
+		if f.function == object.NULL {
+			if isTruthy(next) {
+				return next
+			}
+			continue
+		}
+		ret := evalFunction(f.ctx, f.state, f.function, []object.Object{next})
+		if IsError(ret) {
+			return ret
+		}
+		if isTruthy(ret) {
+			return next
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package evaluator
+
+import (
+	"testing"
+	"weilang/object"
+)
+
+const countdownClass = `
+class Countdown {
+    var n
+    fn __init__(n) {
+        this.n = n
+    }
+    fn __iter__() {
+        return this
+    }
+    fn __next__() {
+        if (this.n == 0) {
+            return StopIteration
+        }
+        this.n = this.n - 1
+        return this.n + 1
+    }
+}
+`
+
+func TestIteratorProtocol(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		{`var s = 0; for (var x in Countdown(4)) { s = s * 10 + x }; s`, 4321, false},
+		{`var n = 0; for (var x in Countdown(0)) { n = n + 1 }; n`, 0, false},
+		{`3 in Countdown(5)`, true, false},
+		{`6 in Countdown(5)`, false, false},
+		{`len(set(Countdown(3)))`, 3, false},
+		{`var c = Countdown(2); next(c) + next(c)`, 3, false},
+		{`var c = Countdown(1); next(c); next(c, 100)`, 100, false},
+		{`var c = Countdown(1); next(c); next(c)`, "StopIteration", true},
+		{`iter(Countdown(1)) == iter(Countdown(1))`, false, false},
+		{`var c = Countdown(1); iter(c) == c`, true, false},
+
+		// Go 中的迭代器
+		{`var it = iter([10, 20]); next(it); next(it)`, "(1, 20)", false},
+		{`var it = iter([10]); next(it); next(it, "end")`, "end", false},
+		{`next(iter("ab"))`, "(0, a)", false},
+		{`var it = iter({1, 2}); next(it); next(it)`, 2, false},
+		{`var it = iter([1, 2]); next(it); var n = 0; for (var i, e in it) { n = n + e }; n`, 2, false},
+		{`var it = iter([]); next(it)`, "StopIteration", true},
+
+		// 错误
+		{`iter(1)`, "'int' object is not iterable", true},
+		{`iter()`, "wrong number of arguments. got=0, want=1", true},
+		{`next([1])`, "'list' object is not an iterator", true},
+		{`next()`, "wrong number of arguments. got=0, want=1-2", true},
+		{`class A {
+}
+for (var x in A()) {}`, "'A' object is not iterable", true},
+		{`class A {
+    fn __iter__() {
+        return 1
+    }
+}
+iter(A())`, "__iter__ returned non-iterator of type 'int'", true},
+		{`class A {
+}
+next(A())`, "'A' object is not an iterator", true},
+		// __next__ 中的错误会中断循环
+		{`class A {
+    fn __iter__() {
+        return this
+    }
+    fn __next__() {
+        return 1 + "a"
+    }
+}
+for (var x in A()) {}`, "unsupported operand type for +: 'int' and 'str'", true},
+		// 内层迭代器结束不会影响外层的 for-in
+		{`class A {
+    fn __iter__() {
+        return this
+    }
+    fn __next__() {
+        return next(iter([]))
+    }
+}
+for (var x in A()) {}`, "StopIteration", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, countdownClass+tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if tt.isError {
+				errObj, ok := evaluated.(*object.Error)
+				if !ok {
+					t.Errorf("%s: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+					continue
+				}
+				if errObj.Message != expected {
+					t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+			if evaluated == nil || evaluated.String() != expected {
+				t.Errorf("%s: expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestLazyIterators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		// range
+		{`var s = 0; for (var i in range(5)) { s = s + i }; s`, 10, false},
+		{`list(range(2, 5))`, "[2, 3, 4]", false},
+		{`list(range(10, 0, -3))`, "[10, 7, 4, 1]", false},
+		{`list(range(0))`, "[]", false},
+		{`range(3)`, "range(0, 3)", false},
+		{`range(1, 9, 2)`, "range(1, 9, 2)", false},
+		{`len(range(1, 10, 3))`, 3, false},
+		{`var r = range(3); len(list(r)) + len(list(r))`, 6, false},
+		{`7 in range(1, 10, 3)`, true, false},
+		{`8 in range(1, 10, 3)`, false, false},
+		{`"1" in range(3)`, false, false},
+		{`bool(range(0))`, false, false},
+		{`range(1, 2, 0)`, "range() arg 3 must not be zero", true},
+		{`len(range(-9223372036854775807, 9223372036854775807))`, "range() result has too many items", true},
+		{`len(range(-9223372036854775807, 9223372036854775807, 2))`, 9223372036854775807, false},
+		{`9223372036854775805 in range(-9223372036854775807, 9223372036854775807, 2)`, true, false},
+		{`list(reversed(range(-9223372036854775807, 9223372036854775807, 9223372036854775807)))`, "[0, -9223372036854775807]", false},
+		{`range("3")`, "wrong argument type: 'str' at 1", true},
+		{`range(0, 3, "1")`, "wrong argument type: 'str' at 3", true},
+		{`range()`, "wrong number of arguments. got=0, want=1-3", true},
+
+		// enumerate zip
+		{`var s = 0; for (var i, v in enumerate([10, 20], 1)) { s = s + i * v }; s`, 50, false},
+		{`list(enumerate("ab"))`, "[(0, a), (1, b)]", false},
+		{`list(enumerate(Countdown(2)))`, "[(0, 2), (1, 1)]", false},
+		{`enumerate(1)`, "'int' object is not iterable", true},
+		{`enumerate([1], "a")`, "wrong argument type: 'str' at 2", true},
+		{`list(zip([1, 2, 3], "ab"))`, "[(1, a), (2, b)]", false},
+		{`list(zip())`, "[]", false},
+		{`var s = 0; for (var a, b in zip([1, 2], {10: 0, 20: 0})) { s = s + a * b }; s`, 50, false},
+
+		// map filter 调用 Weilang 函数
+		{`list(map(fn(x) { return x * x }, range(4)))`, "[0, 1, 4, 9]", false},
+		{`list(map(fn(a, b) { return a + b }, [1, 2], [10, 20, 30]))`, "[11, 22]", false},
+		{`list(filter(fn(x) { return x % 2 }, range(7)))`, "[1, 3, 5]", false},
+		{`list(filter(null, [0, 1, "", "a", []]))`, "[1, a]", false},
+		{`list(map(fn(x) { return x + 1 }, filter(fn(x) { return x > 2 }, [1, 5, 3])))`, "[6, 4]", false},
+		{`var n = 0; var m = map(fn(x) { n = n + 1; return x }, range(10)); next(m); next(m); n`, 2, false},
+		{`list(map(fn(x) { return x + "a" }, [1]))`, "unsupported operand type for +: 'int' and 'str'", true},
+		{`map(fn(x) { return x })`, "wrong number of arguments. got=1, want at least 2", true},
+		{`type(map(fn(x) { return x }, []))`, "map", false},
+
+		// reversed
+		{`list(reversed([1, 2, 3]))`, "[3, 2, 1]", false},
+		{`list(reversed("abc"))`, "[c, b, a]", false},
+		{`list(reversed(range(1, 10, 3)))`, "[7, 4, 1]", false},
+		{`list(reversed(range(0)))`, "[]", false},
+		{`list(reversed({1: 2, 3: 4}))`, "[3, 1]", false},
+		{`reversed({1})`, "'set' object is not reversible", true},
+
+		// list dict tuple
+		{`list()`, "[]", false},
+		{`list("ab")`, "[a, b]", false},
+		{`list({1: 2})`, "[1]", false},
+		{`list(Countdown(3))`, "[3, 2, 1]", false},
+		{`list(1)`, "'int' object is not iterable", true},
+		{`tuple([1, 2])`, "(1, 2)", false},
+		{`len(tuple(range(4)))`, 4, false},
+		{`dict()`, "{}", false},
+		{`dict(zip("ab", [1, 2]))`, "{a: 1, b: 2}", false},
+		{`var d = {1: 2}; var c = dict(d); c[3] = 4; len(d)`, 1, false},
+		{`dict([[1, 2], "ab"])`, "{1: 2, a: b}", false},
+		{`dict([1])`, "cannot convert dictionary update sequence element #0 to a sequence", true},
+		{`dict([[1, 2, 3]])`, "dictionary update sequence element #0 has length 3; 2 is required", true},
+		{`dict([[[], 1]])`, "unhashable type: 'list'", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, countdownClass+tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if tt.isError {
+				errObj, ok := evaluated.(*object.Error)
+				if !ok {
+					t.Errorf("%s: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+					continue
+				}
+				if errObj.Message != expected {
+					t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+			if evaluated == nil || evaluated.String() != expected {
+				t.Errorf("%s: expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
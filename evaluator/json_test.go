@@ -0,0 +1,61 @@
+package evaluator
+
+import "testing"
+
+func TestJSONModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		{`con json = wei.import("json"); json.dumps(null)`, "null", false},
+		{`con json = wei.import("json"); json.dumps([1, "a", true, null])`, `[1, "a", true, null]`, false},
+		{`con json = wei.import("json"); json.dumps({"b": 1, "a": [2]}, null, true)`, `{"a": [2], "b": 1}`, false},
+		{`con json = wei.import("json"); json.dumps({"a": [1, 2]}, 2)`, "{\n  \"a\": [\n    1,\n    2\n  ]\n}", false},
+		{`con json = wei.import("json"); json.dumps([[], {}], 2)`, "[\n  [],\n  {}\n]", false},
+		{`con json = wei.import("json"); json.dumps("<\"中\">")`, `"<\"中\">"`, false},
+		{`con json = wei.import("json"); var a = [1]; json.dumps([a, a])`, `[[1], [1]]`, false},
+		{`con json = wei.import("json"); var a = [1]; a.append(a); json.dumps(a)`, "circular reference detected", true},
+		{`con json = wei.import("json"); var d = {}; d["d"] = d; json.dumps(d)`, "circular reference detected", true},
+		{`con json = wei.import("json"); json.dumps({1: 2})`, "dict keys must be str, not 'int'", true},
+		{`con json = wei.import("json"); json.dumps(fn() {})`, "object of type 'function' is not JSON serializable", true},
+		{`
+con json = wei.import("json")
+class Foo {}
+json.dumps(Foo())`, "object of type 'Foo' is not JSON serializable", true},
+		{`
+con json = wei.import("json")
+class Foo {
+	var a = 1
+	fn __json__() { return {"a": this.a} }
+}
+json.dumps([Foo()])`, `[{"a": 1}]`, false},
+
+		{`con json = wei.import("json"); json.loads("1")`, 1, false},
+		{`con json = wei.import("json"); json.loads('"abc"')`, "abc", false},
+		{`con json = wei.import("json"); json.loads('[1, 2, 3]')[2]`, 3, false},
+		{`con json = wei.import("json"); json.loads('{"a": {"b": [1, 20]}}')["a"]["b"][1]`, 20, false},
+		{`con json = wei.import("json"); json.loads('[true, false, null]').remove(true).remove(false).remove(null); 1`, 1, false},
+		{`con json = wei.import("json"); json.dumps(json.loads('{"b": [1, {}], "a": "x"}'), null, true)`, `{"a": "x", "b": [1, {}]}`, false},
+		{`con json = wei.import("json"); json.loads('[1, 2')`, "invalid json: unexpected end of JSON input", true},
+		{`con json = wei.import("json"); json.loads('1.5')`, "invalid json: unsupported number 1.5", true},
+		{`con json = wei.import("json"); json.loads('1 2')`, "invalid json: extra data", true},
+		{`con json = wei.import("json"); json.loads(1)`, "wrong argument type: 'int' at 1", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if tt.isError {
+				testErrorObject(t, evaluated, expected)
+			} else {
+				testStringObject(t, evaluated, expected)
+			}
+		default:
+			t.Errorf("impossible type case")
+		}
+	}
+}
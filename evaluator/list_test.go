@@ -5,6 +5,84 @@ import (
 	"weilang/object"
 )
 
+func TestListOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		{`[1, 2] + [3]`, "[1, 2, 3]", false},
+		{`[] + []`, "[]", false},
+		{`var a = [1]; var b = a + a; b.append(2); a`, "[1]", false},
+		{`[1, 2] * 2`, "[1, 2, 1, 2]", false},
+		{`2 * ["a"]`, "[a, a]", false},
+		{`[1] * 0`, "[]", false},
+		{`[1] * -1`, "[]", false},
+		{`var a = [[]] * 2; a[0].append(1); a[1]`, "[1]", false},
+		{`[1, 2] * 4611686018427387904`, "repeated list is too long", true},
+		{`[1] * 100000000000`, "repeated list is too long", true},
+		{`9223372036854775807 * [1, 2, 3]`, "repeated list is too long", true},
+		{`[] * 9223372036854775807`, "[]", false},
+		{`[1] + 1`, "unsupported operand type for +: 'list' and 'int'", true},
+		{`[1] * [1]`, "unsupported operand type for *: 'list' and 'list'", true},
+		{`[1] - [1]`, "unsupported operand type for -: 'list' and 'list'", true},
+
+		// 列表、元组、字典按值比较
+		{`[1, [2, "a"]] == [1, [2, "a"]]`, true, false},
+		{`[1, 2] == [2, 1]`, false, false},
+		{`[1] == [1, 1]`, false, false},
+		{`[1] != [1]`, false, false},
+		{`[1] != [2]`, true, false},
+		{`[] == []`, true, false},
+		{`[1] == 1`, false, false},
+		{`[1] == tuple([1])`, false, false},
+		{`tuple([1, [2]]) == tuple([1, [2]])`, true, false},
+		{`tuple([1]) != tuple([2])`, true, false},
+		{`{"a": [1]} == {"a": [1]}`, true, false},
+		{`{"a": [1]} == {"a": [2]}`, false, false},
+		{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, true, false},
+		{`[{1, 2}] == [{2, 1}]`, true, false},
+		{`var a = [1]; a.append(a); var b = [1]; b.append(b); a == b`, true, false},
+		{`var a = [1]; a.append(a); a == a`, true, false},
+		{`var a = [1]; a.append(a); var b = [1]; b.append(b); a != b`, false, false},
+		{`var a = [1]; a.append(a); var b = [2]; b.append(b); a == b`, false, false},
+		{`var a = [1]; a.append(a); var b = [1]; b.append(a); a == b`, true, false},
+
+		// 共享的元素不影响比较结果
+		{`var a = [1]; [a, a] == [a, [1]]`, true, false},
+		{`var a = [1]; [a, [1]] == [a, a]`, true, false},
+		{`var a = [1]; [a, a] == [[1], [1]]`, true, false},
+		{`var a = [1]; [a, a] == [a, [2]]`, false, false},
+		{`var a = [1]; {"x": a, "y": a} == {"x": a, "y": [1]}`, true, false},
+		{`var a = {"k": 1}; [a, a] == [{"k": 1}, a]`, true, false},
+		{`var a = [1]; [a, a] in [[a, [1]]]`, true, false},
+		{`var a = [1]; [[1], a, [a, a]].count([a, [1]])`, 1, false},
+		{`var a = [1]; [[1], [a, a]].index([[1], a])`, 1, false},
+		{`[1, [2]] in [[1, [2]]]`, true, false},
+		{`class A {}; [A()] == [A()]`, false, false},
+		{`class A {}; var x = A(); [x] == [x]`, true, false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if tt.isError {
+				testErrorObject(t, evaluated, expected)
+				continue
+			}
+			if evaluated == nil || evaluated.String() != expected {
+				t.Errorf("%s: expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
+
 func TestListBuiltinAttributeReference(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -88,6 +166,31 @@ func TestListBuiltinAttributeReference(t *testing.T) {
 		{`var a = [1, '2', true]; a.reverse(); a[0]`, true, false},
 		{`var a = [1, '2', true]; a.reverse(); a[1]`, "2", false},
 		{`var a = [1, '2', true]; a.reverse(); a[2]`, 1, false},
+
+		{`var a = [1, 2, 1]; a.index(1)`, 0, false},
+		{`var a = [1, 2, 1]; a.index(1, 1)`, 2, false},
+		{`var a = [1, 2, 1]; a.index(1, -1)`, 2, false},
+		{`var a = [1, 2, 1]; a.index(2, 0, 2)`, 1, false},
+		{`var a = [1, 2, 1]; a.index(1, 1, 2)`, "object not in list", true},
+		{`var a = [1, 2, 1]; a.index(3)`, "object not in list", true},
+		{`var a = [[1], (1)]; a.index([1])`, 0, false},
+		{`var a = [1]; a.index()`, "wrong number of arguments. got=0, want=1-3", true},
+		{`var a = [1]; a.index(1, 'a')`, "wrong argument type: 'str' at 2", true},
+		{`var a = [1]; a.index(1, 0, 'a')`, "wrong argument type: 'str' at 3", true},
+
+		{`var a = [1, 2, 1, [1]]; a.count(1)`, 2, false},
+		{`var a = [1, 2, 1, [1]]; a.count([1])`, 1, false},
+		{`var a = [1, 2, 1]; a.count('1')`, 0, false},
+		{`var a = []; a.count()`, "wrong number of arguments. got=0, want=1", true},
+
+		{`var a = [1, [2]]; var b = a.copy(); b.append(3); len(a)`, 2, false},
+		{`var a = [1, [2]]; var b = a.copy(); b[1].append(3); len(a[1])`, 2, false},
+		{`var a = [1, [2]]; a.copy() == a`, true, false},
+		{`var a = []; a.copy(1)`, "wrong number of arguments. got=1, want=0", true},
+
+		{`var a = [1, 2]; var b = a; a.clear(); len(b)`, 0, false},
+		{`var a = [1, 2]; a.clear(); a.append(3); a[0]`, 3, false},
+		{`var a = []; a.clear(1)`, "wrong number of arguments. got=1, want=0", true},
 	}
 
 	for _, tt := range tests {
@@ -10,6 +10,7 @@ import (
 	"weilang/lexer"
 	"weilang/object"
 	"weilang/parser"
+	"weilang/stdlib"
 )
 
 var modules = make(map[string]*object.Module)
@@ -43,6 +44,10 @@ func evalImport(ctx context.Context, state *WeiState, filename string) object.Ob
 }
 
 func importFromFile(ctx context.Context, state *WeiState, filename string) object.Object {
+	// 优先查找内置模块
+	if mod, ok := stdlib.Get(filename); ok {
+		return mod
+	}
 	weiFilename := filename
 	if !strings.HasSuffix(filename, ".wei") {
 		weiFilename = filename + ".wei"
@@ -56,7 +61,10 @@ func importFromFile(ctx context.Context, state *WeiState, filename string) objec
 		return mod
 	}
 
-	l := lexer.NewWithFilename(weiFilename)
+	l, err := lexer.NewWithFilename(weiFilename)
+	if err != nil {
+		return state.NewError("%v", err)
+	}
 	p := parser.New(l)
 	program, err := p.ParseProgram()
 	if err != nil {
@@ -0,0 +1,60 @@
+package evaluator
+
+import (
+	"testing"
+	"weilang/object"
+)
+
+func TestOsModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		{`con os = wei.import("os"); os.getenv("WEILANG_TEST_NOT_EXIST")`, nil, false},
+		{`con os = wei.import("os"); os.getenv("WEILANG_TEST_NOT_EXIST", "x")`, "x", false},
+		{`con os = wei.import("os"); os.setenv("WEILANG_TEST_ENV", "1"); os.getenv("WEILANG_TEST_ENV")`, "1", false},
+		{`con os = wei.import("os"); os.setenv("WEILANG_TEST_ENV", 1)`, "wrong argument type: 'int' at 2", true},
+		{`con os = wei.import("os"); os.run("sh", ["-c", "echo hi"])["stdout"]`, "hi\n", false},
+		{`con os = wei.import("os"); os.run("sh", ["-c", "echo err >&2"])["stderr"]`, "err\n", false},
+		{`con os = wei.import("os"); os.run("sh", ["-c", "exit 3"])["status"]`, 3, false},
+		{`con os = wei.import("os"); os.run("sh", [1])`, "command argument must be str, not 'int'", true},
+		{`con os = wei.import("os"); os.exit("1")`, "wrong argument type: 'str' at 1", true},
+		{`con os = wei.import("os"); len(os.cwd()) > 0`, true, false},
+	}
+
+	// os.setenv 修改的是测试进程的环境变量，测试结束后恢复
+	t.Setenv("WEILANG_TEST_ENV", "")
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if tt.isError {
+				testErrorObject(t, evaluated, expected)
+			} else {
+				testStringObject(t, evaluated, expected)
+			}
+		case nil:
+			if evaluated != object.NULL {
+				t.Errorf("object is not NULL. got=%T (%+v)", evaluated, evaluated)
+			}
+		default:
+			t.Errorf("impossible type case")
+		}
+	}
+}
+
+func TestOsExit(t *testing.T) {
+	evaluated := testEval(t, `con os = wei.import("os"); os.exit(7); 1`)
+	exit, ok := evaluated.(*object.Error)
+	if !ok || !exit.Exit {
+		t.Fatalf("object is not exit error. got=%T (%+v)", evaluated, evaluated)
+	}
+	if exit.ExitCode != 7 {
+		t.Errorf("wrong exit code. want=7, got=%d", exit.ExitCode)
+	}
+}
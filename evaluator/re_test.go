@@ -0,0 +1,72 @@
+package evaluator
+
+import (
+	"testing"
+	"weilang/object"
+)
+
+func TestReModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		{`con re = wei.import("re"); re.compile("a+").pattern`, "a+", false},
+		{`con re = wei.import("re"); re.compile("(")`, "invalid pattern: error parsing regexp: missing closing ): `(`", true},
+		{`con re = wei.import("re"); re.match("b", "abc")`, nil, false},
+		{`con re = wei.import("re"); re.match("a(b)", "abc").group(1)`, "b", false},
+		{`con re = wei.import("re"); re.search("b", "abc").group()`, "b", false},
+		{`con re = wei.import("re"); re.search("x", "abc")`, nil, false},
+		{`con re = wei.import("re"); re.search("(?P<y>\\d+)", "ab2023").group("y")`, "2023", false},
+		{`con re = wei.import("re"); re.search("(?P<y>\\d+)", "ab2023").groupdict()["y"]`, "2023", false},
+		{`con re = wei.import("re"); re.search("(a)|(b)", "b").groups()[0]`, nil, false},
+		{`con re = wei.import("re"); re.search("a", "b a").group(2)`, "no such group: 2", true},
+		// 位置以 rune 为单位
+		{`con re = wei.import("re"); re.search("世界", "你好世界").start()`, 2, false},
+		{`con re = wei.import("re"); re.search("世界", "你好世界").end()`, 4, false},
+		{`con re = wei.import("re"); re.search("好(世)", "你好世界").span(1) == tuple([2, 3])`, true, false},
+		{`con re = wei.import("re"); type(re.search("a", "b a").span())`, "tuple", false},
+		{`con re = wei.import("re"); "你好世界"[re.search("界", "你好世界").start()]`, "界", false},
+		{`con re = wei.import("re"); ",".join(re.findall("\\d", "a1b2c3"))`, "1,2,3", false},
+		{`con re = wei.import("re"); ",".join(re.findall("(\\w)=\\d", "a=1 b=2"))`, "a,b", false},
+		{`con re = wei.import("re"); re.findall("(\\w)=(\\d)", "a=1 b=2")[1][1]`, "2", false},
+		{`con re = wei.import("re"); re.sub("\\d", "#", "a1b2")`, "a#b#", false},
+		{`con re = wei.import("re"); re.sub("\\d", "#", "a1b2", 1)`, "a#b2", false},
+		{`con re = wei.import("re"); re.sub("(\\w)=(\\d)", "${2}=$1", "a=1")`, "1=a", false},
+		{`con re = wei.import("re"); re.sub("\\d", fn(m) { return "<" + m.group() + ">" }, "a1b2")`, "a<1>b<2>", false},
+		{`con re = wei.import("re"); re.sub("\\d", fn(m) { return 1 }, "a1")`, "expected str from replacement function, got 'int'", true},
+		{`con re = wei.import("re"); "|".join(re.split("\\s*,\\s*", "a , b,c"))`, "a|b|c", false},
+		{`con re = wei.import("re"); "|".join(re.split(",", "a,b,c", 1))`, "a|b,c", false},
+		{`con re = wei.import("re"); re.split("(,)", "a,b") == ["a", ",", "b"]`, true, false},
+		{`con re = wei.import("re"); re.split("(-)|(\\+)", "a-b+c", 1) == ["a", "-", null, "b+c"]`, true, false},
+		{`con re = wei.import("re"); re.split("(?:,)", "a,b") == ["a", "b"]`, true, false},
+		{`con re = wei.import("re"); re.match("a")`, "wrong number of arguments. got=1, want=2", true},
+		{`con re = wei.import("re"); re.split()`, "wrong number of arguments. got=0, want=2-3", true},
+		{`con re = wei.import("re"); re.sub("a", "b", "c", 1, 2)`, "wrong number of arguments. got=5, want=3-4", true},
+		{`con re = wei.import("re"); con p = re.compile("[a-z]+"); p.search("12ab").group()`, "ab", false},
+		{`con re = wei.import("re"); con p = re.compile("[a-z]+"); re.match(p, "ab").group()`, "ab", false},
+		{`con re = wei.import("re"); re.match(1, "ab")`, "wrong argument type: 'int' at 1", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if tt.isError {
+				testErrorObject(t, evaluated, expected)
+			} else {
+				testStringObject(t, evaluated, expected)
+			}
+		case nil:
+			if evaluated != object.NULL {
+				t.Errorf("object is not NULL. got=%T (%+v)", evaluated, evaluated)
+			}
+		default:
+			t.Errorf("impossible type case")
+		}
+	}
+}
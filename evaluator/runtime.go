@@ -0,0 +1,24 @@
+package evaluator
+
+import (
+	"context"
+	"weilang/object"
+)
+
+// evalRuntime 实现 object.Runtime ，让内置函数可以回调 Weilang 函数
+type evalRuntime struct {
+	ctx   context.Context
+	state *WeiState
+}
+
+func newEvalRuntime(ctx context.Context, state *WeiState) *evalRuntime {
+	return &evalRuntime{ctx: ctx, state: state}
+}
+
+func (r *evalRuntime) Context() context.Context {
+	return r.ctx
+}
+
+func (r *evalRuntime) CallFunction(fn object.Object, args ...object.Object) object.Object {
+	return evalFunction(r.ctx, r.state, fn, args)
+}
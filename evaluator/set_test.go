@@ -0,0 +1,112 @@
+package evaluator
+
+import (
+	"testing"
+	"weilang/object"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		{`{1, 2, 2, 3}`, "{1, 2, 3}", false},
+		{`{"b", "a"}`, "{b, a}", false},
+		{`{1, [2]}`, "unhashable type: 'list'", true},
+		{`set()`, "set()", false},
+		{`set([3, 1, 3])`, "{3, 1}", false},
+		{`set("abca")`, "{a, b, c}", false},
+		{`set({1: 2, 3: 4})`, "{1, 3}", false},
+		{`set({1, 2})`, "{1, 2}", false},
+		{`set(1)`, "'int' object is not iterable", true},
+		{`set(1, 2)`, "wrong number of arguments. got=2, want=0-1", true},
+		{`frozenset([1, 2])`, "frozenset({1, 2})", false},
+		{`frozenset()`, "frozenset()", false},
+		{`len({1, 2, 2})`, 2, false},
+		{`len(frozenset("aab"))`, 2, false},
+		{`bool(set())`, false, false},
+		{`bool({0})`, true, false},
+		{`type({1})`, "set", false},
+		{`type(frozenset())`, "frozenset", false},
+
+		// 方法
+		{`var s = {1}; s.add(2); s`, "{1, 2}", false},
+		{`var s = {1}; s.add(1); len(s)`, 1, false},
+		{`{1}.add([])`, "unhashable type: 'list'", true},
+		{`{1}.add()`, "wrong number of arguments. got=0, want=1", true},
+		{`var s = {1, 2, 3}; s.remove(2); s`, "{1, 3}", false},
+		{`{1}.remove(2)`, "object not in set", true},
+		{`var s = {1, 2}; s.discard(3).discard(1); s`, "{2}", false},
+		{`var s = {1, 2}; s.clear(); len(s)`, 0, false},
+		{`{1, 2}.has(2)`, true, false},
+		{`{1, 2}.has("2")`, false, false},
+		{`{1}.has([])`, "unhashable type: 'list'", true},
+		{`var s = {1}; var c = s.copy(); c.add(2); len(s)`, 1, false},
+		{`{1, 2}.union([2, 3])`, "{1, 2, 3}", false},
+		{`{1, 2}.intersection("12")`, "set()", false},
+		{`{1, 2, 3}.difference({2})`, "{1, 3}", false},
+		{`{1, 2}.symmetric_difference({2, 3})`, "{1, 3}", false},
+		{`{1}.issubset([1, 2])`, true, false},
+		{`{1, 2}.issuperset({3})`, false, false},
+		{`{1, 2}.isdisjoint({3})`, true, false},
+		{`frozenset([1]).add(2)`, "'frozenset' object has not attribute 'add'", true},
+		{`frozenset([1]).union({2})`, "frozenset({1, 2})", false},
+
+		// 运算符
+		{`{1, 2} | {2, 3}`, "{1, 2, 3}", false},
+		{`{1, 2} & {2, 3}`, "{2}", false},
+		{`{1, 2} - {2, 3}`, "{1}", false},
+		{`{1, 2} ^ {2, 3}`, "{1, 3}", false},
+		{`frozenset([1]) | {2}`, "frozenset({1, 2})", false},
+		{`{1} | frozenset([2])`, "{1, 2}", false},
+		{`{1} | [2]`, "unsupported operand type for |: 'set' and 'list'", true},
+		{`{1} + {2}`, "unsupported operand type for +: 'set' and 'set'", true},
+		{`{1, 2} == {2, 1}`, true, false},
+		{`{1, 2} != {2, 1}`, false, false},
+		{`{1, 2} == frozenset([1, 2])`, true, false},
+		{`{1} == [1]`, false, false},
+		{`{1} <= {1, 2}`, true, false},
+		{`{1, 2} <= {1, 2}`, true, false},
+		{`{1, 2} < {1, 2}`, false, false},
+		{`{1} < {1, 2}`, true, false},
+		{`{1, 2} >= {2}`, true, false},
+		{`{1, 2} > {1, 2}`, false, false},
+		{`{3} <= {1, 2}`, false, false},
+
+		// frozenset 可以作为字典的键和集合的元素
+		{`var d = {frozenset([1, 2]): "x"}; d[frozenset([2, 1])]`, "x", false},
+		{`len({frozenset([1]), frozenset([1]), frozenset()})`, 2, false},
+		{`var d = {}; d[{1}] = 1`, "unhashable type: 'set'", true},
+
+		// 迭代时返回元素
+		{`var n = 0; for (var e in {1, 2, 3}) { n = n + e }; n`, 6, false},
+		{`var s = {1, 2}; var n = 0; for (var e in s) { s.add(e + 10); n = n + 1 }; n`, 2, false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if tt.isError {
+				errObj, ok := evaluated.(*object.Error)
+				if !ok {
+					t.Errorf("%s: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+					continue
+				}
+				if errObj.Message != expected {
+					t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+			if evaluated == nil || evaluated.String() != expected {
+				t.Errorf("%s: expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
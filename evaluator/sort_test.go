@@ -0,0 +1,101 @@
+package evaluator
+
+import (
+	"testing"
+	"weilang/object"
+)
+
+const pointClass = `
+class Point {
+    var x
+    var y
+    fn __init__(x, y) {
+        this.x = x
+        this.y = y
+    }
+    fn __lt__(other) {
+        return this.x * this.x + this.y * this.y < other.x * other.x + other.y * other.y
+    }
+}
+class Plain {}
+`
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		// list.sort 原地排序，返回列表本身
+		{`var l = [3, 1, 2]; l.sort(); l`, "[1, 2, 3]", false},
+		{`var l = [3, 1, 2]; l.sort() == l`, true, false},
+		{`var l = [3, 1, 2]; l.sort(null, true); l`, "[3, 2, 1]", false},
+		{`var l = ["bb", "a", "ccc"]; l.sort(fn(s) { return len(s) }); l`, "[a, bb, ccc]", false},
+		{`var l = []; l.sort(); l`, "[]", false},
+		{`[1].sort(null, 1)`, "wrong argument type: 'int' at 2", true},
+		{`[1].sort(null, true, 1)`, "wrong number of arguments. got=3, want=0-2", true},
+
+		// sorted 返回新列表，不修改原来的值
+		{`var l = [3, 1, 2]; sorted(l); l`, "[3, 1, 2]", false},
+		{`sorted([3, 1, 2])`, "[1, 2, 3]", false},
+		{`sorted("bca")`, "[a, b, c]", false},
+		{`sorted("中文字")`, "[中, 字, 文]", false},
+		{`sorted({"b": 1, "a": 2})`, "[a, b]", false},
+		{`sorted({3, 1, 2}, null, true)`, "[3, 2, 1]", false},
+		{`sorted(range(5), fn(x) { return x % 2 })`, "[0, 2, 4, 1, 3]", false},
+		{`sorted(map(fn(x) { return -x }, range(3)))`, "[-2, -1, 0]", false},
+		{`sorted()`, "wrong number of arguments. got=0, want=1-3", true},
+		{`sorted(1)`, "'int' object is not iterable", true},
+
+		// 稳定排序，reverse 时相等的值也保持原来的顺序
+		{`sorted(["bb", "a", "dd", "c"], fn(s) { return len(s) })`, "[a, c, bb, dd]", false},
+		{`sorted(["bb", "a", "dd", "c"], fn(s) { return len(s) }, true)`, "[bb, dd, a, c]", false},
+		{`sorted([[1, "b"], [0, "a"], [1, "a"]], fn(p) { return p[0] })`, "[[0, a], [1, b], [1, a]]", false},
+
+		// 列表、元组按元素逐个比较
+		{`sorted([[1, 2], [1], [0, 5], []])`, "[[], [0, 5], [1], [1, 2]]", false},
+		{`sorted([tuple([2]), tuple([1, 1])])`, "[(1, 1), (2)]", false},
+
+		// 不同类型按 null < bool < int < str < tuple < list 排序
+		{`sorted([[0], "a", 2, tuple([1]), true, null, false])`, "[null, false, true, 2, a, (1), [0]]", false},
+		{`sorted([[1, "a"], [1, 2]])`, "[[1, 2], [1, a]]", false},
+		{`sorted([{}, {}])`, "'<' not supported between instances of 'dict' and 'dict'", true},
+		{`sorted([1, {}])`, "'<' not supported between instances of 'dict' and 'int'", true},
+		{`sorted([Plain(), Plain()])`, "'<' not supported between instances of 'Plain' and 'Plain'", true},
+
+		// 实例使用 __lt__
+		{`[p.x for i, p in sorted([Point(3, 4), Point(0, 1), Point(2, 0)])]`, "[0, 2, 3]", false},
+		{`[p.x for i, p in sorted([Point(3, 4), Point(0, 1), Point(2, 0)], null, true)]`, "[3, 2, 0]", false},
+		{`[p.x for i, p in sorted([Point(3, 4), Point(0, 1)], fn(p) { return -p.x })]`, "[3, 0]", false},
+
+		// key 函数出错时停止排序
+		{`sorted([1, 2], fn(x) { return missing })`, "undefined: 'missing'", true},
+		{`var l = [2, 1]; l.sort(fn(x) { return x + "a" }); l`, "unsupported operand type for +: 'int' and 'str'", true},
+		{`sorted([1, 2], 1)`, "not a function: 'int'", true},
+		{`sorted([1], null, 1)`, "wrong argument type: 'int' at 3", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, pointClass+tt.input)
+
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if tt.isError {
+				errObj, ok := evaluated.(*object.Error)
+				if !ok {
+					t.Errorf("%s: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+					continue
+				}
+				if errObj.Message != expected {
+					t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+			if evaluated == nil || evaluated.String() != expected {
+				t.Errorf("%s: expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"weilang/ast"
+	"weilang/diagnostic"
 	"weilang/object"
+	"weilang/token"
 )
 
 type WeiState struct {
@@ -14,6 +18,27 @@ type WeiState struct {
 	// excStack 错误栈
 	excStack *object.CallStack
 	exc      *object.Error
+	// stdout print 的输出位置
+	stdout io.Writer
+	// tracer 不为空时在执行每条语句之前回调，用于调试器
+	tracer Tracer
+}
+
+// Tracer 跟踪语句的执行，用于实现调试器
+type Tracer interface {
+	// Trace 在执行语句之前调用，env 为执行语句的环境，返回错误时停止执行
+	Trace(ctx context.Context, state *WeiState, stmt ast.Statement, env *object.Environment) *object.Error
+	// Breakpoint 脚本调用了 breakpoint() ，在下一条语句暂停
+	Breakpoint()
+}
+
+// FrameTracer 同时跟踪栈帧的创建和销毁的 Tracer ，用于性能分析
+type FrameTracer interface {
+	Tracer
+	// EnterFrame 创建栈帧之后调用
+	EnterFrame(frame *object.Frame)
+	// ExitFrame 销毁栈帧之后调用
+	ExitFrame(frame *object.Frame)
 }
 
 func NewWeiState(module *object.Module) *WeiState {
@@ -22,15 +47,37 @@ func NewWeiState(module *object.Module) *WeiState {
 		stack:    object.NewCallStack(),
 		excStack: nil,
 		exc:      nil,
+		stdout:   os.Stdout,
 	}
 }
 
+// SetStdout 设置 print 的输出位置，默认为 os.Stdout
+func (g *WeiState) SetStdout(w io.Writer) {
+	g.stdout = w
+}
+
 func (g *WeiState) CreateFrame(filename string, funcName string) *object.Frame {
-	return g.stack.CreateFrame(filename, funcName)
+	return g.enterFrame(g.stack.CreateFrame(filename, funcName))
+}
+
+// ResumeFrame 生成器恢复执行时创建栈帧，和 CreateFrame 的区别是栈帧标记为恢复执行，不算新的调用
+func (g *WeiState) ResumeFrame(filename string, funcName string) *object.Frame {
+	return g.enterFrame(g.stack.ResumeFrame(filename, funcName))
+}
+
+func (g *WeiState) enterFrame(frame *object.Frame) *object.Frame {
+	if t, ok := g.tracer.(FrameTracer); ok {
+		t.EnterFrame(frame)
+	}
+	return frame
 }
 
 func (g *WeiState) DestroyFrame() *object.Frame {
-	return g.stack.DestroyFrame()
+	frame := g.stack.DestroyFrame()
+	if t, ok := g.tracer.(FrameTracer); ok {
+		t.ExitFrame(frame)
+	}
+	return frame
 }
 
 func (g *WeiState) Frame() *object.Frame {
@@ -45,11 +92,31 @@ func (g *WeiState) SetModule(module *object.Module) {
 	g.module = module
 }
 
-func (g *WeiState) UpdateLocation(node ast.Node) {
-	location := node.GetFileLocation()
-	frame := g.Frame()
-	frame.SetLineno(location.Lineno)
+// SetTracer 设置跟踪语句执行的 Tracer ，为 nil 时不跟踪
+func (g *WeiState) SetTracer(tracer Tracer) {
+	g.tracer = tracer
+}
+
+// Frames 返回当前的调用栈，最后一个为正在执行的栈帧
+func (g *WeiState) Frames() []*object.Frame {
+	return g.stack.GetFrames()
+}
+
+// trace 执行语句之前通知 Tracer ，并记录语句所在的环境，用于查看变量
+func (g *WeiState) trace(ctx context.Context, stmt ast.Statement, env *object.Environment) object.Object {
+	if g.tracer == nil {
+		return nil
+	}
+	g.UpdateLocation(stmt)
+	g.Frame().SetEnv(env)
+	if err := g.tracer.Trace(ctx, g, stmt, env); err != nil {
+		return err
+	}
+	return nil
+}
 
+func (g *WeiState) UpdateLocation(node ast.Node) {
+	g.Frame().SetLocation(node.GetFileLocation())
 }
 
 func (g *WeiState) HandleError(obj object.Object) {
@@ -84,29 +151,80 @@ func (g *WeiState) WrongNumberArgument(name string, got, want int) *object.Error
 	return e
 }
 
-func getLine(filename string, lineno int) string {
-	data, err := os.ReadFile(filename)
-	if err != nil {
+// underline 返回标注出错表达式的 ^ 行，line 为出错的那一行源码，column end 为表达式的起止位置
+// 输出源码时去掉了缩进，标注的位置也要去掉缩进；表达式覆盖整行时不需要标注，返回空字符串
+func underline(line string, lineno, column int, end token.Position) string {
+	runes := []rune(line)
+	indent := len(runes) - len([]rune(strings.TrimLeft(line, " \t")))
+	lineEnd := len([]rune(strings.TrimRight(line, " \t\r")))
+	endColumn := lineEnd
+	if end.Line == lineno {
+		if end.Column < lineEnd {
+			endColumn = end.Column
+		}
+	} else if end.Line < lineno {
+		// 没有结束位置
 		return ""
 	}
-	lines := strings.Split(string(data), "\n")
-	if lineno >= len(lines) {
+	if column < indent {
+		column = indent
+	}
+	if column >= endColumn || (column == indent && endColumn == lineEnd) {
 		return ""
 	}
-	line := lines[lineno]
-	return strings.TrimSpace(line)
+	return strings.Repeat(" ", column-indent) + strings.Repeat("^", endColumn-column)
 }
 
 func (g *WeiState) HasExc() bool {
 	return g.exc != nil
 }
 
-func (g *WeiState) PrintExc() {
-	// 打印错误栈
-	fmt.Println("Traceback")
+// ClearExc 清除已经处理过的错误，之后的错误才能被记录，用于 repl 中继续执行
+func (g *WeiState) ClearExc() {
+	g.exc = nil
+	g.excStack = nil
+}
+
+// Diagnostic 把当前的错误转换为结构化的诊断信息，位置为出错的表达式
+func (g *WeiState) Diagnostic() diagnostic.Diagnostic {
+	d := diagnostic.Diagnostic{
+		Severity: diagnostic.SeverityError,
+		Code:     diagnostic.CodeRuntimeError,
+	}
+	if g.exc != nil {
+		d.Message = g.exc.Message
+	}
+	frames := g.GetExcFrames()
+	for _, frame := range frames {
+		d.Frames = append(d.Frames, diagnostic.Frame{
+			Filename: frame.GetFilename(),
+			Line:     frame.GetLineno(),
+			Column:   frame.GetColumn(),
+			FuncName: frame.GetFuncName(),
+		})
+	}
+	if n := len(frames); n > 0 {
+		last := frames[n-1]
+		d.Filename = last.GetFilename()
+		d.Start = token.Position{Line: last.GetLineno(), Column: last.GetColumn()}
+		d.End = last.GetEnd()
+	}
+	return d
+}
+
+// PrintExc 把错误栈输出到 w
+func (g *WeiState) PrintExc(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "Traceback")
 	for _, frame := range g.GetExcFrames() {
-		fmt.Printf("  File \"%s\", line %d, in %s\n", frame.GetFilename(), frame.GetLineno()+1, frame.GetFuncName())
-		fmt.Printf("    %s\n", getLine(frame.GetFilename(), frame.GetLineno()))
+		_, _ = fmt.Fprintf(w, "  File \"%s\", line %d, in %s\n", frame.GetFilename(), frame.GetLineno()+1, frame.GetFuncName())
+		line := frame.GetSourceLine()
+		if line == "" {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "    %s\n", strings.TrimSpace(line))
+		if mark := underline(line, frame.GetLineno(), frame.GetColumn(), frame.GetEnd()); mark != "" {
+			_, _ = fmt.Fprintf(w, "    %s\n", mark)
+		}
 	}
-	fmt.Println(g.exc.String())
+	_, _ = fmt.Fprintln(w, g.exc.String())
 }
@@ -157,9 +157,10 @@ func TestStringBuiltinAttributeReference(t *testing.T) {
 		{`"中文abc".startswith("c", 5, 6)`, false, false},
 		{`"中文abc".startswith("c", 5, 60)`, false, false},
 
-		{`"中文".strip()`, "wrong number of arguments. got=0, want=1", true},
+		{`"中文".strip()`, "中文", false},
+		{`" \t中文\n ".strip()`, "中文", false},
 		{`"中文".strip(1)`, "wrong argument type: 'int'", true},
-		{`"abc".strip('', 1)`, "wrong number of arguments. got=2, want=1", true},
+		{`"abc".strip('', 1)`, "wrong number of arguments. got=2, want=0-1", true},
 		{`"  abc  ".strip('')`, "  abc  ", false},
 		{`"  abc  ".strip(' ')`, "abc", false},
 		{`"  中文  ".strip(' ')`, "中文", false},
@@ -167,6 +168,101 @@ func TestStringBuiltinAttributeReference(t *testing.T) {
 		{`"中文abc中文".strip('中文')`, "abc", false},
 		{`"a b".strip(' ')`, "a b", false},
 		{`'www.example.com'.strip('cmowz.')`, "example", false},
+
+		{`"xx中文xx".lstrip('x')`, "中文xx", false},
+		{`"xx中文xx".rstrip('x')`, "xx中文", false},
+		{`"中文abc中".lstrip('文中')`, "abc中", false},
+		{`"中文abc中".rstrip('文中')`, "中文abc", false},
+		{`"  x ".lstrip()`, "x ", false},
+		{`"  x \r\n".rstrip()`, "  x", false},
+		{`"\u3000x".lstrip()`, "x", false},
+		{`"abc".lstrip('a', 'b')`, "wrong number of arguments. got=2, want=0-1", true},
+		{`"abc".rstrip(1)`, "wrong argument type: 'int'", true},
+
+		{`"a-b-c".replace("-", "+")`, "a+b+c", false},
+		{`"a-b-c".replace("-", "", 1)`, "ab-c", false},
+		{`"a-b-c".replace("-", "", 0)`, "a-b-c", false},
+		{`"a-b-c".replace("-", "", -1)`, "abc", false},
+		{`"中文中文".replace("文", "国")`, "中国中国", false},
+		{`"ab".replace("", "|")`, "|a|b|", false},
+		{`"ab".replace("a")`, "wrong number of arguments. got=1, want=2-3", true},
+		{`"ab".replace("a", 1)`, "wrong argument type: 'int' at 2", true},
+		{`"ab".replace("a", "b", "c")`, "wrong argument type: 'str' at 3", true},
+
+		{`"abcabc".rfind("bc")`, 4, false},
+		{`"abcabc".rfind("d")`, -1, false},
+		{`"abcabc".rfind("")`, 6, false},
+		{`"中文中文".rfind("文")`, 3, false},
+		{`"中文中文".rfind("文", 0, 3)`, 1, false},
+		{`"中文中文".rfind("文", -2, -1)`, -1, false},
+		{`"abc".rfind("a", 2, 1)`, -1, false},
+		{`"abc".rfind(1)`, "wrong argument type: 'int' at 1", true},
+
+		{`"中文abc".index("a")`, 2, false},
+		{`"中文abc中文".index("中文", 1)`, 5, false},
+		{`"abc".index("d")`, "substring not found", true},
+		{`"abc".index("a", 1)`, "substring not found", true},
+		{`"abc".index()`, "wrong number of arguments. got=0, want=1-3", true},
+
+		{`"a\nb\r\nc\rd".splitlines()`, []string{"a", "b", "c", "d"}, false},
+		{`"a\n\nb\n".splitlines()`, []string{"a", "", "b"}, false},
+		{`"a\nb\r\n".splitlines(true)`, []string{"a\n", "b\r\n"}, false},
+		{`"中文".splitlines()`, []string{"中文"}, false},
+		{`"".splitlines()`, []string{}, false},
+		{`"a".splitlines(1)`, "wrong argument type: 'int' at 1", true},
+
+		{`"k=v=w".partition("=") == tuple(["k", "=", "v=w"])`, true, false},
+		{`"中文=值".partition("=") == tuple(["中文", "=", "值"])`, true, false},
+		{`"kv".partition("=") == tuple(["kv", "", ""])`, true, false},
+		{`"kv".partition("")`, "empty separator", true},
+		{`"kv".partition(1)`, "wrong argument type: 'int' at 1", true},
+
+		{`"中".ljust(3, "*")`, "中**", false},
+		{`"ab".ljust(1)`, "ab", false},
+		{`"ab".rjust(4)`, "  ab", false},
+		{`"中文".rjust(4, "文")`, "文文中文", false},
+		{`"ab".center(5, "-")`, "--ab-", false},
+		{`"abc".center(6)`, " abc  ", false},
+		{`"ab".center(6, "*")`, "**ab**", false},
+		{`"ab".center(-1)`, "ab", false},
+		{`"ab".ljust(4, "**")`, "the fill character must be exactly one character long", true},
+		{`"ab".rjust("4")`, "wrong argument type: 'str' at 1", true},
+		{`"ab".center()`, "wrong number of arguments. got=0, want=1-2", true},
+
+		{`"42".zfill(5)`, "00042", false},
+		{`"-42".zfill(5)`, "-0042", false},
+		{`"+中".zfill(4)`, "+00中", false},
+		{`"abc".zfill(2)`, "abc", false},
+		{`"".zfill(2)`, "00", false},
+		{`"1".zfill("2")`, "wrong argument type: 'str' at 1", true},
+
+		{`"中文abc".isalpha()`, true, false},
+		{`"a1".isalpha()`, false, false},
+		{`"".isalpha()`, false, false},
+		{`" \t\n".isspace()`, true, false},
+		{`" a".isspace()`, false, false},
+		{`"".isspace()`, false, false},
+		{`"ABC1中".isupper()`, true, false},
+		{`"Ab".isupper()`, false, false},
+		{`"12".isupper()`, false, false},
+		{`"a".isupper(1)`, "wrong number of arguments. got=1, want=0", true},
+
+		{`"hello wORLD".title()`, "Hello World", false},
+		{`"中文abc x1y".title()`, "中文abc X1Y", false},
+		{`"they're".title()`, "They'Re", false},
+
+		{`"-" * 3`, "---", false},
+		{`2 * "中文"`, "中文中文", false},
+		{`"a" * 0`, "", false},
+		{`"a" * -2`, "", false},
+		{`"ab" * 4611686018427387904`, "repeated string is too long", true},
+		{`9223372036854775807 * "a"`, "repeated string is too long", true},
+		{`"" * 9223372036854775807`, "", false},
+		{`"a".ljust(9223372036854775807)`, "padded string is too long", true},
+		{`"a".rjust(1073741825)`, "padded string is too long", true},
+		{`"a".center(1073741824, "中")`, "padded string is too long", true},
+		{`"1".zfill(9223372036854775807)`, "padded string is too long", true},
+		{`"a" * "b"`, "unsupported operand type for *: 'str' and 'str'", true},
 	}
 
 	for _, tt := range tests {
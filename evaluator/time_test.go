@@ -0,0 +1,78 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+	"time"
+	"weilang/lexer"
+	"weilang/object"
+	"weilang/parser"
+)
+
+func TestTimeModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		isError  bool
+	}{
+		{`con time = wei.import("time"); time.date(2023, 10, 21, 14, 47, 42).format(time.DATETIME)`, "2023-10-21 14:47:42", false},
+		{`con time = wei.import("time"); time.format(time.date(2023, 1, 2), "2006/01/02")`, "2023/01/02", false},
+		{`con time = wei.import("time"); time.parse("2023-10-21", time.DATE).day`, 21, false},
+		{`con time = wei.import("time"); time.parse("2023-13-21", time.DATE)`, `parsing time "2023-13-21": month out of range`, true},
+		{`con time = wei.import("time"); time.date(2023, 10, 21).weekday`, 6, false},
+		{`con time = wei.import("time"); time.unix(86400).utc().format(time.RFC3339)`, "1970-01-02T00:00:00Z", false},
+		{`con time = wei.import("time"); (time.date(2023, 1, 31) + time.HOUR * 24).month`, 2, false},
+		{`con time = wei.import("time"); (time.date(2023, 1, 2) - time.date(2023, 1, 1)).hours`, 24, false},
+		{`con time = wei.import("time"); (time.date(2023, 1, 2) - time.duration("90m")).minute`, 30, false},
+		{`con time = wei.import("time"); time.date(2023, 1, 2) > time.date(2023, 1, 1)`, true, false},
+		{`con time = wei.import("time"); time.date(2023, 1, 1) == time.date(2023, 1, 1)`, true, false},
+		{`con time = wei.import("time"); time.date(2023, 1, 1) <= time.date(2022, 1, 1)`, false, false},
+		{`con time = wei.import("time"); time.duration(1500).seconds`, 1, false},
+		{`con time = wei.import("time"); time.duration("1h30m") / time.MINUTE`, 90, false},
+		{`con time = wei.import("time"); (time.SECOND / 4).milliseconds`, 250, false},
+		{`con time = wei.import("time"); time.SECOND < time.MINUTE`, true, false},
+		{`con time = wei.import("time"); time.SECOND / 0`, "division by zero", true},
+		{`con time = wei.import("time"); time.SECOND + 1`, "unsupported operand type for +: 'time.Duration' and 'int'", true},
+		{`con time = wei.import("time"); var start = time.monotonic(); time.sleep(1); time.monotonic() - start >= 1000000`, true, false},
+		{`con time = wei.import("time"); time.sleep("1")`, "wrong argument type: 'str' at 1", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if tt.isError {
+				testErrorObject(t, evaluated, expected)
+			} else {
+				testStringObject(t, evaluated, expected)
+			}
+		default:
+			t.Errorf("impossible type case")
+		}
+	}
+}
+
+func TestTimeSleepCancelled(t *testing.T) {
+	l := lexer.New(`con time = wei.import("time"); time.sleep(60000)`)
+	p := parser.New(l)
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	mod := object.NewModule("")
+	state := NewWeiState(mod)
+	state.CreateFrame("", "<module>")
+	start := time.Now()
+	evaluated := Eval(ctx, state, program, mod.GetEnv())
+	if time.Since(start) > time.Second {
+		t.Fatalf("sleep did not stop after the context was cancelled")
+	}
+	testErrorObject(t, evaluated, "sleep interrupted: context deadline exceeded")
+}
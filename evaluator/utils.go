@@ -3,26 +3,12 @@ package evaluator
 import "weilang/object"
 
 func isTruthy(obj object.Object) bool {
-	switch obj {
-	case object.TRUE:
-		return true
-	case object.FALSE:
-		return false
-	case object.NULL:
-		return false
-	default:
-		switch obj := obj.(type) {
-		case *object.Integer:
-			return obj.Value != 0
-		case *object.String:
-			return len(obj.Value) != 0
-		case *object.List:
-			return len(obj.Elements) != 0
-		case *object.Dict:
-			return len(obj.Pairs) != 0
-		}
-		return true
-	}
+	return object.Truthy(obj)
+}
+
+// IsTruthy 判断对象作为条件时是否为真
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"weilang/format"
+	"weilang/interpreter"
+	"weilang/parser"
+)
+
+const fmtUsage = `Usage:
+    weilang fmt [options] <filename>...    格式化文件，默认把结果输出到标准输出
+
+Options:
+`
+
+// fmtCommand 执行 weilang fmt，返回退出码
+//
+//	-w     把格式化的结果写回文件
+//	-check 只检查，输出没有格式化的文件名，存在这样的文件时退出码为 1
+func fmtCommand(args []string) int {
+	flags := flag.NewFlagSet("weilang fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "把格式化的结果写回文件")
+	check := flags.Bool("check", false, "输出没有格式化的文件名，存在这样的文件时退出码为 1")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(flags.Output(), fmtUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return interpreter.ExitOK
+		}
+		return interpreter.ExitUsageError
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return interpreter.ExitUsageError
+	}
+
+	code := interpreter.ExitOK
+	for _, filename := range flags.Args() {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "weilang: can't open file '%s': %v\n", filename, err)
+			return interpreter.ExitUsageError
+		}
+		src := string(content)
+		formatted, err := format.Source(filename, src)
+		if err != nil {
+			var errorList parser.ErrorList
+			if errors.As(err, &errorList) {
+				_, _ = fmt.Fprintln(os.Stderr, err)
+				code = interpreter.ExitSyntaxError
+				continue
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "weilang: %v\n", err)
+			return interpreter.ExitRuntimeError
+		}
+		switch {
+		case *check:
+			if formatted != src {
+				fmt.Println(filename)
+				if code == interpreter.ExitOK {
+					code = interpreter.ExitRuntimeError
+				}
+			}
+		case *write:
+			if formatted == src {
+				continue
+			}
+			info, err := os.Stat(filename)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "weilang: %v\n", err)
+				return interpreter.ExitRuntimeError
+			}
+			if err := os.WriteFile(filename, []byte(formatted), info.Mode().Perm()); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "weilang: can't write file '%s': %v\n", filename, err)
+				return interpreter.ExitRuntimeError
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+	return code
+}
@@ -0,0 +1,575 @@
+// Package format 格式化 weilang 源码
+//
+// 格式化规则
+//
+//	使用 4 个空格缩进，每行一条语句，不输出分号
+//	运算符两边各一个空格，逗号后面一个空格，只在需要的地方加括号
+//	保留所有注释，保留语句之间的空行（多个空行合并为一个）
+//	表达式中间的注释保留在原来的位置，表达式中的行注释后面换行并多缩进一级
+//	源码中跨越多行的列表、字典字面量每个元素占一行
+//	字符串、数字字面量保持源码中的写法
+package format
+
+import (
+	"strings"
+
+	"weilang/ast"
+	"weilang/lexer"
+	"weilang/parser"
+	"weilang/token"
+)
+
+const indentString = "    "
+
+// Source 格式化源码，源码有语法错误时返回 parser.ErrorList
+func Source(filename string, src string) (string, error) {
+	l := lexer.New(src)
+	l.SetFilename(filename)
+	p := parser.New(l)
+	program, err := p.ParseProgram()
+	if err != nil {
+		return "", err
+	}
+	pr := &printer{comments: l.Comments(), atBlockStart: true}
+	pr.statements(program.Statements, -1)
+	// 文件末尾的注释
+	pr.flushComments(len(l.GetLines()) + 1)
+	return pr.out.String(), nil
+}
+
+// 运算符优先级，数字越大优先级越高，与解析器中的表达式语法规则一一对应
+const (
+	precLowest = iota
+	precOr
+	precAnd
+	precNot
+	precComparison
+	precBitwiseOr
+	precBitwiseXor
+	precBitwiseAnd
+	precShift
+	precPlus
+	precMultiply
+	precUnary
+	precPrimary
+	precAtom
+)
+
+var binaryPrecedences = map[string]int{
+	"or":     precOr,
+	"and":    precAnd,
+	"<":      precComparison,
+	"<=":     precComparison,
+	">":      precComparison,
+	">=":     precComparison,
+	"!=":     precComparison,
+	"==":     precComparison,
+	"in":     precComparison,
+	"not in": precComparison,
+	"|":      precBitwiseOr,
+	"^":      precBitwiseXor,
+	"&":      precBitwiseAnd,
+	"<<":     precShift,
+	">>":     precShift,
+	"+":      precPlus,
+	"-":      precPlus,
+	"*":      precMultiply,
+	"/":      precMultiply,
+	"%":      precMultiply,
+}
+
+func precedence(expr ast.Expression) int {
+	switch expr := expr.(type) {
+	case *ast.BinaryOpExpression:
+		return binaryPrecedences[expr.Operator]
+	case *ast.UnaryExpression:
+		if expr.Operator == "not" {
+			return precNot
+		}
+		return precUnary
+	case *ast.CallExpression, *ast.AttributeExpression, *ast.SubscriptionExpression:
+		return precPrimary
+	case *ast.YieldExpression:
+		return precLowest
+	default:
+		return precAtom
+	}
+}
+
+type printer struct {
+	out    strings.Builder
+	indent int
+	// comments 还没有输出的注释，按照出现的顺序排列
+	comments []token.Token
+	// lastLine 上一个输出的语句或注释在源码中的结束行，用来保留空行
+	lastLine int
+	// atBlockStart 当前位置是否为文件或者语句块的开头，开头不输出空行
+	atBlockStart bool
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+}
+
+func (p *printer) writeIndent() {
+	p.write(strings.Repeat(indentString, p.indent))
+}
+
+// beginLine 开始输出源码中第 line 行的内容，源码中和上一个输出之间有空行时保留一个空行
+func (p *printer) beginLine(line int) {
+	if !p.atBlockStart && line > p.lastLine+1 {
+		p.write("\n")
+	}
+	p.atBlockStart = false
+	p.writeIndent()
+}
+
+// flushComments 输出所有在第 line 行之前的注释，每个注释占一行
+func (p *printer) flushComments(line int) {
+	for len(p.comments) > 0 && p.comments[0].Start.Line < line {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+		p.beginLine(comment.Start.Line)
+		p.write(comment.Literal)
+		p.write("\n")
+		p.lastLine = comment.End.Line
+	}
+}
+
+// trailingComment 输出在第 line 行的注释，放在当前行的末尾
+func (p *printer) trailingComment(line int) {
+	for len(p.comments) > 0 && p.comments[0].Start.Line == line {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+		p.write(" ")
+		p.write(comment.Literal)
+		p.lastLine = comment.End.Line
+	}
+}
+
+// inlineComments 在当前位置输出源码中位于 pos 之前的注释，用于语句开头和表达式中间的注释
+// 块注释后面加一个空格，行注释后面换行，下一行比当前缩进多一级
+func (p *printer) inlineComments(pos token.Position) {
+	p.commentsBefore(pos, false)
+}
+
+// closingComments 输出源码中位于右括号 pos 之前的注释，注释和前面的表达式之间加一个空格
+func (p *printer) closingComments(pos token.Position) {
+	p.commentsBefore(pos, true)
+}
+
+func (p *printer) commentsBefore(pos token.Position, closing bool) {
+	for len(p.comments) > 0 && before(p.comments[0].Start, pos) {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+		if closing {
+			p.write(" ")
+		}
+		p.write(comment.Literal)
+		if strings.HasPrefix(comment.Literal, "//") {
+			p.write("\n")
+			p.write(strings.Repeat(indentString, p.indent+1))
+		} else if !closing {
+			p.write(" ")
+		}
+	}
+}
+
+// before 判断源码位置 a 是否在 b 之前
+func before(a, b token.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// startOf 返回节点在源码中的起始位置
+func startOf(node ast.Node) token.Position {
+	location := node.GetFileLocation()
+	return token.Position{Line: location.Lineno, Column: location.Column}
+}
+
+// statements 输出语句列表，endLine 为语句块 "}" 所在的行，在它之前的注释都属于这个语句块
+func (p *printer) statements(statements []ast.Statement, endLine int) {
+	for _, stmt := range statements {
+		location := stmt.GetFileLocation()
+		p.flushComments(location.Lineno)
+		p.beginLine(location.Lineno)
+		p.inlineComments(startOf(stmt))
+		p.statement(stmt)
+		p.trailingComment(location.End.Line)
+		p.write("\n")
+		if location.End.Line > p.lastLine {
+			p.lastLine = location.End.Line
+		}
+	}
+	if endLine >= 0 {
+		p.flushComments(endLine)
+	}
+}
+
+// block 输出 {} 包裹的语句块
+func (p *printer) block(location *ast.FileLocation, statements []ast.Statement) {
+	p.write("{")
+	p.trailingComment(location.Lineno)
+	if len(statements) == 0 && !p.hasCommentBefore(location.End.Line) {
+		p.write("}")
+		return
+	}
+	p.write("\n")
+	p.indent++
+	p.atBlockStart = true
+	p.lastLine = location.Lineno
+	p.statements(statements, location.End.Line)
+	p.indent--
+	p.writeIndent()
+	p.write("}")
+}
+
+func (p *printer) hasCommentBefore(line int) bool {
+	return len(p.comments) > 0 && p.comments[0].Start.Line < line
+}
+
+func (p *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.VarStatement:
+		p.write("var " + stmt.Name.Value + " = ")
+		p.expression(stmt.Value, precLowest)
+	case *ast.ConStatement:
+		p.write("con " + stmt.Name.Value + " = ")
+		p.expression(stmt.Value, precLowest)
+	case *ast.AssignStatement:
+		p.expression(stmt.Left, precLowest)
+		p.write(" = ")
+		p.expression(stmt.Value, precLowest)
+	case *ast.ReturnStatement:
+		p.write("return")
+		if stmt.ReturnValue != nil {
+			p.write(" ")
+			p.expression(stmt.ReturnValue, precLowest)
+		}
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression, precLowest)
+	case *ast.ContinueStatement:
+		p.write("continue")
+	case *ast.BreakStatement:
+		p.write("break")
+	case *ast.IfStatement:
+		for i, branch := range stmt.IfBranches {
+			if i > 0 {
+				p.write(" else ")
+			}
+			p.write("if (")
+			p.expression(branch.Condition, precLowest)
+			p.write(") ")
+			p.block(branch.Body.Location, branch.Body.Statements)
+		}
+		if stmt.ElseBody != nil {
+			p.write(" else ")
+			p.block(stmt.ElseBody.Location, stmt.ElseBody.Statements)
+		}
+	case *ast.WhileStatement:
+		p.write("while (")
+		p.expression(stmt.Condition, precLowest)
+		p.write(") ")
+		p.block(stmt.Body.Location, stmt.Body.Statements)
+	case *ast.ForInStatement:
+		if stmt.Con {
+			p.write("for (con ")
+		} else {
+			p.write("for (var ")
+		}
+		p.identifiers(stmt.Targets)
+		p.write(" in ")
+		p.expression(stmt.Expr, precLowest)
+		p.write(") ")
+		p.block(stmt.Body.Location, stmt.Body.Statements)
+	case *ast.FunctionDefineStatement:
+		p.write("fn " + stmt.Function.Name)
+		p.function(stmt.Function)
+	case *ast.WeiExportStatement:
+		p.write("wei.export(")
+		p.identifiers(stmt.Names)
+		p.write(")")
+	case *ast.ClassDefineStatement:
+		p.write("class " + stmt.Name)
+		if stmt.Parent != nil {
+			p.write("(" + stmt.Parent.Value + ")")
+		}
+		p.write(" ")
+		p.block(stmt.Body.Location, stmt.Body.Statements)
+	case *ast.ClassVariableDeclarationStatement:
+		if stmt.Con {
+			p.write("con ")
+		} else {
+			p.write("var ")
+		}
+		if stmt.Class {
+			p.write("class.")
+		}
+		p.write(stmt.Name.Value)
+		if stmt.Expr != nil {
+			p.write(" = ")
+			p.expression(stmt.Expr, precLowest)
+		}
+	case *ast.ClassMethodDefineStatement:
+		p.write("fn ")
+		if stmt.Class {
+			p.write("class.")
+		}
+		p.write(stmt.Function.Name)
+		p.function(stmt.Function)
+	default:
+		// 新增的语句类型至少保证不丢失代码
+		p.write(stmt.String())
+	}
+}
+
+// function 输出函数的参数列表和函数体
+func (p *printer) function(fn *ast.FunctionLiteral) {
+	p.write("(")
+	p.identifiers(fn.Parameters)
+	p.write(") ")
+	p.block(fn.Body.Location, fn.Body.Statements)
+}
+
+func (p *printer) identifiers(identifiers []*ast.Identifier) {
+	for i, identifier := range identifiers {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(identifier.Value)
+	}
+}
+
+// expression 输出表达式，表达式的优先级低于 prec 时加上括号
+func (p *printer) expression(expr ast.Expression, prec int) {
+	p.inlineComments(startOf(expr))
+	if precedence(expr) < prec {
+		p.write("(")
+		p.expression(expr, precLowest)
+		p.write(")")
+		return
+	}
+	switch expr := expr.(type) {
+	case *ast.BinaryOpExpression:
+		exprPrec := precedence(expr)
+		// 运算符都是左结合的，右边的操作数优先级相同时也要加括号
+		p.expression(expr.Left, exprPrec)
+		p.write(" " + expr.Operator + " ")
+		p.expression(expr.Right, exprPrec+1)
+	case *ast.UnaryExpression:
+		if expr.Operator == "not" {
+			p.write("not ")
+			p.expression(expr.Operand, precNot)
+		} else if operand, ok := expr.Operand.(*ast.UnaryExpression); ok && operand.Operator != "not" {
+			// 避免连续的一元运算符变成 -- ++
+			p.write(expr.Operator + "(")
+			p.expression(operand, precLowest)
+			p.write(")")
+		} else {
+			p.write(expr.Operator)
+			p.expression(expr.Operand, precUnary)
+		}
+	case *ast.CallExpression:
+		if function, ok := expr.Function.(*ast.FunctionLiteral); ok {
+			// 直接调用函数字面量时保留括号
+			p.write("(")
+			p.expression(function, precLowest)
+			p.write(")")
+		} else {
+			p.expression(expr.Function, precPrimary)
+		}
+		p.write("(")
+		p.expressions(expr.Arguments)
+		p.closingComments(expr.Location.End)
+		p.write(")")
+	case *ast.AttributeExpression:
+		p.expression(expr.Left, precPrimary)
+		p.write("." + expr.Attribute.Value)
+	case *ast.SubscriptionExpression:
+		p.expression(expr.Left, precPrimary)
+		p.write("[")
+		p.expression(expr.Index, precLowest)
+		p.closingComments(expr.Location.End)
+		p.write("]")
+	case *ast.FunctionLiteral:
+		p.write("fn")
+		p.function(expr)
+	case *ast.ListLiteral:
+		p.list(expr, expr.Elements, "[", "]")
+	case *ast.SetLiteral:
+		p.list(expr, expr.Elements, "{", "}")
+	case *ast.ListComprehension:
+		p.write("[")
+		p.expression(expr.Element, precLowest)
+		p.comprehension(expr.Comprehension)
+		p.write("]")
+	case *ast.SetComprehension:
+		p.write("{")
+		p.expression(expr.Element, precLowest)
+		p.comprehension(expr.Comprehension)
+		p.write("}")
+	case *ast.DictComprehension:
+		p.write("{")
+		p.expression(expr.Key, precLowest)
+		p.write(": ")
+		p.expression(expr.Value, precLowest)
+		p.comprehension(expr.Comprehension)
+		p.write("}")
+	case *ast.YieldExpression:
+		p.write("yield")
+		if expr.Value != nil {
+			p.write(" ")
+			p.expression(expr.Value, precLowest)
+		}
+	case *ast.DictLiteral:
+		p.dict(expr)
+	case *ast.Identifier:
+		p.write(expr.Value)
+	case *ast.IntegerLiteral, *ast.StringLiteral:
+		// 保持源码中的写法，比如十六进制数字、引号、转义字符
+		p.write(sourceText(expr))
+	case *ast.Boolean:
+		if expr.Value {
+			p.write("true")
+		} else {
+			p.write("false")
+		}
+	case *ast.NullLiteral:
+		p.write("null")
+	case *ast.WeiAttributeExpression:
+		p.write("wei." + expr.Attribute.Value)
+	case *ast.WeiImportExpression:
+		p.write(sourceText(expr))
+	default:
+		p.write(expr.String())
+	}
+}
+
+func (p *printer) expressions(expressions []ast.Expression) {
+	for i, expr := range expressions {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(expr, precLowest)
+	}
+}
+
+// multiline 源码中跨越多行的列表、字典，每个元素占一行
+func multiline(expr ast.Expression, size int) bool {
+	location := expr.GetFileLocation()
+	return size > 0 && location.End.Line > location.Lineno
+}
+
+// list 输出列表、集合，left right 为两边的括号
+func (p *printer) list(list ast.Expression, elements []ast.Expression, left, right string) {
+	if !multiline(list, len(elements)) {
+		p.write(left)
+		p.expressions(elements)
+		p.closingComments(list.GetFileLocation().End)
+		p.write(right)
+		return
+	}
+	location := list.GetFileLocation()
+	p.write(left)
+	p.trailingComment(location.Lineno)
+	p.write("\n")
+	p.indent++
+	p.atBlockStart = true
+	p.lastLine = location.Lineno
+	for _, element := range elements {
+		p.element(element, element, func() {
+			p.expression(element, precLowest)
+		})
+	}
+	p.flushComments(location.End.Line)
+	p.indent--
+	p.writeIndent()
+	p.write(right)
+}
+
+// comprehension 输出推导式的 for 子句，可迭代对象和条件只能是 or 表达式
+func (p *printer) comprehension(comprehension *ast.Comprehension) {
+	p.write(" for ")
+	p.identifiers(comprehension.Targets)
+	p.write(" in ")
+	p.expression(comprehension.Iterable, precOr)
+	if comprehension.Condition != nil {
+		p.write(" if ")
+		p.expression(comprehension.Condition, precOr)
+	}
+}
+
+func (p *printer) dict(dict *ast.DictLiteral) {
+	if !multiline(dict, len(dict.Keys)) {
+		p.write("{")
+		for i, key := range dict.Keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(key, precLowest)
+			p.write(": ")
+			p.expression(dict.Pairs[key], precLowest)
+		}
+		p.closingComments(dict.Location.End)
+		p.write("}")
+		return
+	}
+	location := dict.GetFileLocation()
+	p.write("{")
+	p.trailingComment(location.Lineno)
+	p.write("\n")
+	p.indent++
+	p.atBlockStart = true
+	p.lastLine = location.Lineno
+	for _, key := range dict.Keys {
+		value := dict.Pairs[key]
+		p.element(key, value, func() {
+			p.expression(key, precLowest)
+			p.write(": ")
+			p.expression(value, precLowest)
+		})
+	}
+	p.flushComments(location.End.Line)
+	p.indent--
+	p.writeIndent()
+	p.write("}")
+}
+
+// element 输出多行列表、字典中的一个元素，元素后面加上逗号，start last 为元素的第一个和最后一个表达式
+func (p *printer) element(start ast.Expression, last ast.Expression, write func()) {
+	line := start.GetFileLocation().Lineno
+	p.flushComments(line)
+	p.beginLine(line)
+	p.inlineComments(startOf(start))
+	write()
+	p.write(",")
+	end := last.GetFileLocation().End.Line
+	p.trailingComment(end)
+	p.write("\n")
+	if end > p.lastLine {
+		p.lastLine = end
+	}
+}
+
+// sourceText 返回节点在源码中的原始文本
+func sourceText(node ast.Node) string {
+	location := node.GetFileLocation()
+	if location == nil || location.Lines == nil {
+		return node.String()
+	}
+	var parts []string
+	for line := location.Lineno; line <= location.End.Line; line++ {
+		runes := []rune(location.Line(line))
+		start, end := 0, len(runes)
+		if line == location.Lineno {
+			start = location.Column
+		}
+		if line == location.End.Line && location.End.Column < end {
+			end = location.End.Column
+		}
+		if start > end {
+			start = end
+		}
+		parts = append(parts, string(runes[start:end]))
+	}
+	return strings.Join(parts, "\n")
+}
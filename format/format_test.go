@@ -0,0 +1,112 @@
+package format
+
+import (
+	"errors"
+	"testing"
+
+	"weilang/lexer"
+	"weilang/parser"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var a=1+2*3;var b=(1+2)*3", "var a = 1 + 2 * 3\nvar b = (1 + 2) * 3\n"},
+		{"print(a-(b-c), (a-b)-c)", "print(a - (b - c), a - b - c)\n"},
+		{"var b = not (a  in l) or (a not  in l)==c", "var b = not a in l or a not in l == c\n"},
+		{"var e = not (a and b) or -(-a)", "var e = not (a and b) or -(-a)\n"},
+		{"var x = 0x10; var s = 'a\\n'", "var x = 0x10\nvar s = 'a\\n'\n"},
+		{"con a = wei.import(\"math\")\nwei.export(a,b)", "con a = wei.import(\"math\")\nwei.export(a, b)\n"},
+		{
+			"fn add(x,y){return x+y}",
+			"fn add(x, y) {\n    return x + y\n}\n",
+		},
+		{
+			"if (a>1) {print(a)} else if (a<0) {} else {return}",
+			"if (a > 1) {\n    print(a)\n} else if (a < 0) {} else {\n    return\n}\n",
+		},
+		{
+			"for (var i, v in l) { while (v) {if (i) {continue}\nbreak} }",
+			"for (var i, v in l) {\n    while (v) {\n        if (i) {\n            continue\n        }\n        break\n    }\n}\n",
+		},
+		{
+			"class A(B){var class.x = 1\ncon y\nfn class.m(){}}",
+			"class A(B) {\n    var class.x = 1\n    con y\n    fn class.m() {}\n}\n",
+		},
+		{"(fn(x){return x})(1)", "(fn(x) {\n    return x\n})(1)\n"},
+		{"var l=[x*2 for  x in items if x>0]", "var l = [x * 2 for x in items if x > 0]\n"},
+		{"var d={v:k for k,v in d};var s={x for x in (a or b) if (not x)}", "var d = {v: k for k, v in d}\nvar s = {x for x in a or b if not x}\n"},
+		{
+			"fn g(){var x=yield  1+2;yield;print((yield x)+1, yield)}",
+			"fn g() {\n    var x = yield 1 + 2\n    yield\n    print((yield x) + 1, yield)\n}\n",
+		},
+		// 注释和空行
+		{
+			"// head\n\n\n\nvar a = 1 // one\n/* b\n c */\nvar b = 2\n// tail",
+			"// head\n\nvar a = 1 // one\n/* b\n c */\nvar b = 2\n// tail\n",
+		},
+		{
+			"while (a) { // loop\n  // body\n  a = a - 1\n\n  // end\n}",
+			"while (a) { // loop\n    // body\n    a = a - 1\n\n    // end\n}\n",
+		},
+		// 语句开头和表达式中间的注释
+		{"/* a */ var x = 1", "/* a */ var x = 1\n"},
+		{"var y = 1 + /* x */ 2 // end\nf(a /* x */)", "var y = 1 + /* x */ 2 // end\nf(a /* x */)\n"},
+		{
+			"print(a, // x\n  b)\nvar c = 1",
+			"print(a, // x\n    b)\nvar c = 1\n",
+		},
+		{
+			"var l = [\n  f(1, // one\n    2),\n  /* three */ 3,\n]",
+			"var l = [\n    f(1, // one\n        2),\n    /* three */ 3,\n]\n",
+		},
+		// 多行的列表、字典
+		{"var l = [1,2,\n3]", "var l = [\n    1,\n    2,\n    3,\n]\n"},
+		{
+			"var d = {\n\"a\": 1, // one\n\"b\": [1, 2]}",
+			"var d = {\n    \"a\": 1, // one\n    \"b\": [1, 2],\n}\n",
+		},
+		// 集合
+		{"var s = {1,2 , 3,}", "var s = {1, 2, 3}\n"},
+		{"var s = {\n1, // one\n2}", "var s = {\n    1, // one\n    2,\n}\n"},
+	}
+	for _, tt := range tests {
+		got, err := Source("<input>", tt.input)
+		if err != nil {
+			t.Errorf("Source(%q) got error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Source(%q)\ngot:\n%s\nexpected:\n%s", tt.input, got, tt.expected)
+			continue
+		}
+		// 格式化是幂等的
+		again, err := Source("<input>", got)
+		if err != nil || again != got {
+			t.Errorf("Source is not idempotent for %q\nfirst:\n%s\nsecond:\n%s", tt.input, got, again)
+		}
+		// 格式化不改变程序的语义
+		if parse(t, tt.input) != parse(t, got) {
+			t.Errorf("Source changed the program %q\n%s", tt.input, got)
+		}
+	}
+}
+
+func TestSourceSyntaxError(t *testing.T) {
+	_, err := Source("<input>", "var a = \nvar b = )")
+	var errorList parser.ErrorList
+	if !errors.As(err, &errorList) {
+		t.Fatalf("expected parser.ErrorList, got %v", err)
+	}
+}
+
+func parse(t *testing.T, input string) string {
+	t.Helper()
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse %q got error: %v", input, err)
+	}
+	return program.String()
+}
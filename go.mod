@@ -2,4 +2,4 @@ module weilang
 
 go 1.20
 
-require github.com/thinkeridea/go-extend v1.3.2 // indirect
+require github.com/thinkeridea/go-extend v1.3.2
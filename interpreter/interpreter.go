@@ -2,34 +2,121 @@ package interpreter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"weilang/diagnostic"
 	"weilang/evaluator"
 	"weilang/lexer"
 	"weilang/object"
 	"weilang/parser"
+	"weilang/stdlib"
 )
 
-func RunFile(filename string) {
-	filename, _ = filepath.Abs(filename)
-	l := lexer.NewWithFilename(filename)
+// 进程退出码，脚本调用 os.exit 时使用脚本指定的退出码
+const (
+	ExitOK           = 0
+	ExitRuntimeError = 1 // 执行出错，包括未捕获的异常
+	ExitUsageError   = 2 // 命令行参数错误、文件不存在
+	ExitSyntaxError  = 3 // 词法、语法错误
+)
+
+// 错误信息的输出格式
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+// Options 执行文件的选项
+type Options struct {
+	// Args 传给脚本的命令行参数
+	Args []string
+	// Stdout print 的输出位置，默认为 os.Stdout
+	Stdout io.Writer
+	// Stderr 错误信息的输出位置，默认为 os.Stderr
+	Stderr io.Writer
+	// ErrorFormat 错误信息的输出格式， ErrorFormatText 或 ErrorFormatJSON ，默认为 ErrorFormatText
+	ErrorFormat string
+	// Tracer 不为空时在执行每条语句之前回调，用于调试器
+	Tracer evaluator.Tracer
+}
+
+// RunFile 执行文件，args 为传给脚本的命令行参数，错误信息输出到 stderr，返回进程退出码
+func RunFile(filename string, args []string) int {
+	return Run(filename, Options{Args: args})
+}
+
+// Run 按照 opts 执行文件，返回进程退出码
+func Run(filename string, opts Options) int {
+	errOut := opts.Stderr
+	if errOut == nil {
+		errOut = os.Stderr
+	}
+	report := func(text string, diagnostics ...diagnostic.Diagnostic) {
+		if opts.ErrorFormat == ErrorFormatJSON {
+			_ = diagnostic.WriteJSON(errOut, diagnostics)
+		} else {
+			_, _ = fmt.Fprintln(errOut, text)
+		}
+	}
+
+	path, _ := filepath.Abs(filename)
+	l, err := lexer.NewWithFilename(path)
+	if err != nil {
+		msg := fmt.Sprintf("can't open file '%s'", filename)
+		report("weilang: "+msg, diagnostic.Diagnostic{
+			Severity: diagnostic.SeverityError,
+			Code:     diagnostic.CodeUsageError,
+			Message:  msg,
+			Filename: filename,
+		})
+		return ExitUsageError
+	}
+	stdlib.SetArgs(append([]string{filename}, opts.Args...))
+	filename = path
 	p := parser.New(l)
 	program, err := p.ParseProgram()
 	if err != nil {
-		fmt.Println(err)
-		return
+		var errs parser.ErrorList
+		if errors.As(err, &errs) {
+			report(err.Error(), errs.Diagnostics()...)
+		} else {
+			report(err.Error())
+		}
+		return ExitSyntaxError
 	}
 
 	mod := object.NewModule(filename)
 	evaluator.CacheModule(mod)
 	state := evaluator.NewWeiState(mod)
+	state.SetTracer(opts.Tracer)
+	if opts.Stdout != nil {
+		state.SetStdout(opts.Stdout)
+	}
 	state.CreateFrame(filename, "<module>")
-	evaluated := evaluator.Eval(context.Background(), state, program, mod.GetEnv())
+	// 程序结束时取消上下文，结束还阻塞在 yield 的生成器
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	evaluated := evaluator.Eval(ctx, state, program, mod.GetEnv())
 	if evaluator.IsError(evaluated) {
-		if state.HasExc() {
-			state.PrintExc()
+		// os.exit 结束程序
+		if exit := evaluated.(*object.Error); exit.Exit {
+			return exit.ExitCode
+		}
+		if opts.ErrorFormat == ErrorFormatJSON {
+			d := state.Diagnostic()
+			if !state.HasExc() {
+				d.Message = evaluated.(*object.Error).Message
+			}
+			report("", d)
+		} else if state.HasExc() {
+			state.PrintExc(errOut)
 		} else {
-			fmt.Println(evaluated.String())
+			report(evaluated.String())
 		}
+		return ExitRuntimeError
 	}
+	return ExitOK
 }
@@ -32,15 +32,18 @@ type Lexer struct {
 	//    下一个 token 的索引
 	tokenIndex int
 	tokens     []token.Token
+	// lines 按行分割的输入文本，第一次使用时生成
+	lines []string
+	// comments 读取过的注释，包括注释符号，用于格式化代码
+	comments []token.Token
 }
 
-func stringFromFilename(filename string) string {
+func stringFromFilename(filename string) (string, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		fmt.Printf(`read file "%s" error: %v\n`, filename, err)
-		os.Exit(1)
+		return "", fmt.Errorf(`read file "%s" error: %w`, filename, err)
 	}
-	return string(data)
+	return string(data), nil
 }
 
 func New(input string) *Lexer {
@@ -50,19 +53,38 @@ func New(input string) *Lexer {
 	return l
 }
 
-func NewWithFilename(filename string) *Lexer {
-	input := stringFromFilename(filename)
+// NewWithFilename 读取文件内容创建词法分析器，读取文件失败时返回错误
+func NewWithFilename(filename string) (*Lexer, error) {
+	input, err := stringFromFilename(filename)
+	if err != nil {
+		return nil, err
+	}
 	l := New(input)
 	l.filename = filename
-	return l
+	return l, nil
+}
+
+// SetFilename 设置文件名，用于报告错误
+func (l *Lexer) SetFilename(filename string) {
+	l.filename = filename
 }
 
 func (l *Lexer) Filename() string {
 	return l.filename
 }
 
+// GetLines 返回按行分割的输入文本，多次调用返回同一个切片，不要修改
 func (l *Lexer) GetLines() []string {
-	return strings.Split(l.input, "\n")
+	if l.lines == nil {
+		l.lines = strings.Split(l.input, "\n")
+	}
+	return l.lines
+}
+
+// Comments 返回已经读取的所有注释， Literal 包括注释符号 // # /* */
+// 解析完成后调用可以得到文件中的全部注释
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
 }
 
 // Dump 读取当前 token 索引
@@ -264,7 +286,9 @@ func (l *Lexer) needSemicolon() bool {
 	var semicolonTokenTypes = []token.TokenType{
 		token.IDENT, token.INT, token.STRING, token.BREAK, token.CONTINUE, token.RETURN,
 		token.RPAREN, token.RBRACKET, token.RBRACE, token.TRUE, token.FALSE, token.NULL,
-		token.RETURN, token.BREAK, token.CONTINUE,
+		token.RETURN, token.BREAK, token.CONTINUE, token.YIELD,
+		// 非法 token 后面也插入分号，方便解析器从错误中恢复
+		token.ILLEGAL,
 	}
 	if lastToken, exist := l.lastTokenExceptComment(); exist {
 		//     文件末尾
@@ -304,6 +328,15 @@ func (l *Lexer) skipWhitespace() {
 func (l *Lexer) readChar() {
 	l.index++
 	if l.index >= len(l.ucodes) {
+		// 第一次读到末尾时，位置移动到最后一个字符之后，保证最后一个 token 的结束位置正确
+		if l.index == len(l.ucodes) {
+			if l.ch == '\n' {
+				l.position.Line++
+				l.position.Column = 0
+			} else {
+				l.position.Column++
+			}
+		}
 		l.ch = 0
 	} else {
 		if l.ch == '\n' {
@@ -456,6 +489,8 @@ func (l *Lexer) readString(end rune) token.Token {
 		// 处理转义字符
 		ch := l.ch
 		if ch == '\\' {
+			escapeIndex := l.index
+			escapeStart := l.position
 			l.readChar()
 			if actual, ok := escapeMap[l.ch]; ok {
 				buf = append(buf, actual)
@@ -463,88 +498,51 @@ func (l *Lexer) readString(end rune) token.Token {
 				continue
 			}
 			// 解析 Unicode 转义字符
-			var ucode rune
-			var codeLen int
+			var codeLen, bitSize int
+			base := 16
+			check := isHexdigit
 			switch l.ch {
 			case 'x':
 				// 格式为 "\xhh" h 代表十六进制字符
-				// 跳过 'x' 字符
-				l.readChar()
-				codeLen = 2
-				s, err := l.getString(codeLen)
-				if err != nil {
-					tok := l.buildToken(token.ILLEGAL)
-					tok.Literal = "illegal escape sequence"
-					return tok
-				}
-				ucode, err = parseRune(s, 16, 8)
-				if err != nil {
-					tok := l.buildToken(token.ILLEGAL)
-					tok.Literal = "illegal escape sequence"
-					return tok
-				}
+				codeLen, bitSize = 2, 8
 			case 'u':
 				// 格式为 "\uhhhh" h 代表十六进制字符
-				// 跳过 'u' 字符
-				l.readChar()
-				codeLen = 4
-				s, err := l.getString(codeLen)
-				if err != nil {
-					tok := l.buildToken(token.ILLEGAL)
-					tok.Literal = "illegal escape sequence"
-					return tok
-				}
-				ucode, err = parseRune(s, 16, 16)
-				if err != nil {
-					tok := l.buildToken(token.ILLEGAL)
-					tok.Literal = "illegal escape sequence"
-					return tok
-				}
+				codeLen, bitSize = 4, 16
 			case 'U':
 				// 格式为 "\Uhhhhhhhh" h 代表十六进制字符
-				// 跳过 'u' 字符
-				l.readChar()
-				codeLen = 8
-				s, err := l.getString(codeLen)
-				if err != nil {
-					tok := l.buildToken(token.ILLEGAL)
-					tok.Literal = "illegal escape sequence"
-					return tok
-				}
-				ucode, err = parseRune(s, 16, 32)
-				if err != nil {
-					tok := l.buildToken(token.ILLEGAL)
-					tok.Literal = "illegal escape sequence"
-					return tok
-				}
+				codeLen, bitSize = 8, 32
 			case '0', '1', '2', '3', '4', '5', '6', '7':
 				// 格式为 "\ooo" o 代表八进制字符，最大为 "\377" (255)
-				codeLen = 3
-				s, err := l.getString(codeLen)
-				if err != nil {
-					tok := l.buildToken(token.ILLEGAL)
-					tok.Literal = "illegal escape sequence"
-					return tok
-				}
-				ucode, err = parseRune(s, 8, 8)
-				if err != nil {
-					tok := l.buildToken(token.ILLEGAL)
-					tok.Literal = "illegal escape sequence"
-					return tok
-				}
+				codeLen, bitSize = 3, 8
+				base = 8
+				check = isOctDigit
 			default:
-				// 非法转义字符
-				tok := l.buildToken(token.ILLEGAL)
-				tok.Literal = "illegal escape sequence"
-				return tok
+				// 非法转义字符，标注反斜杠和后面的一个字符
+				if l.ch != 0 && l.ch != '\n' {
+					l.readChar()
+				}
+				return l.escapeError(escapeIndex, escapeStart, "illegal escape sequence", end)
+			}
+			if base == 16 {
+				// 跳过 'x' 'u' 'U' 字符
+				l.readChar()
+			}
+			// 读取转义字符的数字部分，数字不够时报错
+			digitIndex := l.index
+			for i := 0; i < codeLen && check(l.ch); i++ {
+				l.readChar()
+			}
+			if l.index-digitIndex < codeLen {
+				return l.escapeError(escapeIndex, escapeStart, "illegal escape sequence", end)
+			}
+			ucode, err := parseRune(string(l.ucodes[digitIndex:l.index]), base, bitSize)
+			if err != nil {
+				return l.escapeError(escapeIndex, escapeStart, "illegal escape sequence", end)
 			}
 			if !utf8.ValidRune(ucode) {
-				tok := l.buildToken(token.ILLEGAL)
-				tok.Literal = "escape sequence is invalid Unicode code point"
-				return tok
+				return l.escapeError(escapeIndex, escapeStart, "escape sequence is invalid Unicode code point", end)
 			}
 			buf = append(buf, ucode)
-			l.advance(codeLen)
 			continue
 		}
 
@@ -552,9 +550,7 @@ func (l *Lexer) readString(end rune) token.Token {
 			break
 		}
 		if l.ch == 0 || l.ch == '\n' {
-			tok := l.buildToken(token.ILLEGAL)
-			tok.Literal = "string literal not terminated"
-			return tok
+			return l.unterminatedError("string literal not terminated", 1)
 		}
 		buf = append(buf, l.ch)
 		l.readChar()
@@ -566,14 +562,45 @@ func (l *Lexer) readString(end rune) token.Token {
 	return tok
 }
 
+// escapeError 返回非法转义字符的 ILLEGAL token ，位置为转义字符的起止位置
+// 然后跳过字符串剩余的部分，避免把剩余的部分当作代码继续解析
+func (l *Lexer) escapeError(escapeIndex int, escapeStart token.Position, msg string, end rune) token.Token {
+	escapeEnd := l.position
+	escape := string(l.ucodes[escapeIndex:l.index])
+	for l.ch != end && l.ch != 0 && l.ch != '\n' {
+		if l.ch == '\\' {
+			l.readChar()
+		}
+		if l.ch != 0 && l.ch != '\n' {
+			l.readChar()
+		}
+	}
+	if l.ch == end {
+		l.readChar()
+	}
+	return token.Token{
+		Type:    token.ILLEGAL,
+		Literal: fmt.Sprintf("%s '%s'", msg, escape),
+		Start:   escapeStart,
+		End:     escapeEnd,
+	}
+}
+
+// unterminatedError 返回字符串、注释没有结束的 ILLEGAL token ，位置为开始的定界符，
+// width 为定界符的长度
+func (l *Lexer) unterminatedError(msg string, width int) token.Token {
+	start := l.markPosition
+	end := start
+	end.Column += width
+	return token.Token{Type: token.ILLEGAL, Literal: msg, Start: start, End: end}
+}
+
 func (l *Lexer) readRawString() token.Token {
 	// 跳过开始的引号
 	l.readChar()
 	for {
 		if l.ch == 0 {
-			tok := l.buildToken(token.ILLEGAL)
-			tok.Literal = "string literal not terminated"
-			return tok
+			return l.unterminatedError("raw string literal not terminated", 1)
 		}
 		if l.ch == '`' {
 			break
@@ -586,6 +613,8 @@ func (l *Lexer) readRawString() token.Token {
 }
 
 func (l *Lexer) readComment() token.Token {
+	start := l.markPosition
+	startIndex := l.markIndex
 	l.mark()
 	for {
 		if l.ch == '\n' || l.ch == 0 {
@@ -593,13 +622,35 @@ func (l *Lexer) readComment() token.Token {
 		}
 		l.readChar()
 	}
+	l.addComment(startIndex, start)
 	return l.buildToken(token.COMMENT)
 }
 
+// addComment 记录从 startIndex 到当前位置的注释
+func (l *Lexer) addComment(startIndex int, start token.Position) {
+	endIndex := l.index
+	if endIndex > len(l.ucodes) {
+		endIndex = len(l.ucodes)
+	}
+	l.comments = append(l.comments, token.Token{
+		Type:    token.COMMENT,
+		Literal: strings.TrimRight(string(l.ucodes[startIndex:endIndex]), " \t\r"),
+		Start:   start,
+		End:     l.position,
+	})
+}
+
 func (l *Lexer) readMultilineComment() token.Token {
+	// 开始的 /* 的位置，没有结束时用来报错
+	start := l.markPosition
+	startIndex := l.markIndex
 	l.mark()
 	for {
-		if l.ch == '*' || l.peekCharIs('/') {
+		if l.ch == 0 {
+			l.markPosition = start
+			return l.unterminatedError("comment not terminated", 2)
+		}
+		if l.ch == '*' && l.peekCharIs('/') {
 			break
 		}
 		l.readChar()
@@ -608,20 +659,25 @@ func (l *Lexer) readMultilineComment() token.Token {
 	// 跳过结束的 */ 字符
 	l.readChar()
 	l.readChar()
+	l.addComment(startIndex, start)
 	return tk
 }
 
 func (l *Lexer) getIdentifier() string {
 	index := l.index
-	for unicode.IsSpace(l.ucodes[index]) {
+	length := len(l.ucodes)
+	for index < length && unicode.IsSpace(l.ucodes[index]) {
 		index++
 	}
+	if index >= length {
+		return ""
+	}
 	start := index
 	ch := l.ucodes[index]
 	if !isIdentifierStart(ch) {
 		return ""
 	}
-	for isIdentifierContinue(l.ucodes[index]) {
+	for index < length && isIdentifierContinue(l.ucodes[index]) {
 		index++
 	}
 	return string(l.ucodes[start:index])
@@ -694,9 +750,10 @@ func isLetterAndDigit(ch rune) bool {
 
 // UnicodeCategory returns the Unicode Character Category of the given rune.
 // code from https://stackoverflow.com/a/53507592
+// 新版本 Go 的 unicode.Categories 包含了 "LC" (Lu | Ll | Lt) 这个组合类别，需要跳过
 func UnicodeCategory(r rune) string {
 	for name, table := range unicode.Categories {
-		if len(name) == 2 && unicode.Is(table, r) {
+		if len(name) == 2 && name != "LC" && unicode.Is(table, r) {
 			return name
 		}
 	}
@@ -658,116 +658,116 @@ func TestIllegalToken(t *testing.T) {
 		{
 			`"abc`,
 			token.ILLEGAL, "string literal not terminated",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.Position{Line: 0, Column: 0},
+			token.Position{Line: 0, Column: 1},
 		},
 		{
 			`@`,
 			token.ILLEGAL, "invalid char @",
-			token.Position{0, 0},
-			token.Position{0, 0},
+			token.Position{Line: 0, Column: 0},
+			token.Position{Line: 0, Column: 1},
 		},
 		{
 			`' 6月21日`,
 			token.ILLEGAL, "string literal not terminated",
-			token.Position{0, 0},
-			token.Position{0, 6},
+			token.Position{Line: 0, Column: 0},
+			token.Position{Line: 0, Column: 1},
 		},
 		{
 			`'\d'`,
-			token.ILLEGAL, "illegal escape sequence",
-			token.Position{0, 0},
-			token.Position{0, 2},
+			token.ILLEGAL, `illegal escape sequence '\d'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 3},
 		},
 		{
 			`'\1'`,
-			token.ILLEGAL, "illegal escape sequence",
-			token.Position{0, 0},
-			token.Position{0, 2},
+			token.ILLEGAL, `illegal escape sequence '\1'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 3},
 		},
 		{
 			`'\777'`,
-			token.ILLEGAL, "illegal escape sequence",
-			token.Position{0, 0},
-			token.Position{0, 2},
+			token.ILLEGAL, `illegal escape sequence '\777'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 5},
 		},
 		{
 			`'\x'`,
-			token.ILLEGAL, "illegal escape sequence",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.ILLEGAL, `illegal escape sequence '\x'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 3},
 		},
 		{
 			`'\x1'`,
-			token.ILLEGAL, "illegal escape sequence",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.ILLEGAL, `illegal escape sequence '\x1'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 4},
 		},
 		{
 			`'\xgg'`,
-			token.ILLEGAL, "illegal escape sequence",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.ILLEGAL, `illegal escape sequence '\x'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 3},
 		},
 		{
 			`'\u'`,
-			token.ILLEGAL, "illegal escape sequence",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.ILLEGAL, `illegal escape sequence '\u'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 3},
 		},
 		{
 			`'\u111'`,
-			token.ILLEGAL, "illegal escape sequence",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.ILLEGAL, `illegal escape sequence '\u111'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 6},
 		},
 		{
 			`'\uabct'`,
-			token.ILLEGAL, "illegal escape sequence",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.ILLEGAL, `illegal escape sequence '\uabc'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 6},
 		},
 		{
 			`'\U12345678'`,
-			token.ILLEGAL, "escape sequence is invalid Unicode code point",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.ILLEGAL, `escape sequence is invalid Unicode code point '\U12345678'`,
+			token.Position{Line: 0, Column: 1},
+			token.Position{Line: 0, Column: 11},
 		},
 		{
 			"`abcd",
-			token.ILLEGAL, "string literal not terminated",
-			token.Position{0, 0},
-			token.Position{0, 4},
+			token.ILLEGAL, "raw string literal not terminated",
+			token.Position{Line: 0, Column: 0},
+			token.Position{Line: 0, Column: 1},
 		},
 		{
 			"0c123",
 			token.ILLEGAL, "invalid digit 'c' in decimal literal",
-			token.Position{0, 0},
-			token.Position{0, 1},
+			token.Position{Line: 0, Column: 0},
+			token.Position{Line: 0, Column: 1},
 		},
 		{
 			"0b1_0_2",
 			token.ILLEGAL, "invalid digit '2' in binary literal",
-			token.Position{0, 0},
-			token.Position{0, 6},
+			token.Position{Line: 0, Column: 0},
+			token.Position{Line: 0, Column: 6},
 		},
 		{
 			"0O18",
 			token.ILLEGAL, "invalid digit '8' in octal literal",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.Position{Line: 0, Column: 0},
+			token.Position{Line: 0, Column: 3},
 		},
 		{
 			"0x1g",
 			token.ILLEGAL, "invalid digit 'g' in hexadecimal literal",
-			token.Position{0, 0},
-			token.Position{0, 3},
+			token.Position{Line: 0, Column: 0},
+			token.Position{Line: 0, Column: 3},
 		},
 		{
 			"0x",
 			token.ILLEGAL, "hexadecimal literal has no digits",
-			token.Position{0, 0},
-			token.Position{0, 1},
+			token.Position{Line: 0, Column: 0},
+			token.Position{Line: 0, Column: 2},
 		},
 	}
 
@@ -815,3 +815,54 @@ func TestUnicodeCategory(t *testing.T) {
 		}
 	}
 }
+
+func TestIllegalTokenRecovery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.Token
+	}{
+		{
+			`a = "x\qy" + 1`,
+			[]token.Token{
+				{Type: token.IDENT, Literal: "a", Start: token.Position{Line: 0, Column: 0}, End: token.Position{Line: 0, Column: 1}},
+				{Type: token.ASSIGN, Literal: "=", Start: token.Position{Line: 0, Column: 2}, End: token.Position{Line: 0, Column: 3}},
+				{Type: token.ILLEGAL, Literal: `illegal escape sequence '\q'`, Start: token.Position{Line: 0, Column: 6}, End: token.Position{Line: 0, Column: 8}},
+				{Type: token.PLUS, Literal: "+", Start: token.Position{Line: 0, Column: 11}, End: token.Position{Line: 0, Column: 12}},
+				{Type: token.INT, Literal: "1", Start: token.Position{Line: 0, Column: 13}, End: token.Position{Line: 0, Column: 14}},
+			},
+		},
+		{
+			"1\n  /* abc\n",
+			[]token.Token{
+				{Type: token.INT, Literal: "1", Start: token.Position{Line: 0, Column: 0}, End: token.Position{Line: 0, Column: 1}},
+				{Type: token.SEMICOLON, Literal: ";", Start: token.Position{Line: 1, Column: 2}, End: token.Position{Line: 1, Column: 2}},
+				{Type: token.ILLEGAL, Literal: "comment not terminated", Start: token.Position{Line: 1, Column: 2}, End: token.Position{Line: 1, Column: 4}},
+				{Type: token.SEMICOLON, Literal: ";", Start: token.Position{Line: 2, Column: 0}, End: token.Position{Line: 2, Column: 0}},
+				{Type: token.EOF, Literal: ""},
+			},
+		},
+		{
+			"/* a * b / c */ 1",
+			[]token.Token{
+				{Type: token.COMMENT, Literal: " a * b / c ", Start: token.Position{Line: 0, Column: 2}, End: token.Position{Line: 0, Column: 13}},
+				{Type: token.INT, Literal: "1", Start: token.Position{Line: 0, Column: 16}, End: token.Position{Line: 0, Column: 17}},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		for j, expected := range tt.expected {
+			tok := l.NextToken()
+			if expected.TypeIs(token.EOF) {
+				if !tok.TypeIs(token.EOF) {
+					t.Fatalf("tests[%d][%d] - expected EOF, got=%+v", i, j, tok)
+				}
+				continue
+			}
+			if tok != expected {
+				t.Fatalf("tests[%d][%d] - token wrong. expected=%+v, got=%+v", i, j, expected, tok)
+			}
+		}
+	}
+}
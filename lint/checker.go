@@ -0,0 +1,438 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"weilang/ast"
+	"weilang/diagnostic"
+	"weilang/evaluator"
+)
+
+// SymbolKind 名字的种类
+type SymbolKind int
+
+const (
+	SymbolVariable SymbolKind = iota
+	SymbolConstant
+	SymbolParameter
+	SymbolFunction
+	SymbolClass
+	// SymbolImplicit 方法中自动定义的 this cls super
+	SymbolImplicit
+)
+
+// Symbol 作用域中声明的名字
+type Symbol struct {
+	Name string
+	Kind SymbolKind
+	// Location 声明中名字的位置
+	Location *ast.FileLocation
+	// References 读取、赋值这个名字的位置，不包括声明
+	References []*ast.FileLocation
+	// Module wei.import 导入的模块名，不是导入的模块时为空
+	Module string
+	// Node 声明对应的语法树节点，函数为 *ast.FunctionLiteral，类为 *ast.ClassDefineStatement，
+	// 变量、常量为初始值表达式，参数、循环变量为 nil
+	Node ast.Node
+	// Global 是否在模块顶层声明
+	Global bool
+
+	// used 是否被读取过，赋值不算使用
+	used bool
+	// exported 是否被 wei.export 导出
+	exported bool
+	// arity 函数、类调用时的参数个数，-1 表示不确定
+	arity int
+}
+
+func (s *Symbol) isConstant() bool {
+	return s.Kind != SymbolVariable && s.Kind != SymbolParameter
+}
+
+// scope 与执行时的 object.Environment 一一对应
+type scope struct {
+	outer   *scope
+	symbols map[string]*Symbol
+	// order 按照声明顺序排列的名字，用来输出没有使用的变量
+	order []*Symbol
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, symbols: map[string]*Symbol{}}
+}
+
+func (s *scope) lookup(name string) *Symbol {
+	for sc := s; sc != nil; sc = sc.outer {
+		if sym, ok := sc.symbols[name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+// pendingFunction 等待检查的函数体
+type pendingFunction struct {
+	function *ast.FunctionLiteral
+	scope    *scope
+	// implicit 方法中自动定义的名字
+	implicit []string
+}
+
+// checker 遍历语法树，按照执行时的规则维护作用域
+//
+// 函数执行时才会查找外层作用域中的名字，外层作用域中在函数定义之后声明的名字也可以使用，
+// 所以函数体在所在的作用域检查完之后再检查
+type checker struct {
+	diagnostics []diagnostic.Diagnostic
+	scopes      []*scope
+	pending     []pendingFunction
+}
+
+func newChecker() *checker {
+	return &checker{}
+}
+
+func (c *checker) report(rule string, location *ast.FileLocation, format string, args ...any) {
+	c.diagnostics = append(c.diagnostics, diagnostic.Diagnostic{
+		Severity: ruleSeverities[rule],
+		Code:     rule,
+		Message:  fmt.Sprintf(format, args...),
+		Filename: location.Filename,
+		Start:    location.Start(),
+		End:      location.End,
+	})
+}
+
+func (c *checker) newScope(outer *scope) *scope {
+	sc := newScope(outer)
+	c.scopes = append(c.scopes, sc)
+	return sc
+}
+
+func (c *checker) declare(sc *scope, name string, kind SymbolKind, location *ast.FileLocation) *Symbol {
+	sym := &Symbol{Name: name, Kind: kind, Location: location, Global: sc.outer == nil, arity: -1}
+	sc.symbols[name] = sym
+	sc.order = append(sc.order, sym)
+	return sym
+}
+
+func (c *checker) program(program *ast.Program) {
+	module := c.newScope(nil)
+	c.statements(program.Statements, module)
+	for len(c.pending) > 0 {
+		fn := c.pending[0]
+		c.pending = c.pending[1:]
+		c.functionBody(fn)
+	}
+	c.unused()
+}
+
+// unused 检查没有使用的局部变量和导入的模块
+func (c *checker) unused() {
+	for _, sc := range c.scopes {
+		for _, sym := range sc.order {
+			if sym.used || sym.exported || strings.HasPrefix(sym.Name, "_") {
+				continue
+			}
+			switch {
+			case sym.Module != "":
+				c.report(RuleUnusedImport, sym.Location, "imported and not used: '%s'", sym.Name)
+			case !sym.Global && (sym.Kind == SymbolVariable || sym.Kind == SymbolConstant):
+				c.report(RuleUnusedVariable, sym.Location, "declared and not used: '%s'", sym.Name)
+			}
+		}
+	}
+}
+
+func (c *checker) functionBody(fn pendingFunction) {
+	sc := c.newScope(fn.scope)
+	for _, name := range fn.implicit {
+		sym := c.declare(sc, name, SymbolImplicit, fn.function.GetFileLocation())
+		sym.used = true
+	}
+	for _, param := range fn.function.Parameters {
+		c.declare(sc, param.Value, SymbolParameter, param.GetFileLocation())
+	}
+	c.block(fn.function.Body.Statements, sc)
+}
+
+func (c *checker) block(statements []ast.Statement, outer *scope) {
+	c.statements(statements, c.newScope(outer))
+}
+
+func (c *checker) statements(statements []ast.Statement, sc *scope) {
+	for i, stmt := range statements {
+		c.statement(stmt, sc)
+		if terminates(stmt) && i+1 < len(statements) {
+			c.report(RuleUnreachable, statements[i+1].GetFileLocation(), "unreachable code")
+			// 后面的代码仍然检查，避免漏掉其他问题
+			for _, rest := range statements[i+1:] {
+				c.statement(rest, sc)
+			}
+			return
+		}
+	}
+}
+
+// terminates 判断语句执行后是否一定会跳出当前语句块
+func terminates(stmt ast.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStatement, *ast.BreakStatement, *ast.ContinueStatement:
+		return true
+	case *ast.IfStatement:
+		if stmt.ElseBody == nil || !blockTerminates(stmt.ElseBody.Statements) {
+			return false
+		}
+		for _, branch := range stmt.IfBranches {
+			if !blockTerminates(branch.Body.Statements) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func blockTerminates(statements []ast.Statement) bool {
+	for _, stmt := range statements {
+		if terminates(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) statement(stmt ast.Statement, sc *scope) {
+	switch stmt := stmt.(type) {
+	case *ast.VarStatement:
+		c.declaration(stmt.Name, stmt.Value, SymbolVariable, sc)
+	case *ast.ConStatement:
+		c.declaration(stmt.Name, stmt.Value, SymbolConstant, sc)
+	case *ast.AssignStatement:
+		c.expression(stmt.Value, sc)
+		switch left := stmt.Left.(type) {
+		case *ast.Identifier:
+			sym := sc.lookup(left.Value)
+			if sym != nil {
+				sym.References = append(sym.References, left.GetFileLocation())
+			}
+			switch {
+			case sym == nil:
+				c.report(RuleUndefined, left.GetFileLocation(), "undefined: '%s'", left.Value)
+			case sym.isConstant():
+				c.report(RuleAssignToConstant, left.GetFileLocation(), "cannot assign to constant: '%s'", left.Value)
+			default:
+				// 重新赋值后不能确定函数的参数个数
+				sym.arity = -1
+			}
+		default:
+			c.expression(left, sc)
+		}
+	case *ast.ReturnStatement:
+		if stmt.ReturnValue != nil {
+			c.expression(stmt.ReturnValue, sc)
+		}
+	case *ast.ExpressionStatement:
+		c.expression(stmt.Expression, sc)
+	case *ast.IfStatement:
+		for _, branch := range stmt.IfBranches {
+			c.expression(branch.Condition, sc)
+			c.block(branch.Body.Statements, sc)
+		}
+		if stmt.ElseBody != nil {
+			c.block(stmt.ElseBody.Statements, sc)
+		}
+	case *ast.WhileStatement:
+		c.expression(stmt.Condition, sc)
+		c.block(stmt.Body.Statements, sc)
+	case *ast.ForInStatement:
+		c.expression(stmt.Expr, sc)
+		loop := c.newScope(sc)
+		kind := SymbolVariable
+		if stmt.Con {
+			kind = SymbolConstant
+		}
+		for _, target := range stmt.Targets {
+			// 循环变量常常只是占位，不检查是否被使用
+			c.declare(loop, target.Value, kind, target.GetFileLocation()).used = true
+		}
+		c.block(stmt.Body.Statements, loop)
+	case *ast.FunctionDefineStatement:
+		sym := c.declare(sc, stmt.Function.Name, SymbolFunction, nameLocation(stmt.Function.NameLocation, stmt))
+		sym.Node = stmt.Function
+		sym.arity = len(stmt.Function.Parameters)
+		c.function(stmt.Function, sc)
+	case *ast.ClassDefineStatement:
+		c.class(stmt, sc)
+	case *ast.WeiExportStatement:
+		for _, name := range stmt.Names {
+			sym := sc.lookup(name.Value)
+			if sym == nil {
+				c.report(RuleUndefinedExport, name.GetFileLocation(), "undefined export: '%s'", name.Value)
+				continue
+			}
+			sym.exported = true
+			sym.References = append(sym.References, name.GetFileLocation())
+		}
+	}
+}
+
+func (c *checker) declaration(name *ast.Identifier, value ast.Expression, kind SymbolKind, sc *scope) {
+	c.expression(value, sc)
+	sym := c.declare(sc, name.Value, kind, name.GetFileLocation())
+	sym.Node = value
+	switch value := value.(type) {
+	case *ast.WeiImportExpression:
+		sym.Module = value.Filename
+	case *ast.FunctionLiteral:
+		if kind == SymbolConstant {
+			sym.arity = len(value.Parameters)
+		}
+	}
+}
+
+func (c *checker) function(fn *ast.FunctionLiteral, sc *scope, implicit ...string) {
+	c.pending = append(c.pending, pendingFunction{function: fn, scope: sc, implicit: implicit})
+}
+
+func (c *checker) class(stmt *ast.ClassDefineStatement, sc *scope) {
+	var parent *Symbol
+	if stmt.Parent != nil {
+		parent = c.use(stmt.Parent, sc)
+	}
+	sym := c.declare(sc, stmt.Name, SymbolClass, nameLocation(stmt.NameLocation, stmt))
+	sym.Node = stmt
+	// 没有 __init__ 方法时使用父类的 __init__ 方法
+	switch {
+	case stmt.Parent == nil:
+		sym.arity = 0
+	case parent != nil && parent.Kind == SymbolClass:
+		sym.arity = parent.arity
+	}
+	for _, member := range stmt.Body.Statements {
+		switch member := member.(type) {
+		case *ast.ClassVariableDeclarationStatement:
+			if member.Expr != nil {
+				c.expression(member.Expr, sc)
+			}
+		case *ast.ClassMethodDefineStatement:
+			if member.Class {
+				c.function(member.Function, sc, "cls", "super")
+				continue
+			}
+			if member.Function.Name == "__init__" {
+				sym.arity = len(member.Function.Parameters)
+			}
+			c.function(member.Function, sc, "this", "cls", "super")
+		}
+	}
+}
+
+// nameLocation 返回声明中名字的位置，没有记录名字的位置时使用整个声明的位置
+func nameLocation(location *ast.FileLocation, node ast.Node) *ast.FileLocation {
+	if location != nil {
+		return location
+	}
+	return node.GetFileLocation()
+}
+
+// use 读取名字，返回名字对应的声明，内置函数和未定义的名字返回 nil
+func (c *checker) use(identifier *ast.Identifier, sc *scope) *Symbol {
+	sym := sc.lookup(identifier.Value)
+	if sym != nil {
+		sym.used = true
+		sym.References = append(sym.References, identifier.GetFileLocation())
+		return sym
+	}
+	if !evaluator.IsBuiltin(identifier.Value) {
+		c.report(RuleUndefined, identifier.GetFileLocation(), "undefined: '%s'", identifier.Value)
+	}
+	return nil
+}
+
+func (c *checker) expression(expr ast.Expression, sc *scope) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		c.use(expr, sc)
+	case *ast.BinaryOpExpression:
+		c.expression(expr.Left, sc)
+		c.expression(expr.Right, sc)
+	case *ast.UnaryExpression:
+		c.expression(expr.Operand, sc)
+	case *ast.CallExpression:
+		c.expression(expr.Function, sc)
+		for _, arg := range expr.Arguments {
+			c.expression(arg, sc)
+		}
+		c.call(expr, sc)
+	case *ast.AttributeExpression:
+		c.expression(expr.Left, sc)
+	case *ast.SubscriptionExpression:
+		c.expression(expr.Left, sc)
+		c.expression(expr.Index, sc)
+	case *ast.ListLiteral:
+		for _, element := range expr.Elements {
+			c.expression(element, sc)
+		}
+	case *ast.SetLiteral:
+		for _, element := range expr.Elements {
+			c.expression(element, sc)
+		}
+	case *ast.DictLiteral:
+		for _, key := range expr.Keys {
+			c.expression(key, sc)
+			c.expression(expr.Pairs[key], sc)
+		}
+	case *ast.FunctionLiteral:
+		c.function(expr, sc)
+	case *ast.ListComprehension:
+		inner := c.comprehension(expr.Comprehension, sc)
+		c.expression(expr.Element, inner)
+	case *ast.SetComprehension:
+		inner := c.comprehension(expr.Comprehension, sc)
+		c.expression(expr.Element, inner)
+	case *ast.DictComprehension:
+		inner := c.comprehension(expr.Comprehension, sc)
+		c.expression(expr.Key, inner)
+		c.expression(expr.Value, inner)
+	case *ast.YieldExpression:
+		if expr.Value != nil {
+			c.expression(expr.Value, sc)
+		}
+	}
+}
+
+// comprehension 检查推导式的 for 子句，返回推导式的作用域，变量只在推导式中可见
+func (c *checker) comprehension(comprehension *ast.Comprehension, sc *scope) *scope {
+	c.expression(comprehension.Iterable, sc)
+	inner := c.newScope(sc)
+	for _, target := range comprehension.Targets {
+		// 和 for-in 一样，变量常常只是占位，不检查是否被使用
+		c.declare(inner, target.Value, SymbolVariable, target.GetFileLocation()).used = true
+	}
+	if comprehension.Condition != nil {
+		c.expression(comprehension.Condition, inner)
+	}
+	return inner
+}
+
+// call 检查调用本文件定义的函数、类时参数的个数
+func (c *checker) call(call *ast.CallExpression, sc *scope) {
+	identifier, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	sym := sc.lookup(identifier.Value)
+	if sym == nil || sym.arity < 0 || sym.arity == len(call.Arguments) {
+		return
+	}
+	if sym.Kind == SymbolClass {
+		c.report(RuleWrongArgumentCount, call.GetFileLocation(),
+			"class '%s' expected %d arguments but got %d", sym.Name, sym.arity, len(call.Arguments))
+		return
+	}
+	c.report(RuleWrongArgumentCount, call.GetFileLocation(),
+		"function '%s' expected %d arguments but got %d", sym.Name, sym.arity, len(call.Arguments))
+}
@@ -0,0 +1,199 @@
+// Package lint 不执行代码，通过分析语法树找出脚本中的错误
+//
+// 检查规则
+//
+//	undefined             使用未定义的变量
+//	assign-to-constant    给常量赋值
+//	unreachable           return break continue 之后的代码不会执行
+//	unused-variable       函数、语句块中声明的变量没有被使用
+//	unused-import         导入的模块没有被使用
+//	wrong-argument-count  调用本文件定义的函数、类时参数个数不对
+//	undefined-export      wei.export 导出不存在的名字
+//
+// 可以通过注释关闭规则
+//
+//	// lint:ignore rule1,rule2  忽略本行（行尾注释）或下一行（单独一行的注释）的问题，不写规则时忽略所有规则
+//	// lint:disable rule1,rule2 在整个文件中关闭规则，不写规则时关闭所有规则
+package lint
+
+import (
+	"sort"
+	"strings"
+
+	"weilang/ast"
+	"weilang/diagnostic"
+	"weilang/lexer"
+	"weilang/parser"
+	"weilang/token"
+)
+
+// 检查规则的名字，同时作为诊断信息的 Code
+const (
+	RuleUndefined          = "undefined"
+	RuleAssignToConstant   = "assign-to-constant"
+	RuleUnreachable        = "unreachable"
+	RuleUnusedVariable     = "unused-variable"
+	RuleUnusedImport       = "unused-import"
+	RuleWrongArgumentCount = "wrong-argument-count"
+	RuleUndefinedExport    = "undefined-export"
+)
+
+// Rules 所有的检查规则
+var Rules = []string{
+	RuleUndefined,
+	RuleAssignToConstant,
+	RuleUnreachable,
+	RuleUnusedVariable,
+	RuleUnusedImport,
+	RuleWrongArgumentCount,
+	RuleUndefinedExport,
+}
+
+var ruleSeverities = map[string]diagnostic.Severity{
+	RuleUndefined:          diagnostic.SeverityError,
+	RuleAssignToConstant:   diagnostic.SeverityError,
+	RuleUnreachable:        diagnostic.SeverityWarning,
+	RuleUnusedVariable:     diagnostic.SeverityWarning,
+	RuleUnusedImport:       diagnostic.SeverityWarning,
+	RuleWrongArgumentCount: diagnostic.SeverityError,
+	RuleUndefinedExport:    diagnostic.SeverityError,
+}
+
+// IsRule 判断 name 是否为检查规则的名字
+func IsRule(name string) bool {
+	_, ok := ruleSeverities[name]
+	return ok
+}
+
+// Config 检查的配置
+type Config struct {
+	// Disabled 关闭的规则
+	Disabled map[string]bool
+}
+
+// Source 检查源码，源码有语法错误时返回 parser.ErrorList
+func Source(filename string, src string, config Config) ([]diagnostic.Diagnostic, error) {
+	l := lexer.New(src)
+	l.SetFilename(filename)
+	p := parser.New(l)
+	program, err := p.ParseProgram()
+	if err != nil {
+		return nil, err
+	}
+	return Program(program, l.Comments(), config), nil
+}
+
+// Program 检查语法树，comments 为源码中的注释，用来关闭规则，结果按照位置排序
+func Program(program *ast.Program, comments []token.Token, config Config) []diagnostic.Diagnostic {
+	c := newChecker()
+	c.program(program)
+
+	suppressions := parseSuppressions(comments, program.GetFileLocation())
+	var diagnostics []diagnostic.Diagnostic
+	for _, d := range c.diagnostics {
+		if config.Disabled[d.Code] || suppressions.suppressed(d) {
+			continue
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i].Start, diagnostics[j].Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return diagnostics
+}
+
+// Analyze 分析语法树中声明的名字和引用这些名字的位置，供编辑器跳转到定义、查找引用，
+// 不包括方法中自动定义的 this cls super
+func Analyze(program *ast.Program) []*Symbol {
+	c := newChecker()
+	c.program(program)
+	var symbols []*Symbol
+	for _, sc := range c.scopes {
+		for _, sym := range sc.order {
+			if sym.Kind != SymbolImplicit {
+				symbols = append(symbols, sym)
+			}
+		}
+	}
+	return symbols
+}
+
+const (
+	ignoreDirective  = "lint:ignore"
+	disableDirective = "lint:disable"
+)
+
+// suppressions 注释中关闭的规则，规则集合为 nil 时表示所有规则
+type suppressions struct {
+	// file 整个文件中关闭的规则
+	file map[string]bool
+	// fileAll 是否关闭了所有规则
+	fileAll bool
+	// lines 每一行忽略的规则
+	lines map[int]map[string]bool
+}
+
+// parseSuppressions 解析注释中的 lint 指令，location 用来读取注释所在行的源码
+func parseSuppressions(comments []token.Token, location *ast.FileLocation) *suppressions {
+	s := &suppressions{file: map[string]bool{}, lines: map[int]map[string]bool{}}
+	for _, comment := range comments {
+		text := strings.TrimPrefix(comment.Literal, "//")
+		text = strings.TrimPrefix(text, "/*")
+		text = strings.TrimSuffix(text, "*/")
+		text = strings.TrimSpace(text)
+		var directive string
+		switch {
+		case strings.HasPrefix(text, ignoreDirective):
+			directive = ignoreDirective
+		case strings.HasPrefix(text, disableDirective):
+			directive = disableDirective
+		default:
+			continue
+		}
+		rules := parseRules(text[len(directive):])
+		if directive == disableDirective {
+			if rules == nil {
+				s.fileAll = true
+			}
+			for rule := range rules {
+				s.file[rule] = true
+			}
+			continue
+		}
+		// 单独一行的注释作用于下一行，行尾注释作用于本行
+		line := comment.Start.Line
+		before := []rune(location.Line(line))
+		if comment.Start.Column <= len(before) && strings.TrimSpace(string(before[:comment.Start.Column])) == "" {
+			line = comment.End.Line + 1
+		}
+		s.lines[line] = rules
+	}
+	return s
+}
+
+// parseRules 解析逗号分隔的规则名，没有规则时返回 nil
+func parseRules(text string) map[string]bool {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(fields) == 0 {
+		return nil
+	}
+	rules := map[string]bool{}
+	for _, field := range fields {
+		rules[field] = true
+	}
+	return rules
+}
+
+func (s *suppressions) suppressed(d diagnostic.Diagnostic) bool {
+	if s.fileAll || s.file[d.Code] {
+		return true
+	}
+	rules, ok := s.lines[d.Start.Line]
+	return ok && (rules == nil || rules[d.Code])
+}
@@ -0,0 +1,160 @@
+package lint
+
+import (
+	"errors"
+	"testing"
+
+	"weilang/lexer"
+	"weilang/parser"
+)
+
+// finding 检查结果的规则和位置，行列从 0 开始
+type finding struct {
+	rule   string
+	line   int
+	column int
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []finding
+	}{
+		// undefined
+		{"print(a)", []finding{{RuleUndefined, 0, 6}}},
+		{"print(len([1]))", nil},
+		{"a = 1", []finding{{RuleUndefined, 0, 0}}},
+		{"print(a)\nvar a = 1", []finding{{RuleUndefined, 0, 6}}},
+		{"if (true) { var a = 1 }\nprint(a)", []finding{{RuleUnusedVariable, 0, 16}, {RuleUndefined, 1, 6}}},
+		// 函数执行时才查找外层的名字
+		{"fn f() { return g() }\nfn g() { return 1 }", nil},
+		{"fn f(a) { return a + b }", []finding{{RuleUndefined, 0, 21}}},
+		{"class A {\nfn m() { return this }\nfn class.n() { return cls }\n}", nil},
+		{"class A {\nfn class.n() { return this }\n}", []finding{{RuleUndefined, 1, 22}}},
+		{"class A(B) {}", []finding{{RuleUndefined, 0, 8}}},
+		// 推导式的变量只在推导式中可见
+		{"print([x for x in [1] if x])", nil},
+		{"print({k: v for k, v in {}})", nil},
+		{"print({x for x in y})", []finding{{RuleUndefined, 0, 18}}},
+		{"print([x for x in [1]])\nprint(x)", []finding{{RuleUndefined, 1, 6}}},
+		// assign-to-constant
+		{"con a = 1\na = 2", []finding{{RuleAssignToConstant, 1, 0}}},
+		{"fn f() {}\nf = 1", []finding{{RuleAssignToConstant, 1, 0}}},
+		{"var a = 1\nfn f() { a = 2 }", nil},
+		{"for (con i in [1]) { i = 2 }", []finding{{RuleAssignToConstant, 0, 21}}},
+		// unreachable
+		{"fn f() { return 1\nprint(1) }", []finding{{RuleUnreachable, 1, 0}}},
+		{"while (true) { break; print(1); print(2) }", []finding{{RuleUnreachable, 0, 22}}},
+		{"fn f(a) { if (a) { return 1 } else { return 2 }\nprint(a) }", []finding{{RuleUnreachable, 1, 0}}},
+		{"fn f(a) { if (a) { return 1 }\nprint(a) }", nil},
+		// unused-variable unused-import
+		{"fn f() { var a = 1 }", []finding{{RuleUnusedVariable, 0, 13}}},
+		{"fn f() { var _a = 1 }", nil},
+		{"fn f() { var a = 1\nreturn fn() { return a } }", nil},
+		{"fn f() { var a = 1\na = 2 }", []finding{{RuleUnusedVariable, 0, 13}}},
+		{"var a = 1", nil},
+		{"var m = wei.import(\"math\")", []finding{{RuleUnusedImport, 0, 4}}},
+		{"var m = wei.import(\"math\")\nwei.export(m)", nil},
+		// wrong-argument-count
+		{"fn f(a, b) {}\nf(1)", []finding{{RuleWrongArgumentCount, 1, 0}}},
+		{"con f = fn(a) {}\nf(1, 2)", []finding{{RuleWrongArgumentCount, 1, 0}}},
+		{"var f = fn(a) {}\nf = fn() {}\nf()", nil},
+		{"class A { fn __init__(a) {} }\nA()", []finding{{RuleWrongArgumentCount, 1, 0}}},
+		{"class A {}\nclass B(A) {}\nB(1)", []finding{{RuleWrongArgumentCount, 2, 0}}},
+		// undefined-export
+		{"wei.export(a)", []finding{{RuleUndefinedExport, 0, 11}}},
+		// 注释关闭规则
+		{"print(a) // lint:ignore undefined", nil},
+		{"// lint:ignore\nprint(a)\nprint(b)", []finding{{RuleUndefined, 2, 6}}},
+		{"print(a) // lint:ignore unused-variable", []finding{{RuleUndefined, 0, 6}}},
+		{"// lint:disable undefined\nprint(a)\nprint(b)", nil},
+		{"/* lint:disable */\nprint(a)\ncon b = 1\nb = 2", nil},
+	}
+	for _, tt := range tests {
+		diagnostics, err := Source("<input>", tt.input, Config{})
+		if err != nil {
+			t.Errorf("Source(%q) got error: %v", tt.input, err)
+			continue
+		}
+		var got []finding
+		for _, d := range diagnostics {
+			got = append(got, finding{d.Code, d.Start.Line, d.Start.Column})
+		}
+		if len(got) != len(tt.expected) {
+			t.Errorf("Source(%q)\ngot:      %v\nexpected: %v", tt.input, got, tt.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("Source(%q)\ngot:      %v\nexpected: %v", tt.input, got, tt.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	input := "fn f() { var a = b }"
+	diagnostics, err := Source("<input>", input, Config{Disabled: map[string]bool{RuleUnusedVariable: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Code != RuleUndefined {
+		t.Fatalf("expected only %s, got %v", RuleUndefined, diagnostics)
+	}
+	if diagnostics[0].Severity != ruleSeverities[RuleUndefined] || diagnostics[0].Filename != "<input>" {
+		t.Errorf("wrong diagnostic %v", diagnostics[0])
+	}
+}
+
+func TestSourceSyntaxError(t *testing.T) {
+	_, err := Source("<input>", "var a = )", Config{})
+	var errorList parser.ErrorList
+	if !errors.As(err, &errorList) {
+		t.Fatalf("expected parser.ErrorList, got %v", err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	input := `var m = wei.import("math")
+fn add(a, b) {
+    return a + b
+}
+class A {}
+var x = add(1, 2)
+x = add(x, m)
+`
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		kind       SymbolKind
+		line       int
+		column     int
+		references int
+	}{
+		{"m", SymbolVariable, 0, 4, 1},
+		{"add", SymbolFunction, 1, 3, 2},
+		{"A", SymbolClass, 4, 6, 0},
+		{"x", SymbolVariable, 5, 4, 2},
+		{"a", SymbolParameter, 1, 7, 1},
+		{"b", SymbolParameter, 1, 10, 1},
+	}
+	symbols := Analyze(program)
+	if len(symbols) != len(tests) {
+		t.Fatalf("expected %d symbols, got %d", len(tests), len(symbols))
+	}
+	for i, tt := range tests {
+		sym := symbols[i]
+		if sym.Name != tt.name || sym.Kind != tt.kind || sym.Location.Lineno != tt.line ||
+			sym.Location.Column != tt.column || len(sym.References) != tt.references {
+			t.Errorf("symbols[%d] expected %v, got %s %d %d:%d %d", i, tt,
+				sym.Name, sym.Kind, sym.Location.Lineno, sym.Location.Column, len(sym.References))
+		}
+	}
+	if symbols[0].Module != "math" || !symbols[0].Global || symbols[4].Global {
+		t.Errorf("wrong symbol attributes")
+	}
+}
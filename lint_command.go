@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"weilang/diagnostic"
+	"weilang/interpreter"
+	"weilang/lint"
+	"weilang/parser"
+)
+
+const lintUsage = `Usage:
+    weilang lint [options] <filename>...    检查文件中的错误，不执行代码
+
+Options:
+`
+
+// lintCommand 执行 weilang lint，返回退出码，发现问题时退出码为 1
+func lintCommand(args []string) int {
+	flags := flag.NewFlagSet("weilang lint", flag.ContinueOnError)
+	disable := flags.String("disable", "", "关闭的规则，多个规则用逗号分隔，可用的规则: "+strings.Join(lint.Rules, ", "))
+	errorFormat := flags.String("error-format", interpreter.ErrorFormatText, "检查结果的输出格式: text 或 json")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(flags.Output(), lintUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return interpreter.ExitOK
+		}
+		return interpreter.ExitUsageError
+	}
+	if *errorFormat != interpreter.ErrorFormatText && *errorFormat != interpreter.ErrorFormatJSON {
+		_, _ = fmt.Fprintf(os.Stderr, "weilang: invalid error format '%s'\n", *errorFormat)
+		return interpreter.ExitUsageError
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return interpreter.ExitUsageError
+	}
+	config := lint.Config{Disabled: map[string]bool{}}
+	for _, rule := range strings.Split(*disable, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if !lint.IsRule(rule) {
+			_, _ = fmt.Fprintf(os.Stderr, "weilang: unknown lint rule '%s'\n", rule)
+			return interpreter.ExitUsageError
+		}
+		config.Disabled[rule] = true
+	}
+
+	code := interpreter.ExitOK
+	var diagnostics []diagnostic.Diagnostic
+	for _, filename := range flags.Args() {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "weilang: can't open file '%s': %v\n", filename, err)
+			return interpreter.ExitUsageError
+		}
+		found, err := lint.Source(filename, string(content), config)
+		if err != nil {
+			var errorList parser.ErrorList
+			if !errors.As(err, &errorList) {
+				_, _ = fmt.Fprintf(os.Stderr, "weilang: %v\n", err)
+				return interpreter.ExitRuntimeError
+			}
+			found = errorList.Diagnostics()
+			code = interpreter.ExitSyntaxError
+		}
+		if len(found) > 0 && code == interpreter.ExitOK {
+			code = interpreter.ExitRuntimeError
+		}
+		diagnostics = append(diagnostics, found...)
+	}
+
+	if *errorFormat == interpreter.ErrorFormatJSON {
+		_ = diagnostic.WriteJSON(os.Stdout, diagnostics)
+		return code
+	}
+	for _, d := range diagnostics {
+		// 与编辑器、编译器的习惯一致，行列从 1 开始
+		fmt.Printf("%s:%d:%d: %s: %s (%s)\n", d.Filename, d.Start.Line+1, d.Start.Column+1, d.Severity, d.Message, d.Code)
+	}
+	return code
+}
@@ -0,0 +1,199 @@
+package lsp
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"weilang/ast"
+	"weilang/diagnostic"
+	"weilang/lexer"
+	"weilang/lint"
+	"weilang/parser"
+	"weilang/token"
+)
+
+// document 客户端打开的文档
+type document struct {
+	uri     string
+	path    string
+	version int
+	text    string
+	lines   []string
+	// program symbols 为最后一次解析成功的结果，文档有语法错误时仍然可以跳转、补全
+	program *ast.Program
+	symbols []*lint.Symbol
+	// stale 当前文本有语法错误，program symbols 为旧的结果
+	stale bool
+	// diagnostics 当前文本的语法错误或者检查结果
+	diagnostics []diagnostic.Diagnostic
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{uri: uri, path: uriToPath(uri), version: version}
+	d.update(text)
+	return d
+}
+
+// update 更新文档内容，重新解析和检查
+func (d *document) update(text string) {
+	d.text = text
+	d.lines = strings.Split(text, "\n")
+	l := lexer.New(text)
+	l.SetFilename(d.path)
+	program, err := parser.New(l).ParseProgram()
+	if err != nil {
+		var errorList parser.ErrorList
+		if errors.As(err, &errorList) {
+			d.diagnostics = errorList.Diagnostics()
+		}
+		d.stale = true
+		return
+	}
+	d.stale = false
+	d.program = program
+	d.symbols = lint.Analyze(program)
+	d.diagnostics = lint.Program(program, l.Comments(), lint.Config{})
+}
+
+// withoutLine 返回去掉第 n 行内容后的文档，用于补全正在输入的、还不能解析的代码
+func (d *document) withoutLine(n int) *document {
+	lines := append([]string{}, d.lines...)
+	if n >= 0 && n < len(lines) {
+		lines[n] = ""
+	}
+	return newDocument(d.uri, d.version, strings.Join(lines, "\n"))
+}
+
+// applyChange 应用文档的修改
+func (d *document) applyChange(change TextDocumentContentChangeEvent) string {
+	if change.Range == nil {
+		return change.Text
+	}
+	start := d.offset(change.Range.Start)
+	end := d.offset(change.Range.End)
+	if end < start {
+		end = start
+	}
+	return d.text[:start] + change.Text + d.text[end:]
+}
+
+// offset 把 LSP 的位置转换为文本中的字节偏移
+func (d *document) offset(pos Position) int {
+	offset := 0
+	for i := 0; i < pos.Line && i < len(d.lines); i++ {
+		offset += len(d.lines[i]) + 1
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	line := d.lines[pos.Line]
+	units := 0
+	for i, r := range line {
+		if units >= pos.Character {
+			return offset + i
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return offset + len(line)
+}
+
+// line 返回第 n 行的内容，超出范围时返回空字符串
+func (d *document) line(n int) string {
+	if n < 0 || n >= len(d.lines) {
+		return ""
+	}
+	return d.lines[n]
+}
+
+// position 把行号和字符（rune）列号转换为 LSP 的位置
+func (d *document) position(pos token.Position) Position {
+	runes := []rune(d.line(pos.Line))
+	column := pos.Column
+	if column > len(runes) {
+		column = len(runes)
+	}
+	if column < 0 {
+		column = 0
+	}
+	return Position{Line: pos.Line, Character: len(utf16.Encode(runes[:column]))}
+}
+
+// tokenPosition 把 LSP 的位置转换为行号和字符（rune）列号
+func (d *document) tokenPosition(pos Position) token.Position {
+	line := d.line(pos.Line)
+	units, column := 0, 0
+	for _, r := range line {
+		if units >= pos.Character {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		column++
+	}
+	return token.Position{Line: pos.Line, Column: column}
+}
+
+// locationRange 返回语法树节点位置对应的范围
+func (d *document) locationRange(location *ast.FileLocation) Range {
+	end := location.End
+	if end.Line < location.Lineno {
+		// 没有记录结束位置
+		end = location.Start()
+	}
+	return Range{Start: d.position(location.Start()), End: d.position(end)}
+}
+
+func (d *document) diagnosticRange(diag diagnostic.Diagnostic) Range {
+	end := diag.End
+	if end.Line < diag.Start.Line || (end.Line == diag.Start.Line && end.Column < diag.Start.Column) {
+		end = diag.Start
+	}
+	return Range{Start: d.position(diag.Start), End: d.position(end)}
+}
+
+// contains 判断位置是否在节点的范围内，光标在名字末尾时也算在范围内
+func contains(location *ast.FileLocation, pos token.Position) bool {
+	if location == nil || pos.Line != location.Lineno || location.End.Line != location.Lineno {
+		return false
+	}
+	return location.Column <= pos.Column && pos.Column <= location.End.Column
+}
+
+// wordBefore 返回光标前的标识符和标识符之前的文本
+func (d *document) wordBefore(pos token.Position) (prefix string, word string) {
+	runes := []rune(d.line(pos.Line))
+	if pos.Column < len(runes) {
+		runes = runes[:pos.Column]
+	}
+	return splitTrailingIdent(string(runes))
+}
+
+// splitTrailingIdent 把文本分为末尾的标识符和之前的部分
+func splitTrailingIdent(text string) (prefix string, ident string) {
+	runes := []rune(text)
+	start := len(runes)
+	for start > 0 && isIdentRune(runes[start-1]) {
+		start--
+	}
+	return string(runes[:start]), string(runes[start:])
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r >= utf8.RuneSelf || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	path, _ = filepath.Abs(path)
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
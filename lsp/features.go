@@ -0,0 +1,517 @@
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"weilang/ast"
+	"weilang/diagnostic"
+	"weilang/evaluator"
+	"weilang/lint"
+	"weilang/object"
+	"weilang/stdlib"
+	"weilang/token"
+)
+
+func diagnosticSeverity(d diagnostic.Diagnostic) int {
+	switch d.Severity {
+	case diagnostic.SeverityWarning:
+		return DiagnosticSeverityWarning
+	case diagnostic.SeverityInfo:
+		return DiagnosticSeverityInformation
+	default:
+		return DiagnosticSeverityError
+	}
+}
+
+// symbolAt 返回位置上的名字对应的声明
+func (d *document) symbolAt(pos token.Position) *lint.Symbol {
+	for _, sym := range d.symbols {
+		if contains(sym.Location, pos) {
+			return sym
+		}
+		for _, ref := range sym.References {
+			if contains(ref, pos) {
+				return sym
+			}
+		}
+	}
+	return nil
+}
+
+// identifierAt 返回位置上的标识符
+func (d *document) identifierAt(pos token.Position) *ast.Identifier {
+	var found *ast.Identifier
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if identifier, ok := node.(*ast.Identifier); ok && contains(identifier.Location, pos) {
+			found = identifier
+		}
+		return found == nil
+	})
+	return found
+}
+
+// moduleAttributeAt 返回位置上的 module.name 表达式中的 name 和导入模块的声明
+func (d *document) moduleAttributeAt(pos token.Position) (*ast.Identifier, *lint.Symbol) {
+	var attribute *ast.AttributeExpression
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if attr, ok := node.(*ast.AttributeExpression); ok && contains(attr.Attribute.Location, pos) {
+			attribute = attr
+		}
+		return attribute == nil
+	})
+	if attribute == nil {
+		return nil, nil
+	}
+	left, ok := attribute.Left.(*ast.Identifier)
+	if !ok {
+		return nil, nil
+	}
+	module := d.symbolAt(left.Location.Start())
+	if module == nil || module.Module == "" {
+		return nil, nil
+	}
+	return attribute.Attribute, module
+}
+
+// moduleAttributes 返回文档中所有 module.name 表达式中的 name
+func (d *document) moduleAttributes(module *lint.Symbol, name string) []*ast.Identifier {
+	var identifiers []*ast.Identifier
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		attr, ok := node.(*ast.AttributeExpression)
+		if !ok || attr.Attribute.Value != name {
+			return true
+		}
+		if left, ok := attr.Left.(*ast.Identifier); ok && d.symbolAt(left.Location.Start()) == module {
+			identifiers = append(identifiers, attr.Attribute)
+		}
+		return true
+	})
+	return identifiers
+}
+
+func (d *document) location(location *ast.FileLocation) Location {
+	return Location{URI: d.uri, Range: d.locationRange(location)}
+}
+
+// loadModule 按照 wei.import 的规则查找模块文件，先查找文档所在的目录，再查找当前目录，
+// 已经打开的文档优先使用编辑器中的内容
+func (s *Server) loadModule(d *document, name string) *document {
+	filename := name
+	if !strings.HasSuffix(filename, ".wei") {
+		filename += ".wei"
+	}
+	candidates := []string{filename}
+	if !filepath.IsAbs(filename) {
+		candidates = []string{filepath.Join(filepath.Dir(d.path), filename), filename}
+	}
+	for _, candidate := range candidates {
+		path, err := filepath.Abs(candidate)
+		if err != nil {
+			continue
+		}
+		for _, doc := range s.documents {
+			if doc.path == path {
+				return doc
+			}
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		return newDocument(pathToURI(path), 0, string(content))
+	}
+	return nil
+}
+
+// moduleMember 返回模块中声明的名字
+func (s *Server) moduleMember(d *document, module *lint.Symbol, name string) (*document, *lint.Symbol) {
+	doc := s.loadModule(d, module.Module)
+	if doc == nil {
+		return nil, nil
+	}
+	for _, sym := range doc.symbols {
+		if sym.Global && sym.Name == name {
+			return doc, sym
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) definition(params TextDocumentPositionParams) []Location {
+	d, ok := s.documents[params.TextDocument.URI]
+	if !ok || d.program == nil {
+		return nil
+	}
+	pos := d.tokenPosition(params.Position)
+	if attribute, module := d.moduleAttributeAt(pos); attribute != nil {
+		doc, sym := s.moduleMember(d, module, attribute.Value)
+		if sym == nil {
+			return nil
+		}
+		return []Location{doc.location(sym.Location)}
+	}
+	if sym := d.symbolAt(pos); sym != nil {
+		return []Location{d.location(sym.Location)}
+	}
+	return nil
+}
+
+func (s *Server) references(params ReferenceParams) []Location {
+	d, ok := s.documents[params.TextDocument.URI]
+	if !ok || d.program == nil {
+		return nil
+	}
+	pos := d.tokenPosition(params.Position)
+	locations := []Location{}
+	if attribute, module := d.moduleAttributeAt(pos); attribute != nil {
+		if params.Context.IncludeDeclaration {
+			if doc, sym := s.moduleMember(d, module, attribute.Value); sym != nil {
+				locations = append(locations, doc.location(sym.Location))
+			}
+		}
+		for _, identifier := range d.moduleAttributes(module, attribute.Value) {
+			locations = append(locations, d.location(identifier.Location))
+		}
+		return locations
+	}
+	sym := d.symbolAt(pos)
+	if sym == nil {
+		return nil
+	}
+	if params.Context.IncludeDeclaration {
+		locations = append(locations, d.location(sym.Location))
+	}
+	for _, ref := range sym.References {
+		locations = append(locations, d.location(ref))
+	}
+	return locations
+}
+
+func (s *Server) hover(params TextDocumentPositionParams) *Hover {
+	d, ok := s.documents[params.TextDocument.URI]
+	if !ok || d.program == nil {
+		return nil
+	}
+	pos := d.tokenPosition(params.Position)
+	if attribute, module := d.moduleAttributeAt(pos); attribute != nil {
+		r := d.locationRange(attribute.Location)
+		if _, ok := stdlib.Get(module.Module); ok {
+			return markdownHover(codeBlock(fmt.Sprintf("%s.%s", module.Module, attribute.Value)), &r)
+		}
+		if _, sym := s.moduleMember(d, module, attribute.Value); sym != nil {
+			return markdownHover(codeBlock(describe(sym)), &r)
+		}
+		return nil
+	}
+	if sym := d.symbolAt(pos); sym != nil {
+		return markdownHover(codeBlock(describe(sym)), nil)
+	}
+	identifier := d.identifierAt(pos)
+	if identifier == nil {
+		return nil
+	}
+	if doc, ok := evaluator.BuiltinDoc(identifier.Value); ok {
+		signature, text, _ := strings.Cut(doc, "\n")
+		if signature == "" {
+			signature = identifier.Value + "(...)"
+		}
+		value := codeBlock(signature)
+		if text != "" {
+			value += "\n" + text
+		}
+		r := d.locationRange(identifier.Location)
+		return markdownHover(value, &r)
+	}
+	return nil
+}
+
+func markdownHover(value string, r *Range) *Hover {
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: r}
+}
+
+func codeBlock(code string) string {
+	return "```weilang\n" + code + "\n```"
+}
+
+// describe 返回名字的声明，用于悬停提示
+func describe(sym *lint.Symbol) string {
+	switch sym.Kind {
+	case lint.SymbolFunction:
+		if fn, ok := sym.Node.(*ast.FunctionLiteral); ok {
+			return "fn " + sym.Name + parameters(fn)
+		}
+	case lint.SymbolClass:
+		if class, ok := sym.Node.(*ast.ClassDefineStatement); ok && class.Parent != nil {
+			return fmt.Sprintf("class %s(%s)", sym.Name, class.Parent.Value)
+		}
+		return "class " + sym.Name
+	case lint.SymbolParameter:
+		return "(parameter) " + sym.Name
+	}
+	keyword := "var"
+	if sym.Kind == lint.SymbolConstant {
+		keyword = "con"
+	}
+	switch node := sym.Node.(type) {
+	case *ast.WeiImportExpression:
+		return fmt.Sprintf("%s %s = wei.import(%q)", keyword, sym.Name, node.Filename)
+	case *ast.FunctionLiteral:
+		return fmt.Sprintf("%s %s = fn%s", keyword, sym.Name, parameters(node))
+	}
+	return keyword + " " + sym.Name
+}
+
+func parameters(fn *ast.FunctionLiteral) string {
+	var names []string
+	for _, param := range fn.Parameters {
+		names = append(names, param.Value)
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func (s *Server) completion(params TextDocumentPositionParams) CompletionList {
+	list := CompletionList{Items: []CompletionItem{}}
+	d, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return list
+	}
+	pos := d.tokenPosition(params.Position)
+	prefix, word := d.wordBefore(pos)
+	if d.stale {
+		// 正在输入的这一行通常不能解析，去掉这一行后再分析
+		if patched := d.withoutLine(pos.Line); !patched.stale {
+			d = patched
+		}
+	}
+	var items []CompletionItem
+	if receiver := strings.TrimRight(prefix, " \t"); strings.HasSuffix(receiver, ".") {
+		items = s.attributeCompletion(d, strings.TrimRight(strings.TrimSuffix(receiver, "."), " \t"), pos)
+	} else {
+		items = d.nameCompletion()
+	}
+	for _, item := range items {
+		if strings.HasPrefix(item.Label, word) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list
+}
+
+// attributeCompletion 补全 receiver. 之后的属性，receiver 为 . 之前的文本
+func (s *Server) attributeCompletion(d *document, receiver string, pos token.Position) []CompletionItem {
+	var types []object.ObjectType
+	switch {
+	case receiver == "" || strings.HasSuffix(receiver, ")"):
+		// 不能确定类型
+		types = []object.ObjectType{object.STRING_OBJ, object.LIST_OBJ, object.DICT_OBJ, object.SET_OBJ}
+	case strings.HasSuffix(receiver, `"`), strings.HasSuffix(receiver, "'"), strings.HasSuffix(receiver, "`"):
+		types = []object.ObjectType{object.STRING_OBJ}
+	case strings.HasSuffix(receiver, "]"):
+		types = []object.ObjectType{object.LIST_OBJ}
+	case strings.HasSuffix(receiver, "}"):
+		types = []object.ObjectType{object.DICT_OBJ}
+	default:
+		_, name := splitTrailingIdent(receiver)
+		if name == "wei" {
+			return []CompletionItem{
+				{Label: "export", Kind: CompletionItemKindFunction},
+				{Label: "filename", Kind: CompletionItemKindConstant},
+				{Label: "import", Kind: CompletionItemKindFunction},
+			}
+		}
+		sym := d.lookup(name, pos)
+		if sym != nil && sym.Module != "" {
+			return s.moduleCompletion(d, sym)
+		}
+		types = []object.ObjectType{object.STRING_OBJ, object.LIST_OBJ, object.DICT_OBJ, object.SET_OBJ}
+		if sym != nil {
+			switch sym.Node.(type) {
+			case *ast.StringLiteral:
+				types = []object.ObjectType{object.STRING_OBJ}
+			case *ast.ListLiteral, *ast.ListComprehension:
+				types = []object.ObjectType{object.LIST_OBJ}
+			case *ast.DictLiteral, *ast.DictComprehension:
+				types = []object.ObjectType{object.DICT_OBJ}
+			case *ast.SetLiteral, *ast.SetComprehension:
+				types = []object.ObjectType{object.SET_OBJ}
+			}
+		}
+	}
+	var items []CompletionItem
+	seen := map[string]bool{}
+	for _, objectType := range types {
+		for _, name := range object.AttributeNames(objectType) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			items = append(items, CompletionItem{Label: name, Kind: CompletionItemKindMethod, Detail: string(objectType)})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+// moduleCompletion 补全模块导出的名字
+func (s *Server) moduleCompletion(d *document, module *lint.Symbol) []CompletionItem {
+	var items []CompletionItem
+	if mod, ok := stdlib.Get(module.Module); ok {
+		for _, name := range mod.Exports() {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionItemKindFunction, Detail: module.Module})
+		}
+		return items
+	}
+	doc := s.loadModule(d, module.Module)
+	if doc == nil {
+		return nil
+	}
+	for _, sym := range doc.symbols {
+		if sym.Global {
+			items = append(items, CompletionItem{Label: sym.Name, Kind: completionKind(sym), Detail: describe(sym)})
+		}
+	}
+	return items
+}
+
+// lookup 返回在 pos 之前声明的最后一个名为 name 的名字
+func (d *document) lookup(name string, pos token.Position) *lint.Symbol {
+	var found *lint.Symbol
+	for _, sym := range d.symbols {
+		if sym.Name != name {
+			continue
+		}
+		start := sym.Location.Start()
+		if start.Line > pos.Line || (start.Line == pos.Line && start.Column > pos.Column) {
+			continue
+		}
+		if found == nil || found.Location.Lineno <= start.Line {
+			found = sym
+		}
+	}
+	return found
+}
+
+// nameCompletion 补全变量名、内置函数和关键字
+func (d *document) nameCompletion() []CompletionItem {
+	var items []CompletionItem
+	seen := map[string]bool{}
+	for _, sym := range d.symbols {
+		if seen[sym.Name] {
+			continue
+		}
+		seen[sym.Name] = true
+		items = append(items, CompletionItem{Label: sym.Name, Kind: completionKind(sym), Detail: describe(sym)})
+	}
+	for _, name := range evaluator.BuiltinNames() {
+		if seen[name] {
+			continue
+		}
+		doc, _ := evaluator.BuiltinDoc(name)
+		signature, _, _ := strings.Cut(doc, "\n")
+		items = append(items, CompletionItem{Label: name, Kind: CompletionItemKindFunction, Detail: signature})
+	}
+	for _, keyword := range token.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: CompletionItemKindKeyword})
+	}
+	return items
+}
+
+func completionKind(sym *lint.Symbol) int {
+	switch {
+	case sym.Module != "":
+		return CompletionItemKindModule
+	case sym.Kind == lint.SymbolFunction:
+		return CompletionItemKindFunction
+	case sym.Kind == lint.SymbolClass:
+		return CompletionItemKindClass
+	case sym.Kind == lint.SymbolConstant:
+		return CompletionItemKindConstant
+	default:
+		return CompletionItemKindVariable
+	}
+}
+
+func (s *Server) documentSymbol(params DocumentSymbolParams) []DocumentSymbol {
+	d, ok := s.documents[params.TextDocument.URI]
+	if !ok || d.program == nil {
+		return []DocumentSymbol{}
+	}
+	symbols := d.documentSymbols(d.program.Statements)
+	if symbols == nil {
+		symbols = []DocumentSymbol{}
+	}
+	return symbols
+}
+
+// documentSymbols 返回语句中定义的类和函数，函数中定义的函数作为子节点
+func (d *document) documentSymbols(statements []ast.Statement) []DocumentSymbol {
+	var symbols []DocumentSymbol
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *ast.FunctionDefineStatement:
+			symbols = append(symbols, d.functionSymbol(stmt, stmt.Function, SymbolKindFunction, ""))
+		case *ast.ClassDefineStatement:
+			class := DocumentSymbol{
+				Name:           stmt.Name,
+				Kind:           SymbolKindClass,
+				Range:          d.locationRange(stmt.GetFileLocation()),
+				SelectionRange: d.locationRange(nameLocation(stmt.NameLocation, stmt)),
+			}
+			if stmt.Parent != nil {
+				class.Detail = stmt.Parent.Value
+			}
+			for _, member := range stmt.Body.Statements {
+				if method, ok := member.(*ast.ClassMethodDefineStatement); ok {
+					prefix := ""
+					if method.Class {
+						prefix = "class."
+					}
+					class.Children = append(class.Children, d.functionSymbol(method, method.Function, SymbolKindMethod, prefix))
+				}
+			}
+			symbols = append(symbols, class)
+		case *ast.VarStatement:
+			if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+				symbols = append(symbols, d.variableFunctionSymbol(stmt, stmt.Name, fn))
+			}
+		case *ast.ConStatement:
+			if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+				symbols = append(symbols, d.variableFunctionSymbol(stmt, stmt.Name, fn))
+			}
+		}
+	}
+	return symbols
+}
+
+func (d *document) functionSymbol(stmt ast.Statement, fn *ast.FunctionLiteral, kind int, prefix string) DocumentSymbol {
+	return DocumentSymbol{
+		Name:           prefix + fn.Name,
+		Detail:         parameters(fn),
+		Kind:           kind,
+		Range:          d.locationRange(stmt.GetFileLocation()),
+		SelectionRange: d.locationRange(nameLocation(fn.NameLocation, stmt)),
+		Children:       d.documentSymbols(fn.Body.Statements),
+	}
+}
+
+func (d *document) variableFunctionSymbol(stmt ast.Statement, name *ast.Identifier, fn *ast.FunctionLiteral) DocumentSymbol {
+	return DocumentSymbol{
+		Name:           name.Value,
+		Detail:         parameters(fn),
+		Kind:           SymbolKindFunction,
+		Range:          d.locationRange(stmt.GetFileLocation()),
+		SelectionRange: d.locationRange(name.GetFileLocation()),
+		Children:       d.documentSymbols(fn.Body.Statements),
+	}
+}
+
+func nameLocation(location *ast.FileLocation, node ast.Node) *ast.FileLocation {
+	if location != nil {
+		return location
+	}
+	return node.GetFileLocation()
+}
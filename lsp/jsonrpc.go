@@ -0,0 +1,88 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// conn 基于 Content-Length 分帧的 JSON-RPC 连接
+type conn struct {
+	reader *bufio.Reader
+	// mu 保证消息完整地写入
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{reader: bufio.NewReader(r), writer: w}
+}
+
+// read 读取一条消息，连接关闭时返回 io.EOF
+func (c *conn) read() (*message, error) {
+	header, err := textproto.NewReader(c.reader).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || (err == io.ErrUnexpectedEOF && len(header) == 0) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header '%s'", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return nil, err
+	}
+	msg := &message{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, &ResponseError{Code: codeParseError, Message: err.Error()}
+	}
+	return msg, nil
+}
+
+func (c *conn) write(msg *message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.writer.Write(body)
+	return err
+}
+
+// reply 回复请求，err 不为空时回复错误
+func (c *conn) reply(id *json.RawMessage, result any, err *ResponseError) error {
+	msg := &message{ID: id}
+	if err != nil {
+		msg.Error = err
+		return c.write(msg)
+	}
+	data, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		msg.Error = &ResponseError{Code: codeInternalError, Message: marshalErr.Error()}
+		return c.write(msg)
+	}
+	msg.Result = data
+	return c.write(msg)
+}
+
+// notify 发送通知
+func (c *conn) notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(&message{Method: method, Params: data})
+}
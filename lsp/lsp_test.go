@@ -0,0 +1,484 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient 在同一个进程中通过管道与服务器通信的 LSP 客户端
+type testClient struct {
+	t      *testing.T
+	conn   *conn
+	nextID int
+	// messages 服务器发来的所有消息
+	messages chan *message
+	// done Serve 的返回值
+	done chan error
+	// pending 已经收到但是还没有被取走的通知
+	pending []*message
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+	clientToServerR, clientToServerW := io.Pipe()
+	serverToClientR, serverToClientW := io.Pipe()
+	c := &testClient{
+		t:        t,
+		conn:     newConn(serverToClientR, clientToServerW),
+		messages: make(chan *message, 100),
+		done:     make(chan error, 1),
+	}
+	go func() {
+		c.done <- NewServer(clientToServerR, serverToClientW).Serve()
+		_ = serverToClientW.Close()
+	}()
+	go func() {
+		for {
+			msg, err := c.conn.read()
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() { _ = clientToServerW.Close() })
+	return c
+}
+
+func (c *testClient) next() *message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("connection closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timeout waiting for message")
+	}
+	return nil
+}
+
+// call 发送请求并等待响应，result 为 nil 时不解析结果
+func (c *testClient) call(method string, params any, result any) *ResponseError {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(strings.TrimSpace(string(mustMarshal(c.t, c.nextID))))
+	if err := c.conn.write(&message{ID: &id, Method: method, Params: mustMarshal(c.t, params)}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.next()
+		if msg.ID == nil {
+			c.pending = append(c.pending, msg)
+			continue
+		}
+		if string(*msg.ID) != string(id) {
+			c.t.Fatalf("unexpected response id %s, want %s", *msg.ID, id)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("unmarshal result of %s: %v", method, err)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *testClient) notify(method string, params any) {
+	c.t.Helper()
+	if err := c.conn.notify(method, params); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// diagnostics 等待下一个 publishDiagnostics 通知
+func (c *testClient) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+	for {
+		var msg *message
+		if len(c.pending) > 0 {
+			msg, c.pending = c.pending[0], c.pending[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			c.t.Fatal(err)
+		}
+		return params
+	}
+}
+
+func (c *testClient) initialize() {
+	c.t.Helper()
+	var result InitializeResult
+	if err := c.call("initialize", InitializeParams{}, &result); err != nil {
+		c.t.Fatal(err)
+	}
+	c.notify("initialized", struct{}{})
+}
+
+// open 打开文档并返回文档的诊断信息
+func (c *testClient) open(uri string, text string) PublishDiagnosticsParams {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "weilang", Version: 1, Text: text},
+	})
+	return c.diagnostics()
+}
+
+func (c *testClient) shutdown() {
+	c.t.Helper()
+	if err := c.call("shutdown", nil, nil); err != nil {
+		c.t.Fatal(err)
+	}
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		c.t.Fatalf("Serve returned %v", err)
+	}
+}
+
+func mustMarshal(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func position(uri string, line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	c := newTestClient(t)
+	if err := c.call("textDocument/hover", position("file:///a.wei", 0, 0), nil); err == nil || err.Code != codeServerNotInitialized {
+		t.Errorf("expected server not initialized error, got %v", err)
+	}
+	var result InitializeResult
+	if err := c.call("initialize", InitializeParams{}, &result); err != nil {
+		t.Fatal(err)
+	}
+	capabilities := result.Capabilities
+	if !capabilities.DefinitionProvider || !capabilities.ReferencesProvider || !capabilities.HoverProvider ||
+		!capabilities.DocumentSymbolProvider || capabilities.CompletionProvider == nil {
+		t.Errorf("missing capabilities %+v", capabilities)
+	}
+	if err := c.call("weilang/unknown", struct{}{}, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("expected method not found error, got %v", err)
+	}
+	c.shutdown()
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+	c.notify("exit", nil)
+	if err := <-c.done; !errors.Is(err, ErrExitWithoutShutdown) {
+		t.Errorf("expected ErrExitWithoutShutdown, got %v", err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+	uri := "file:///tmp/main.wei"
+
+	params := c.open(uri, "var a = \nvar b = )")
+	if len(params.Diagnostics) == 0 {
+		t.Fatal("expected syntax error")
+	}
+	d := params.Diagnostics[0]
+	if d.Severity != DiagnosticSeverityError || d.Code != "SyntaxError" || d.Range.Start.Line != 1 {
+		t.Errorf("wrong syntax error %+v", d)
+	}
+
+	// 增量修改
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Range: &Range{Start: Position{0, 8}, End: Position{1, 9}}, Text: "1\nfn f() { var b = a }"},
+		},
+	})
+	params = c.diagnostics()
+	if params.Version != 2 || len(params.Diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic, got %+v", params)
+	}
+	d = params.Diagnostics[0]
+	expected := Range{Start: Position{1, 13}, End: Position{1, 14}}
+	if d.Severity != DiagnosticSeverityWarning || d.Code != "unused-variable" || d.Range != expected {
+		t.Errorf("wrong lint diagnostic %+v", d)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "print(1)"}},
+	})
+	if params = c.diagnostics(); len(params.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", params.Diagnostics)
+	}
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	if params = c.diagnostics(); params.URI != uri || len(params.Diagnostics) != 0 {
+		t.Errorf("expected diagnostics cleared, got %+v", params)
+	}
+	c.shutdown()
+}
+
+func TestDefinitionAndReferences(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+	uri := "file:///tmp/main.wei"
+	c.open(uri, `fn add(a, b) {
+    return a + b
+}
+var s = "😀"
+var total = add(1, 2)
+total = add(total, len("😀" + s))
+class A {}
+var x = A()
+`)
+	tests := []struct {
+		name       string
+		line       int
+		character  int
+		definition Range
+		references int
+	}{
+		{"function call", 4, 13, Range{Position{0, 3}, Position{0, 6}}, 3},
+		{"function name", 0, 4, Range{Position{0, 3}, Position{0, 6}}, 3},
+		{"parameter", 1, 11, Range{Position{0, 7}, Position{0, 8}}, 2},
+		{"variable", 5, 14, Range{Position{4, 4}, Position{4, 9}}, 3},
+		// 😀 在 UTF-16 中占两个编码单元
+		{"variable after emoji", 5, 30, Range{Position{3, 4}, Position{3, 5}}, 2},
+		{"class", 7, 8, Range{Position{6, 6}, Position{6, 7}}, 2},
+	}
+	for _, tt := range tests {
+		var locations []Location
+		if err := c.call("textDocument/definition", position(uri, tt.line, tt.character), &locations); err != nil {
+			t.Fatal(err)
+		}
+		if len(locations) != 1 || locations[0].URI != uri || locations[0].Range != tt.definition {
+			t.Errorf("%s: wrong definition %+v", tt.name, locations)
+		}
+		params := ReferenceParams{TextDocumentPositionParams: position(uri, tt.line, tt.character)}
+		params.Context.IncludeDeclaration = true
+		if err := c.call("textDocument/references", params, &locations); err != nil {
+			t.Fatal(err)
+		}
+		if len(locations) != tt.references {
+			t.Errorf("%s: expected %d references, got %+v", tt.name, tt.references, locations)
+		}
+	}
+
+	var locations []Location
+	if err := c.call("textDocument/definition", position(uri, 5, 20), &locations); err != nil {
+		t.Fatal(err)
+	}
+	if len(locations) != 0 {
+		t.Errorf("builtin should not have definition, got %+v", locations)
+	}
+	c.shutdown()
+}
+
+func TestModuleExports(t *testing.T) {
+	dir := t.TempDir()
+	util := "var unused = 1\n\nfn helper(a) {\n    return a\n}\nwei.export(helper)\n"
+	if err := os.WriteFile(filepath.Join(dir, "util.wei"), []byte(util), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t)
+	c.initialize()
+	uri := pathToURI(filepath.Join(dir, "main.wei"))
+	c.open(uri, "var u = wei.import(\"util\")\nu.helper(1)\nprint(u.helper(2))\n")
+
+	var locations []Location
+	if err := c.call("textDocument/definition", position(uri, 1, 4), &locations); err != nil {
+		t.Fatal(err)
+	}
+	utilURI := pathToURI(filepath.Join(dir, "util.wei"))
+	expected := Location{URI: utilURI, Range: Range{Position{2, 3}, Position{2, 9}}}
+	if len(locations) != 1 || locations[0] != expected {
+		t.Errorf("wrong definition %+v, expected %+v", locations, expected)
+	}
+
+	params := ReferenceParams{TextDocumentPositionParams: position(uri, 2, 9)}
+	params.Context.IncludeDeclaration = true
+	if err := c.call("textDocument/references", params, &locations); err != nil {
+		t.Fatal(err)
+	}
+	if len(locations) != 3 || locations[0] != expected || locations[2].Range.Start != (Position{2, 8}) {
+		t.Errorf("wrong references %+v", locations)
+	}
+
+	var hover Hover
+	if err := c.call("textDocument/hover", position(uri, 1, 3), &hover); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hover.Contents.Value, "fn helper(a)") {
+		t.Errorf("wrong hover %q", hover.Contents.Value)
+	}
+
+	var list CompletionList
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "var u = wei.import(\"util\")\nu.h"}},
+	})
+	c.diagnostics()
+	if err := c.call("textDocument/completion", position(uri, 1, 3), &list); err != nil {
+		t.Fatal(err)
+	}
+	if labels := completionLabels(list); labels != "helper" {
+		t.Errorf("wrong module completion %q", labels)
+	}
+	c.shutdown()
+}
+
+func TestHover(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+	uri := "file:///tmp/main.wei"
+	c.open(uri, "con m = wei.import(\"json\")\nfn add(a, b) { return a + b }\nprint(len(\"abc\"), add(1, 2), m.dumps(1))")
+	tests := []struct {
+		line      int
+		character int
+		expected  string
+	}{
+		{2, 7, "len(object) -> int"},
+		{2, 1, "print(*objects)"},
+		{2, 20, "fn add(a, b)"},
+		{1, 8, "(parameter) a"},
+		{2, 31, "json.dumps"},
+		{0, 4, `con m = wei.import("json")`},
+	}
+	for _, tt := range tests {
+		var hover *Hover
+		if err := c.call("textDocument/hover", position(uri, tt.line, tt.character), &hover); err != nil {
+			t.Fatal(err)
+		}
+		if hover == nil || !strings.Contains(hover.Contents.Value, tt.expected) {
+			t.Errorf("hover at %d:%d expected %q, got %+v", tt.line, tt.character, tt.expected, hover)
+		}
+	}
+	var hover *Hover
+	if err := c.call("textDocument/hover", position(uri, 2, 11), &hover); err != nil {
+		t.Fatal(err)
+	}
+	if hover != nil {
+		t.Errorf("expected no hover on string literal, got %+v", hover)
+	}
+	c.shutdown()
+}
+
+func completionLabels(list CompletionList) string {
+	var labels []string
+	for _, item := range list.Items {
+		labels = append(labels, item.Label)
+	}
+	return strings.Join(labels, ",")
+}
+
+func TestCompletion(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+	tests := []struct {
+		text      string
+		line      int
+		character int
+		contains  []string
+		excludes  []string
+	}{
+		{"var s = \"abc\"\ns.up", 1, 4, []string{"upper"}, []string{"append", "lower"}},
+		{"var l = [1]\nl.", 1, 2, []string{"append", "extend"}, []string{"upper", "get"}},
+		{"var d = {}\nd.", 1, 2, []string{"get", "setdefault"}, []string{"append"}},
+		{"\"abc\".", 0, 6, []string{"upper"}, []string{"append"}},
+		{"var j = wei.import(\"json\")\nj.", 1, 2, []string{"dumps", "loads"}, []string{"upper"}},
+		{"var total = 1\npri", 1, 3, []string{"print"}, []string{"total", "len"}},
+		{"var total = 1\nt", 1, 1, []string{"total", "true", "type"}, []string{"print"}},
+	}
+	for i, tt := range tests {
+		uri := "file:///tmp/completion.wei"
+		if i == 0 {
+			c.open(uri, tt.text)
+		} else {
+			c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+				TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: i + 1},
+				ContentChanges: []TextDocumentContentChangeEvent{{Text: tt.text}},
+			})
+			c.diagnostics()
+		}
+		var list CompletionList
+		if err := c.call("textDocument/completion", position(uri, tt.line, tt.character), &list); err != nil {
+			t.Fatal(err)
+		}
+		labels := "," + completionLabels(list) + ","
+		for _, label := range tt.contains {
+			if !strings.Contains(labels, ","+label+",") {
+				t.Errorf("completion of %q expected %s, got %s", tt.text, label, labels)
+			}
+		}
+		for _, label := range tt.excludes {
+			if strings.Contains(labels, ","+label+",") {
+				t.Errorf("completion of %q should not contain %s, got %s", tt.text, label, labels)
+			}
+		}
+	}
+	c.shutdown()
+}
+
+func TestDocumentSymbol(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+	uri := "file:///tmp/main.wei"
+	c.open(uri, `class Animal {
+    fn __init__(name) {}
+    fn class.create() {}
+}
+fn main() {
+    fn helper() {}
+}
+con square = fn(x) { return x * x }
+var n = 1
+`)
+	var symbols []DocumentSymbol
+	if err := c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols); err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 3 {
+		t.Fatalf("expected 3 symbols, got %+v", symbols)
+	}
+	class := symbols[0]
+	if class.Name != "Animal" || class.Kind != SymbolKindClass || len(class.Children) != 2 ||
+		class.Children[0].Name != "__init__" || class.Children[1].Name != "class.create" ||
+		class.Children[0].Kind != SymbolKindMethod {
+		t.Errorf("wrong class symbol %+v", class)
+	}
+	if class.Range != (Range{Position{0, 0}, Position{3, 1}}) || class.SelectionRange != (Range{Position{0, 6}, Position{0, 12}}) {
+		t.Errorf("wrong class range %+v %+v", class.Range, class.SelectionRange)
+	}
+	main := symbols[1]
+	if main.Name != "main" || main.Kind != SymbolKindFunction || len(main.Children) != 1 || main.Children[0].Name != "helper" {
+		t.Errorf("wrong function symbol %+v", main)
+	}
+	if symbols[2].Name != "square" || symbols[2].Detail != "(x)" {
+		t.Errorf("wrong function variable symbol %+v", symbols[2])
+	}
+	c.shutdown()
+}
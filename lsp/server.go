@@ -0,0 +1,215 @@
+// Package lsp 实现 Weilang 的 Language Server Protocol 服务器，通过标准输入输出与编辑器通信
+//
+// 支持的功能
+//
+//	语法错误和 lint 检查结果的诊断信息
+//	变量、函数、类以及 wei.import 导入的模块成员的跳转到定义、查找引用
+//	内置函数的悬停说明
+//	str list dict 方法、模块成员、变量和关键字的补全
+//	类和函数的文档符号
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrExitWithoutShutdown 没有收到 shutdown 请求就收到了 exit 通知或者连接被关闭
+var ErrExitWithoutShutdown = errors.New("exit without shutdown")
+
+// Server LSP 服务器，一次只处理一个请求
+type Server struct {
+	conn        *conn
+	documents   map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer 创建从 in 读取请求、向 out 写入响应的服务器
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		conn:      newConn(in, out),
+		documents: map[string]*document{},
+	}
+}
+
+// Serve 处理请求直到收到 exit 通知或者连接关闭，
+// 收到 shutdown 请求后正常退出时返回 nil，否则返回 ErrExitWithoutShutdown 或者读写错误
+func (s *Server) Serve() error {
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if s.shutdown {
+					return nil
+				}
+				return ErrExitWithoutShutdown
+			}
+			var respErr *ResponseError
+			if errors.As(err, &respErr) {
+				// 消息不是合法的 JSON，无法得知请求 id
+				if err := s.conn.reply(nil, nil, respErr); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			if s.shutdown {
+				return nil
+			}
+			return ErrExitWithoutShutdown
+		}
+		if msg.ID == nil {
+			s.handleNotification(msg)
+			continue
+		}
+		result, respErr := s.handleRequest(msg)
+		if err := s.conn.reply(msg.ID, result, respErr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handleRequest(msg *message) (any, *ResponseError) {
+	if msg.Method == "initialize" {
+		var params InitializeParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		s.initialized = true
+		return s.initialize(), nil
+	}
+	if !s.initialized {
+		return nil, &ResponseError{Code: codeServerNotInitialized, Message: "server not initialized"}
+	}
+	if s.shutdown {
+		return nil, &ResponseError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+	switch msg.Method {
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params), nil
+	case "textDocument/references":
+		var params ReferenceParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.references(params), nil
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params), nil
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params), nil
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.documentSymbol(params), nil
+	default:
+		return nil, &ResponseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+}
+
+// handleNotification 处理通知，通知不需要回复，参数错误时直接忽略
+func (s *Server) handleNotification(msg *message) {
+	if !s.initialized {
+		return
+	}
+	switch msg.Method {
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if unmarshalParams(msg, &params) != nil {
+			return
+		}
+		item := params.TextDocument
+		doc := newDocument(item.URI, item.Version, item.Text)
+		s.documents[item.URI] = doc
+		s.publishDiagnostics(doc)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if unmarshalParams(msg, &params) != nil {
+			return
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return
+		}
+		for _, change := range params.ContentChanges {
+			doc.update(doc.applyChange(change))
+		}
+		doc.version = params.TextDocument.Version
+		s.publishDiagnostics(doc)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if unmarshalParams(msg, &params) != nil {
+			return
+		}
+		delete(s.documents, params.TextDocument.URI)
+		// 清除关闭的文档的诊断信息
+		_ = s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+	}
+}
+
+func unmarshalParams(msg *message, v any) *ResponseError {
+	if len(msg.Params) == 0 {
+		return &ResponseError{Code: codeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &ResponseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) initialize() InitializeResult {
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:   TextDocumentSyncKindIncremental,
+			DefinitionProvider: true,
+			ReferencesProvider: true,
+			HoverProvider:      true,
+			CompletionProvider: &CompletionOptions{
+				TriggerCharacters: []string{"."},
+			},
+			DocumentSymbolProvider: true,
+		},
+		ServerInfo: &ServerInfo{Name: "weilang"},
+	}
+}
+
+func (s *Server) publishDiagnostics(doc *document) {
+	diagnostics := []Diagnostic{}
+	for _, d := range doc.diagnostics {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    doc.diagnosticRange(d),
+			Severity: diagnosticSeverity(d),
+			Code:     d.Code,
+			Source:   "weilang",
+			Message:  d.Message,
+		})
+	}
+	_ = s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: diagnostics,
+	})
+}
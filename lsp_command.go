@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"weilang/interpreter"
+	"weilang/lsp"
+)
+
+// lspCommand 执行 weilang lsp ，通过标准输入输出提供 Language Server Protocol 服务
+func lspCommand(args []string) int {
+	if len(args) > 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "Usage:\n    weilang lsp                             启动 Language Server Protocol 服务器")
+		return interpreter.ExitUsageError
+	}
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "weilang: %v\n", err)
+		return interpreter.ExitRuntimeError
+	}
+	return interpreter.ExitOK
+}
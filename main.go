@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/user"
@@ -8,18 +10,73 @@ import (
 	"weilang/repl"
 )
 
+const usage = `Usage:
+    weilang                                 启动 repl
+    weilang [options] <filename> [args...]  执行文件，args 通过 os.args 传给脚本
+    weilang fmt [-w] [-check] <filename>... 格式化文件
+    weilang lint [options] <filename>...    检查文件中的错误
+    weilang lsp                             启动 Language Server Protocol 服务器
+    weilang debug <filename> [args...]      在调试器中执行文件
+    weilang dap                             启动 Debug Adapter Protocol 服务器
+
+Options:
+`
+
 func main() {
-	if len(os.Args) > 2 {
-		fmt.Println("Invalid argument")
-		fmt.Println("Usage:")
-		fmt.Println("    weilang [filename]")
-		os.Exit(1)
+	// 子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			os.Exit(fmtCommand(os.Args[2:]))
+		case "lint":
+			os.Exit(lintCommand(os.Args[2:]))
+		case "lsp":
+			os.Exit(lspCommand(os.Args[2:]))
+		case "debug":
+			os.Exit(debugCommand(os.Args[2:]))
+		case "dap":
+			os.Exit(dapCommand(os.Args[2:]))
+		}
+	}
+
+	flags := flag.NewFlagSet("weilang", flag.ContinueOnError)
+	errorFormat := flags.String("error-format", interpreter.ErrorFormatText,
+		"错误信息的输出格式: text 或 json")
+	profiling := flags.Bool("profile", false,
+		"统计函数和行的执行时间，执行结束后输出表格到标准错误")
+	profileOutput := flags.String("profile-output", "weilang.pprof",
+		"--profile 时 pprof 格式结果的输出文件")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(interpreter.ExitUsageError)
+	}
+	if *errorFormat != interpreter.ErrorFormatText && *errorFormat != interpreter.ErrorFormatJSON {
+		_, _ = fmt.Fprintf(os.Stderr, "weilang: invalid error format '%s'\n", *errorFormat)
+		flags.Usage()
+		os.Exit(interpreter.ExitUsageError)
+	}
+
+	// 执行文件，文件名后面的参数传给脚本
+	if flags.NArg() >= 1 {
+		opts := interpreter.Options{
+			Args:        flags.Args()[1:],
+			ErrorFormat: *errorFormat,
+		}
+		if *profiling {
+			os.Exit(runProfile(flags.Arg(0), opts, *profileOutput))
+		}
+		os.Exit(interpreter.Run(flags.Arg(0), opts))
 	}
-	// 执行文件
-	if len(os.Args) == 2 {
-		filename := os.Args[1]
-		interpreter.RunFile(filename)
-		return
+	if *profiling {
+		_, _ = fmt.Fprintln(os.Stderr, "weilang: --profile requires a filename")
+		flags.Usage()
+		os.Exit(interpreter.ExitUsageError)
 	}
 	u, err := user.Current()
 	if err != nil {
@@ -28,5 +85,5 @@ func main() {
 	fmt.Printf("Hello %s! This is the Weilang programming language!\n",
 		u.Username)
 	fmt.Printf("Feel free to type in commands\n")
-	repl.Start(os.Stdin, os.Stdout)
+	os.Exit(repl.Start(os.Stdin, os.Stdout))
 }
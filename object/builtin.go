@@ -1,12 +1,38 @@
 package object
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
+
+// Runtime 解释器提供给内置函数的运行时能力，由 evaluator 实现
+type Runtime interface {
+	// Context 当前执行的上下文
+	Context() context.Context
+	// CallFunction 调用 Weilang 中的可调用对象（函数、方法、类等）
+	CallFunction(fn Object, args ...Object) Object
+}
 
 type BuiltinFunction func(args ...Object) Object
 
+// RuntimeFunction 需要回调 Weilang 函数或者感知执行上下文的内置函数
+type RuntimeFunction func(rt Runtime, args ...Object) Object
+
 type Builtin struct {
 	Name string
-	Fn   BuiltinFunction
+	// Doc 函数的说明，第一行为函数签名，编辑器悬停时显示
+	Doc string
+	Fn  BuiltinFunction
+	// RuntimeFn 不为空时优先于 Fn 使用
+	RuntimeFn RuntimeFunction
+}
+
+// Call 调用内置函数
+func (b *Builtin) Call(rt Runtime, args ...Object) Object {
+	if b.RuntimeFn != nil {
+		return b.RuntimeFn(rt, args...)
+	}
+	return b.Fn(args...)
 }
 
 func (b *Builtin) Type() ObjectType {
@@ -27,10 +53,15 @@ func (b *Builtin) String() string {
 
 type BuiltinMethodFunction func(obj Object, args ...Object) Object
 
+// RuntimeMethodFunction 需要回调 Weilang 函数的内置方法
+type RuntimeMethodFunction func(rt Runtime, obj Object, args ...Object) Object
+
 type BuiltinMethod struct {
 	ctype ObjectType
 	name  string
 	Fn    BuiltinMethodFunction
+	// RuntimeFn 不为空时优先于 Fn 使用
+	RuntimeFn RuntimeMethodFunction
 }
 
 func (b *BuiltinMethod) Type() ObjectType {
@@ -55,6 +86,14 @@ type BoundBuiltinMethod struct {
 	This Object
 }
 
+// Call 调用绑定的内置方法
+func (b *BoundBuiltinMethod) Call(rt Runtime, args ...Object) Object {
+	if b.RuntimeFn != nil {
+		return b.RuntimeFn(rt, b.This, args...)
+	}
+	return b.Fn(b.This, args...)
+}
+
 func (b *BoundBuiltinMethod) Type() ObjectType {
 	return BOUND_BUILTIN_METHOD_OBJ
 }
@@ -1,9 +1,20 @@
 package object
 
+import (
+	"weilang/ast"
+	"weilang/token"
+)
+
 type Frame struct {
 	filename string
 	lineno   int
 	funcName string
+	// location 当前执行的节点位置，用来在错误栈中标注出错的表达式
+	location *ast.FileLocation
+	// env 正在执行的语句所在的环境，只在调试时记录
+	env *Environment
+	// resumed 生成器恢复执行时创建的栈帧，不是一次新的调用
+	resumed bool
 }
 
 func (f *Frame) SetFilename(filename string) {
@@ -22,6 +33,56 @@ func (f *Frame) GetLineno() int {
 	return f.lineno
 }
 
+// SetLocation 更新当前执行的节点位置
+func (f *Frame) SetLocation(location *ast.FileLocation) {
+	f.lineno = location.Lineno
+	f.location = location
+}
+
+// GetLocation 当前执行的节点位置，还没有执行任何节点时返回 nil
+func (f *Frame) GetLocation() *ast.FileLocation {
+	return f.location
+}
+
+// SetEnv 记录正在执行的语句所在的环境
+func (f *Frame) SetEnv(env *Environment) {
+	f.env = env
+}
+
+// GetEnv 正在执行的语句所在的环境，没有记录时返回 nil
+func (f *Frame) GetEnv() *Environment {
+	return f.env
+}
+
+// IsResumed 栈帧是否为生成器恢复执行时创建的
+func (f *Frame) IsResumed() bool {
+	return f.resumed
+}
+
+// GetColumn 当前执行节点的起始列
+func (f *Frame) GetColumn() int {
+	if f.location == nil {
+		return 0
+	}
+	return f.location.Column
+}
+
+// GetEnd 当前执行节点的结束位置，没有位置信息时返回行首
+func (f *Frame) GetEnd() token.Position {
+	if f.location == nil {
+		return token.Position{Line: f.lineno}
+	}
+	return f.location.End
+}
+
+// GetSourceLine 当前执行的那一行源码，没有源码时返回空字符串
+func (f *Frame) GetSourceLine() string {
+	if f.location == nil {
+		return ""
+	}
+	return f.location.Line(f.lineno)
+}
+
 func (f *Frame) GetFuncName() string {
 	return f.funcName
 }
@@ -42,6 +103,13 @@ func (cs *CallStack) CreateFrame(filename string, funcName string) *Frame {
 	return frame
 }
 
+// ResumeFrame 生成器恢复执行时创建栈帧
+func (cs *CallStack) ResumeFrame(filename string, funcName string) *Frame {
+	frame := cs.CreateFrame(filename, funcName)
+	frame.resumed = true
+	return frame
+}
+
 func (cs *CallStack) DestroyFrame() *Frame {
 	frame := cs.frames[cs.index]
 	cs.frames = cs.frames[:cs.index]
@@ -53,11 +121,15 @@ func (cs *CallStack) Top() *Frame {
 	return cs.frames[cs.index]
 }
 
+// Copy 复制调用栈，栈帧也会复制，之后执行代码不会影响复制出来的位置信息
 func (cs *CallStack) Copy() *CallStack {
 	other := NewCallStack()
 	other.frames = make([]*Frame, cs.index+1)
 	other.index = cs.index
-	copy(other.frames, cs.frames)
+	for i, frame := range cs.frames[:cs.index+1] {
+		f := *frame
+		other.frames[i] = &f
+	}
 	return other
 }
 
@@ -2,6 +2,7 @@ package object
 
 import (
 	"fmt"
+	"sort"
 )
 
 // Class 对应用户定义的类
@@ -219,6 +220,16 @@ func (ins *Instance) Ready() Object {
 	return ins
 }
 
+// MemberNames 返回实例的属性名，按字母排序
+func (ins *Instance) MemberNames() []string {
+	names := make([]string, 0, len(ins.members))
+	for name := range ins.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (ins *Instance) ClassName() string {
 	return ins.class.Name
 }
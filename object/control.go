@@ -65,3 +65,27 @@ func (b *BreakValue) TypeNotIs(objectType ObjectType) bool {
 func (b *BreakValue) String() string {
 	return "break"
 }
+
+// STOP_ITERATION Weilang 代码中的 StopIteration ，__next__ 返回它表示迭代结束
+//
+// Go 代码中的迭代器返回 StopIteration 错误表示结束，这个值不是错误，可以作为普通的返回值
+var STOP_ITERATION = &StopIterationValue{}
+
+type StopIterationValue struct {
+}
+
+func (s *StopIterationValue) Type() ObjectType {
+	return STOP_ITERATION_OBJ
+}
+
+func (s *StopIterationValue) TypeIs(objectType ObjectType) bool {
+	return s.Type() == objectType
+}
+
+func (s *StopIterationValue) TypeNotIs(objectType ObjectType) bool {
+	return s.Type() != objectType
+}
+
+func (s *StopIterationValue) String() string {
+	return "StopIteration"
+}
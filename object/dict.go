@@ -5,13 +5,131 @@ type HashPair struct {
 	Value Object
 }
 
+// Dict 按插入顺序保存键值对的哈希表
+//
+// 哈希值相同的键用 recursiveEqual 比较，不同的键不会互相覆盖。
+// 删除时只把 entries 中的位置置为空，空位超过一半时再压缩
 type Dict struct {
 	*attributeStore
-	Pairs map[HashKey]HashPair
+	// index 哈希值到 entries 下标的映射，哈希冲突时一个哈希值对应多个下标
+	index   map[HashKey][]int
+	entries []*HashPair
+	// deleted entries 中被删除的位置的数量
+	deleted int
 }
 
-func NewDict(pairs map[HashKey]HashPair) *Dict {
-	return &Dict{attributeStore: dictAttr, Pairs: pairs}
+func NewDict() *Dict {
+	return &Dict{attributeStore: dictAttr, index: make(map[HashKey][]int)}
+}
+
+// hashKey 返回 key 的哈希值，key 不可哈希时返回错误
+func hashKey(key Object) (HashKey, *Error) {
+	hashable, ok := key.(Hashable)
+	if !ok {
+		return HashKey{}, NewError("unhashable type: '%s'", key.Type())
+	}
+	return hashable.HashKey(), nil
+}
+
+// lookup 返回 key 在 entries 中的下标，不存在时返回 -1
+func (d *Dict) lookup(hk HashKey, key Object) int {
+	for _, i := range d.index[hk] {
+		if pair := d.entries[i]; pair.Key == key || equal(pair.Key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Len 返回键值对的数量
+func (d *Dict) Len() int {
+	return len(d.entries) - d.deleted
+}
+
+// Get 查找 key 对应的值，key 不可哈希时返回错误
+func (d *Dict) Get(key Object) (Object, bool, *Error) {
+	hk, err := hashKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	i := d.lookup(hk, key)
+	if i < 0 {
+		return nil, false, nil
+	}
+	return d.entries[i].Value, true, nil
+}
+
+// Set 设置 key 对应的值，新的键加到最后，已经存在的键保持原来的位置
+func (d *Dict) Set(key, value Object) *Error {
+	hk, err := hashKey(key)
+	if err != nil {
+		return err
+	}
+	if i := d.lookup(hk, key); i >= 0 {
+		d.entries[i].Value = value
+		return nil
+	}
+	d.index[hk] = append(d.index[hk], len(d.entries))
+	d.entries = append(d.entries, &HashPair{Key: key, Value: value})
+	return nil
+}
+
+// Delete 删除 key ，返回删除的值
+func (d *Dict) Delete(key Object) (Object, bool, *Error) {
+	hk, err := hashKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	i := d.lookup(hk, key)
+	if i < 0 {
+		return nil, false, nil
+	}
+	value := d.entries[i].Value
+	bucket := d.index[hk]
+	if len(bucket) == 1 {
+		delete(d.index, hk)
+	} else {
+		for j, k := range bucket {
+			if k == i {
+				d.index[hk] = append(bucket[:j:j], bucket[j+1:]...)
+				break
+			}
+		}
+	}
+	d.entries[i] = nil
+	d.deleted++
+	if d.deleted > len(d.entries)/2 {
+		d.compact()
+	}
+	return value, true, nil
+}
+
+// compact 去掉 entries 中被删除的位置，重建 index
+func (d *Dict) compact() {
+	entries := make([]*HashPair, 0, d.Len())
+	index := make(map[HashKey][]int, len(d.index))
+	for _, pair := range d.entries {
+		if pair == nil {
+			continue
+		}
+		hk := pair.Key.(Hashable).HashKey()
+		index[hk] = append(index[hk], len(entries))
+		entries = append(entries, pair)
+	}
+	d.entries = entries
+	d.index = index
+	d.deleted = 0
+}
+
+// Pairs 按插入顺序返回所有键值对
+func (d *Dict) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, d.Len())
+	for _, pair := range d.entries {
+		if pair != nil {
+			pairs = append(pairs, *pair)
+		}
+	}
+	return pairs
 }
 
 func (d *Dict) Type() ObjectType {
@@ -32,26 +150,19 @@ func (d *Dict) String() string {
 }
 
 func (d *Dict) GetItem(key Object) Object {
-	hashKey, ok := key.(Hashable)
-	if !ok {
-		return NewError("unhashable type: '%s'", key.Type())
+	value, ok, err := d.Get(key)
+	if err != nil {
+		return err
 	}
-
-	pair, ok := d.Pairs[hashKey.HashKey()]
 	if !ok {
 		return NewError("key '%s' does not exist", key.String())
 	}
-	return pair.Value
+	return value
 }
 
 func (d *Dict) SetItem(key, value Object) Object {
-	hashKey, ok := key.(Hashable)
-	if !ok {
-		return NewError("unhashable type: '%s'", key.Type())
-	}
-	d.Pairs[hashKey.HashKey()] = HashPair{
-		Key:   key,
-		Value: value,
+	if err := d.Set(key, value); err != nil {
+		return err
 	}
 	return nil
 }
@@ -65,20 +176,14 @@ func (d *Dict) GetAttribute(name string) Object {
 	if ret != nil {
 		return ret
 	}
-	key := NewString(name)
-	pair, ok := d.Pairs[key.HashKey()]
-	if ok {
-		return pair.Value
+	if value, ok, _ := d.Get(NewString(name)); ok {
+		return value
 	}
 	return attributeError(string(d.Type()), name)
 }
 
 func (d *Dict) SetAttribute(name string, value Object) Object {
-	key := NewString(name)
-	d.Pairs[key.HashKey()] = HashPair{
-		Key:   key,
-		Value: value,
-	}
+	d.Set(NewString(name), value)
 	return nil
 }
 
@@ -95,10 +200,6 @@ var dictAttr = &attributeStore{
 				if argc == 0 || argc > 2 {
 					return WrongNumberArgument2(argc, 1, 2)
 				}
-				key, ok := args[0].(Hashable)
-				if !ok {
-					return NewError("unhashable type: '%s'", args[0].Type())
-				}
 				var defaultValue Object
 				if argc == 1 {
 					defaultValue = NULL
@@ -106,9 +207,12 @@ var dictAttr = &attributeStore{
 					defaultValue = args[1]
 				}
 				this := obj.(*Dict)
-				pair, ok := this.Pairs[key.HashKey()]
+				value, ok, err := this.Get(args[0])
+				if err != nil {
+					return err
+				}
 				if ok {
-					return pair.Value
+					return value
 				}
 				return defaultValue
 			},
@@ -121,11 +225,10 @@ var dictAttr = &attributeStore{
 					return WrongNumberArgument(len(args), 1)
 				}
 				this := obj.(*Dict)
-				key, ok := args[0].(Hashable)
-				if !ok {
-					return NewError("unhashable type: '%s'", args[0].Type())
+				_, ok, err := this.Get(args[0])
+				if err != nil {
+					return err
 				}
-				_, ok = this.Pairs[key.HashKey()]
 				return NativeBoolToBooleanObject(ok)
 			},
 		},
@@ -137,10 +240,6 @@ var dictAttr = &attributeStore{
 				if argc == 0 || argc > 2 {
 					return WrongNumberArgument2(argc, 1, 2)
 				}
-				key, ok := args[0].(Hashable)
-				if !ok {
-					return NewError("unhashable type: '%s'", args[0].Type())
-				}
 				var defaultValue Object
 				if argc == 1 {
 					defaultValue = NULL
@@ -148,11 +247,12 @@ var dictAttr = &attributeStore{
 					defaultValue = args[1]
 				}
 				this := obj.(*Dict)
-				hk := key.HashKey()
-				pair, ok := this.Pairs[hk]
+				value, ok, err := this.Delete(args[0])
+				if err != nil {
+					return err
+				}
 				if ok {
-					delete(this.Pairs, hk)
-					return pair.Value
+					return value
 				}
 				return defaultValue
 			},
@@ -165,10 +265,6 @@ var dictAttr = &attributeStore{
 				if argc == 0 || argc > 2 {
 					return WrongNumberArgument2(argc, 1, 2)
 				}
-				key, ok := args[0].(Hashable)
-				if !ok {
-					return NewError("unhashable type: '%s'", args[0].Type())
-				}
 				var defaultValue Object
 				if argc == 1 {
 					defaultValue = NULL
@@ -176,15 +272,14 @@ var dictAttr = &attributeStore{
 					defaultValue = args[1]
 				}
 				this := obj.(*Dict)
-				hk := key.HashKey()
-				pair, ok := this.Pairs[hk]
-				if ok {
-					return pair.Value
+				value, ok, err := this.Get(args[0])
+				if err != nil {
+					return err
 				}
-				this.Pairs[hk] = HashPair{
-					Key:   args[0],
-					Value: defaultValue,
+				if ok {
+					return value
 				}
+				this.Set(args[0], defaultValue)
 				return defaultValue
 			},
 		},
@@ -200,8 +295,8 @@ var dictAttr = &attributeStore{
 				if !ok {
 					return WrongArgumentTypeAt(args[0].Type(), 1)
 				}
-				for key, pair := range other.Pairs {
-					this.Pairs[key] = pair
+				for _, pair := range other.Pairs() {
+					this.Set(pair.Key, pair.Value)
 				}
 				return this
 			},
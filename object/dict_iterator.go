@@ -6,12 +6,8 @@ type DictIterator struct {
 }
 
 func NewDictIterator(d *Dict) *DictIterator {
-	var pairs []HashPair
-	for _, pair := range d.Pairs {
-		pairs = append(pairs, pair)
-	}
 	return &DictIterator{
-		pairs: pairs,
+		pairs: d.Pairs(),
 		index: 0,
 	}
 }
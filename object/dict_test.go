@@ -0,0 +1,125 @@
+package object
+
+import "testing"
+
+// collidingKey 所有的值哈希值都相同，用于测试哈希冲突
+type collidingKey struct {
+	name string
+}
+
+func (k *collidingKey) Type() ObjectType                     { return "colliding_key" }
+func (k *collidingKey) TypeIs(objectType ObjectType) bool    { return k.Type() == objectType }
+func (k *collidingKey) TypeNotIs(objectType ObjectType) bool { return k.Type() != objectType }
+func (k *collidingKey) String() string                       { return k.name }
+func (k *collidingKey) HashKey() HashKey                     { return HashKey{Type: k.Type(), Value: 42} }
+
+func dictKeys(d *Dict) []string {
+	var keys []string
+	for _, pair := range d.Pairs() {
+		keys = append(keys, pair.Key.String())
+	}
+	return keys
+}
+
+func TestDictCollision(t *testing.T) {
+	a, b, c := &collidingKey{"a"}, &collidingKey{"b"}, &collidingKey{"c"}
+	d := NewDict()
+	d.Set(a, NewInteger(1))
+	d.Set(b, NewInteger(2))
+	d.Set(c, NewInteger(3))
+	if d.Len() != 3 {
+		t.Fatalf("len = %d, want 3", d.Len())
+	}
+	tests := []struct {
+		key   Object
+		value int64
+	}{
+		{a, 1},
+		{b, 2},
+		{c, 3},
+	}
+	for _, tt := range tests {
+		value, ok, err := d.Get(tt.key)
+		if err != nil || !ok {
+			t.Fatalf("get %s: ok = %v, err = %v", tt.key, ok, err)
+		}
+		if value.(*Integer).Value != tt.value {
+			t.Errorf("get %s = %s, want %d", tt.key, value, tt.value)
+		}
+	}
+	if _, ok, _ := d.Delete(b); !ok {
+		t.Fatalf("delete b failed")
+	}
+	if _, ok, _ := d.Get(b); ok {
+		t.Errorf("b still exists after delete")
+	}
+	if value, _, _ := d.Get(c); value.(*Integer).Value != 3 {
+		t.Errorf("get c = %s after deleting b", value)
+	}
+}
+
+func TestDictOrder(t *testing.T) {
+	d := NewDict()
+	for _, key := range []string{"z", "a", "m", "b"} {
+		d.Set(NewString(key), NULL)
+	}
+	// 覆盖已经存在的键不改变顺序
+	d.Set(NewString("z"), TRUE)
+	d.Delete(NewString("a"))
+	d.Set(NewString("a"), NULL)
+
+	want := []string{"z", "m", "b", "a"}
+	keys := dictKeys(d)
+	if len(keys) != len(want) {
+		t.Fatalf("keys = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("keys = %v, want %v", keys, want)
+		}
+	}
+	if d.String() != "{z: true, m: null, b: null, a: null}" {
+		t.Errorf("string = %s", d.String())
+	}
+}
+
+func TestDictDeleteCompact(t *testing.T) {
+	d := NewDict()
+	for i := 0; i < 100; i++ {
+		d.Set(NewInteger(int64(i)), NewInteger(int64(i)))
+	}
+	for i := 0; i < 100; i += 3 {
+		if _, ok, _ := d.Delete(NewInteger(int64(i))); !ok {
+			t.Fatalf("delete %d failed", i)
+		}
+	}
+	for i := 1; i < 100; i += 3 {
+		d.Delete(NewInteger(int64(i)))
+	}
+	if d.Len() != 33 {
+		t.Fatalf("len = %d, want 33", d.Len())
+	}
+	if len(d.entries) >= 100 {
+		t.Errorf("entries not compacted: %d", len(d.entries))
+	}
+	pairs := d.Pairs()
+	for i, pair := range pairs {
+		want := int64(i*3 + 2)
+		if pair.Key.(*Integer).Value != want {
+			t.Fatalf("pairs[%d] = %s, want %d", i, pair.Key, want)
+		}
+		if value, _, _ := d.Get(pair.Key); value.(*Integer).Value != want {
+			t.Errorf("get %d = %s", want, value)
+		}
+	}
+}
+
+func TestDictUnhashable(t *testing.T) {
+	d := NewDict()
+	if err := d.Set(NewList(nil), NULL); err == nil {
+		t.Errorf("expected unhashable error")
+	}
+	if _, _, err := d.Get(NewList(nil)); err == nil || err.Message != "unhashable type: 'list'" {
+		t.Errorf("get error = %v", err)
+	}
+}
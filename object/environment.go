@@ -1,5 +1,7 @@
 package object
 
+import "sort"
+
 const weiName = "wei"
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
@@ -38,6 +40,21 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// Outer 返回上层环境，最外层的模块环境返回 nil
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Names 返回当前这一层环境中声明的名字，按字母排序，不包括上层环境
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (e *Environment) isConstant(name string) bool {
 	if constant, ok := e.propertys[name]; ok {
 		return constant
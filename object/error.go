@@ -4,6 +4,9 @@ import "fmt"
 
 type Error struct {
 	Message string
+	// Exit 为 true 表示这是 os.exit 引发的退出，不是真正的错误
+	Exit     bool
+	ExitCode int
 }
 
 var (
@@ -14,6 +17,11 @@ func NewError(format string, a ...any) *Error {
 	return &Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// NewExit 创建用于结束程序的错误， ExitCode 为进程退出码
+func NewExit(code int) *Error {
+	return &Error{Message: fmt.Sprintf("exit status %d", code), Exit: true, ExitCode: code}
+}
+
 func WrongNumberUnpack(got, want int) *Error {
 	return NewError("unpack got=%d, want=%d", got, want)
 }
@@ -10,14 +10,17 @@ type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	// IsGenerator 函数体中含有 yield ，调用时返回生成器而不执行函数体
+	IsGenerator bool
 }
 
 func NewFunction(fl *ast.FunctionLiteral, env *Environment) *Function {
 	return &Function{
-		Name:       fl.Name,
-		Parameters: fl.Parameters,
-		Body:       fl.Body,
-		Env:        env,
+		Name:        fl.Name,
+		Parameters:  fl.Parameters,
+		Body:        fl.Body,
+		Env:         env,
+		IsGenerator: fl.IsGenerator,
 	}
 }
 
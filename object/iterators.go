@@ -0,0 +1,229 @@
+package object
+
+// sliceIterator 依次返回切片中的元素
+type sliceIterator struct {
+	elements []Object
+	index    int
+}
+
+func (si *sliceIterator) Next() Object {
+	if si.index == len(si.elements) {
+		return StopIteration
+	}
+	element := si.elements[si.index]
+	si.index++
+	return element
+}
+
+// listValueIterator 依次返回列表中的元素，迭代时列表的修改会反映出来
+type listValueIterator struct {
+	l     *List
+	index int
+}
+
+func (li *listValueIterator) Next() Object {
+	if li.index >= len(li.l.Elements) {
+		return StopIteration
+	}
+	element := li.l.Elements[li.index]
+	li.index++
+	return element
+}
+
+// Values 返回依次产生 obj 中的值的迭代器：列表、元组的元素，字符串的字符，字典的键，
+// 其他可迭代对象为自身的迭代器
+//
+// 列表、字符串、字典在 for-in 中迭代时返回下标或键组成的元组，这里只取值
+func Values(obj Object) (Iterator, *Error) {
+	switch obj := obj.(type) {
+	case *List:
+		return &listValueIterator{l: obj}, nil
+	case *Tuple:
+		return &sliceIterator{elements: obj.Elements}, nil
+	case *String:
+		var values []Object
+		for _, r := range obj.Value {
+			values = append(values, NewString(string(r)))
+		}
+		return &sliceIterator{elements: values}, nil
+	case *Dict:
+		var values []Object
+		for _, pair := range obj.Pairs() {
+			values = append(values, pair.Key)
+		}
+		return &sliceIterator{elements: values}, nil
+	case Iterable:
+		return obj.Iter(), nil
+	}
+	return nil, NewError("'%s' object is not iterable", obj.Type())
+}
+
+// IterValues 返回对象中的所有值，和 Values 产生的值相同
+func IterValues(obj Object) ([]Object, *Error) {
+	iterator, err := Values(obj)
+	if err != nil {
+		return nil, err
+	}
+	return Collect(iterator)
+}
+
+// Collect 取出迭代器中剩下的所有值
+func Collect(iterator Iterator) ([]Object, *Error) {
+	values := []Object{}
+	for {
+		next := iterator.Next()
+		if next == StopIteration {
+			return values, nil
+		}
+		if err, ok := next.(*Error); ok {
+			return nil, err
+		}
+		values = append(values, next)
+	}
+}
+
+// Enumerate enumerate(iterable, start) 返回的迭代器，产生 (下标, 值) 元组
+type Enumerate struct {
+	iterator Iterator
+	index    int64
+}
+
+func NewEnumerate(iterator Iterator, start int64) *Enumerate {
+	return &Enumerate{iterator: iterator, index: start}
+}
+
+func (e *Enumerate) Type() ObjectType {
+	return ENUMERATE_OBJ
+}
+
+func (e *Enumerate) TypeIs(objectType ObjectType) bool {
+	return e.Type() == objectType
+}
+
+func (e *Enumerate) TypeNotIs(objectType ObjectType) bool {
+	return e.Type() != objectType
+}
+
+func (e *Enumerate) String() string {
+	return "<enumerate>"
+}
+
+func (e *Enumerate) Iter() Iterator {
+	return e
+}
+
+func (e *Enumerate) Next() Object {
+	next := e.iterator.Next()
+	if _, ok := next.(*Error); ok {
+		return next
+	}
+	index := e.index
+	e.index++
+	return NewTuple([]Object{NewInteger(index), next})
+}
+
+// Zip zip(*iterables) 返回的迭代器，依次从每个迭代器中取一个值组成元组，最短的迭代器结束时结束
+type Zip struct {
+	iterators []Iterator
+	done      bool
+}
+
+func NewZip(iterators []Iterator) *Zip {
+	return &Zip{iterators: iterators, done: len(iterators) == 0}
+}
+
+func (z *Zip) Type() ObjectType {
+	return ZIP_OBJ
+}
+
+func (z *Zip) TypeIs(objectType ObjectType) bool {
+	return z.Type() == objectType
+}
+
+func (z *Zip) TypeNotIs(objectType ObjectType) bool {
+	return z.Type() != objectType
+}
+
+func (z *Zip) String() string {
+	return "<zip>"
+}
+
+func (z *Zip) Iter() Iterator {
+	return z
+}
+
+func (z *Zip) Next() Object {
+	if z.done {
+		return StopIteration
+	}
+	elements := make([]Object, 0, len(z.iterators))
+	for _, iterator := range z.iterators {
+		next := iterator.Next()
+		if next == StopIteration {
+			z.done = true
+			return StopIteration
+		}
+		if _, ok := next.(*Error); ok {
+			return next
+		}
+		elements = append(elements, next)
+	}
+	return NewTuple(elements)
+}
+
+// Reversed reversed(sequence) 返回的迭代器，从后往前产生序列中的值
+type Reversed struct {
+	elements []Object
+	// list 不为空时直接读取列表，迭代时列表的修改会反映出来
+	list  *List
+	index int
+}
+
+// NewReversed 支持列表、元组、字符串、字典，range 使用 Range.Reversed
+func NewReversed(obj Object) (*Reversed, *Error) {
+	if l, ok := obj.(*List); ok {
+		return &Reversed{list: l, index: len(l.Elements) - 1}, nil
+	}
+	switch obj.(type) {
+	case *Tuple, *String, *Dict:
+		elements, err := IterValues(obj)
+		if err != nil {
+			return nil, err
+		}
+		return &Reversed{elements: elements, index: len(elements) - 1}, nil
+	}
+	return nil, NewError("'%s' object is not reversible", obj.Type())
+}
+
+func (r *Reversed) Type() ObjectType {
+	return REVERSED_OBJ
+}
+
+func (r *Reversed) TypeIs(objectType ObjectType) bool {
+	return r.Type() == objectType
+}
+
+func (r *Reversed) TypeNotIs(objectType ObjectType) bool {
+	return r.Type() != objectType
+}
+
+func (r *Reversed) String() string {
+	return "<reversed>"
+}
+
+func (r *Reversed) Iter() Iterator {
+	return r
+}
+
+func (r *Reversed) Next() Object {
+	elements := r.elements
+	if r.list != nil {
+		elements = r.list.Elements
+	}
+	if r.index < 0 || r.index >= len(elements) {
+		return StopIteration
+	}
+	element := elements[r.index]
+	r.index--
+	return element
+}
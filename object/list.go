@@ -42,6 +42,32 @@ func (l *List) pop(idx int) Object {
 	return ele
 }
 
+// Concat 返回 l 和 other 的元素依次组成的新列表
+func (l *List) Concat(other *List) *List {
+	elements := make([]Object, 0, len(l.Elements)+len(other.Elements))
+	elements = append(elements, l.Elements...)
+	elements = append(elements, other.Elements...)
+	return NewList(elements)
+}
+
+// MaxListLength 重复得到的列表的最大长度，超过时返回错误，而不是让 Go 运行时分配内存失败
+const MaxListLength = 1 << 26
+
+// Repeat 返回 l 的元素重复 n 次组成的新列表，n 小于等于 0 时为空列表
+func (l *List) Repeat(n int64) (*List, *Error) {
+	if n <= 0 || len(l.Elements) == 0 {
+		return NewList([]Object{}), nil
+	}
+	if n > MaxListLength/int64(len(l.Elements)) {
+		return nil, NewError("repeated list is too long")
+	}
+	elements := make([]Object, 0, int64(len(l.Elements))*n)
+	for i := int64(0); i < n; i++ {
+		elements = append(elements, l.Elements...)
+	}
+	return NewList(elements), nil
+}
+
 var (
 	outOfRange           = NewError("list index out of range")
 	popOutOfRange        = NewError("list pop index out of range")
@@ -98,150 +124,252 @@ func (l *List) SetAttribute(name string, _ Object) Object {
 // ================================
 // list 对象的内置属性和方法
 // ================================
-var listAttr = &attributeStore{
-	attribute: map[string]Object{
-		// list.append(*objs)
-		"append": &BuiltinMethod{
-			ctype: LIST_OBJ,
-			name:  "append",
-			Fn: func(obj Object, args ...Object) Object {
-				if len(args) == 0 {
-					return atLeastOneArgument
-				}
-
-				this := obj.(*List)
-				this.Elements = append(this.Elements, args...)
-				return this
+// 方法中会创建新的列表，在 init 中赋值以避免初始化循环
+var listAttr *attributeStore
+
+func init() {
+	listAttr = &attributeStore{
+		attribute: map[string]Object{
+			// list.append(*objs)
+			"append": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "append",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) == 0 {
+						return atLeastOneArgument
+					}
+
+					this := obj.(*List)
+					this.Elements = append(this.Elements, args...)
+					return this
+				},
 			},
-		},
-		// list.extend(list2)
-		"extend": &BuiltinMethod{
-			ctype: LIST_OBJ,
-			name:  "extend",
-			Fn: func(obj Object, args ...Object) Object {
-				if len(args) != 1 {
-					return WrongNumberArgument(len(args), 1)
-				}
-
-				this := obj.(*List)
-				arg, ok := args[0].(*List)
-				if !ok {
-					return WrongArgumentTypeAt(args[0].Type(), 1)
-				}
-				this.Elements = append(this.Elements, arg.Elements...)
-				return this
+			// list.extend(list2)
+			"extend": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "extend",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) != 1 {
+						return WrongNumberArgument(len(args), 1)
+					}
+
+					this := obj.(*List)
+					arg, ok := args[0].(*List)
+					if !ok {
+						return WrongArgumentTypeAt(args[0].Type(), 1)
+					}
+					this.Elements = append(this.Elements, arg.Elements...)
+					return this
+				},
 			},
-		},
-		// list.insert(i, obj)
-		"insert": &BuiltinMethod{
-			ctype: LIST_OBJ,
-			name:  "insert",
-			Fn: func(obj Object, args ...Object) Object {
-				if len(args) != 2 {
-					return WrongNumberArgument(len(args), 2)
-				}
-
-				this := obj.(*List)
-				index, ok := args[0].(*Integer)
-				if !ok {
-					return WrongArgumentTypeAt(args[0].Type(), 1)
-				}
-				val := args[1]
-				length := len(this.Elements)
-				idx := convertRange(int(index.Value), length)
-				if idx >= length {
-					this.Elements = append(this.Elements, val)
+			// list.insert(i, obj)
+			"insert": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "insert",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) != 2 {
+						return WrongNumberArgument(len(args), 2)
+					}
+
+					this := obj.(*List)
+					index, ok := args[0].(*Integer)
+					if !ok {
+						return WrongArgumentTypeAt(args[0].Type(), 1)
+					}
+					val := args[1]
+					length := len(this.Elements)
+					idx := convertRange(int(index.Value), length)
+					if idx >= length {
+						this.Elements = append(this.Elements, val)
+						return this
+					}
+					// 增加一个空间
+					elements := append(this.Elements, NULL)
+					copy(elements[idx+1:], elements[idx:])
+					elements[idx] = val
+					this.Elements = elements
 					return this
-				}
-				// 增加一个空间
-				elements := append(this.Elements, NULL)
-				copy(elements[idx+1:], elements[idx:])
-				elements[idx] = val
-				this.Elements = elements
-				return this
+				},
 			},
-		},
-		// list.pop() or list.pop(i)
-		// list.pop() 弹出最后一个元素
-		"pop": &BuiltinMethod{
-			ctype: LIST_OBJ,
-			name:  "pop",
-			Fn: func(obj Object, args ...Object) Object {
-				if len(args) > 1 {
-					return WrongNumberArgument2(len(args), 0, 1)
-				}
-				this := obj.(*List)
-				elements := this.Elements
-				length := len(elements)
-				if len(args) == 0 {
+			// list.pop() or list.pop(i)
+			// list.pop() 弹出最后一个元素
+			"pop": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "pop",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) > 1 {
+						return WrongNumberArgument2(len(args), 0, 1)
+					}
+					this := obj.(*List)
+					elements := this.Elements
+					length := len(elements)
+					if len(args) == 0 {
+						if length == 0 {
+							return NewError("pop from empty list")
+						}
+						ele := elements[length-1]
+						this.Elements = elements[:length-1]
+						return ele
+					}
+					arg, ok := args[0].(*Integer)
+					if !ok {
+						return WrongArgumentTypeAt(args[0].Type(), 1)
+					}
 					if length == 0 {
 						return NewError("pop from empty list")
 					}
-					ele := elements[length-1]
-					this.Elements = elements[:length-1]
-					return ele
-				}
-				arg, ok := args[0].(*Integer)
-				if !ok {
-					return WrongArgumentTypeAt(args[0].Type(), 1)
-				}
-				if length == 0 {
-					return NewError("pop from empty list")
-				}
-				idx := int(arg.Value)
-				if idx < 0 {
-					idx += length
-				}
-				if idx < 0 || idx >= length {
-					return popOutOfRange
-				}
-				if idx == length-1 {
-					ele := elements[length-1]
-					this.Elements = elements[:length-1]
+					idx := int(arg.Value)
+					if idx < 0 {
+						idx += length
+					}
+					if idx < 0 || idx >= length {
+						return popOutOfRange
+					}
+					if idx == length-1 {
+						ele := elements[length-1]
+						this.Elements = elements[:length-1]
+						return ele
+					}
+					ele := elements[idx]
+					this.Elements = append(elements[:idx], elements[idx+1:]...)
 					return ele
-				}
-				ele := elements[idx]
-				this.Elements = append(elements[:idx], elements[idx+1:]...)
-				return ele
+				},
 			},
-		},
-		// list.remove(obj)
-		"remove": &BuiltinMethod{
-			ctype: LIST_OBJ,
-			name:  "remove",
-			Fn: func(obj Object, args ...Object) Object {
-				if len(args) != 1 {
-					return WrongNumberArgument(len(args), 1)
-				}
-				this := obj.(*List)
-				idx := -1
-				for i, element := range this.Elements {
-					if equal(element, args[0]) {
-						idx = i
-						break
-					}
-				}
-				if idx != -1 {
-					this.pop(idx)
+			// list.remove(obj)
+			"remove": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "remove",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) != 1 {
+						return WrongNumberArgument(len(args), 1)
+					}
+					this := obj.(*List)
+					idx := -1
+					for i, element := range this.Elements {
+						if equal(element, args[0]) {
+							idx = i
+							break
+						}
+					}
+					if idx != -1 {
+						this.pop(idx)
+						return this
+					}
+					return NewError("object not in list")
+				},
+			},
+			// list.index(obj[, start[, end]])
+			// 返回第一个等于 obj 的元素的下标，只在 start 到 end （不包括 end ）之间查找
+			"index": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "index",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) == 0 || len(args) > 3 {
+						return WrongNumberArgument2(len(args), 1, 3)
+					}
+					this := obj.(*List)
+					length := len(this.Elements)
+					bounds := []int{0, length}
+					for i, arg := range args[1:] {
+						integer, ok := arg.(*Integer)
+						if !ok {
+							return WrongArgumentTypeAt(arg.Type(), i+2)
+						}
+						bounds[i] = convertRange(int(integer.Value), length)
+					}
+					for i := bounds[0]; i < bounds[1] && i < len(this.Elements); i++ {
+						if equal(this.Elements[i], args[0]) {
+							return NewInteger(int64(i))
+						}
+					}
+					return NewError("object not in list")
+				},
+			},
+			// list.count(obj)
+			// 返回等于 obj 的元素个数
+			"count": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "count",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) != 1 {
+						return WrongNumberArgument(len(args), 1)
+					}
+					this := obj.(*List)
+					count := 0
+					for _, element := range this.Elements {
+						if equal(element, args[0]) {
+							count++
+						}
+					}
+					return NewInteger(int64(count))
+				},
+			},
+			// list.copy()
+			// 返回浅拷贝，新列表中的元素和原来的是同一个对象
+			"copy": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "copy",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) > 0 {
+						return WrongNumberArgument(len(args), 0)
+					}
+					this := obj.(*List)
+					elements := make([]Object, len(this.Elements))
+					copy(elements, this.Elements)
+					return NewList(elements)
+				},
+			},
+			// list.clear()
+			"clear": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "clear",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) > 0 {
+						return WrongNumberArgument(len(args), 0)
+					}
+					this := obj.(*List)
+					this.Elements = []Object{}
 					return this
-				}
-				return NewError("object not in list")
+				},
 			},
-		},
-		// list.reverse()
-		"reverse": &BuiltinMethod{
-			ctype: LIST_OBJ,
-			name:  "reverse",
-			Fn: func(obj Object, args ...Object) Object {
-				if len(args) > 0 {
-					return WrongNumberArgument(len(args), 0)
-				}
-				this := obj.(*List)
-				elements := this.Elements
-				for i, j := 0, len(elements)-1; i < j; i, j = i+1, j-1 {
-					elements[i], elements[j] = elements[j], elements[i]
-				}
-				return this
+			// list.sort([key[, reverse]])
+			// 稳定排序，key 为 null 时直接比较元素
+			"sort": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "sort",
+				RuntimeFn: func(rt Runtime, obj Object, args ...Object) Object {
+					if len(args) > 2 {
+						return WrongNumberArgument2(len(args), 0, 2)
+					}
+					key, reverse, err := SortOptions(args, 1)
+					if err != nil {
+						return err
+					}
+					this := obj.(*List)
+					sorted, err := Sort(rt, this.Elements, key, reverse)
+					if err != nil {
+						return err
+					}
+					this.Elements = sorted
+					return this
+				},
+			},
+			// list.reverse()
+			"reverse": &BuiltinMethod{
+				ctype: LIST_OBJ,
+				name:  "reverse",
+				Fn: func(obj Object, args ...Object) Object {
+					if len(args) > 0 {
+						return WrongNumberArgument(len(args), 0)
+					}
+					this := obj.(*List)
+					elements := this.Elements
+					for i, j := 0, len(elements)-1; i < j; i, j = i+1, j-1 {
+						elements[i], elements[j] = elements[j], elements[i]
+					}
+					return this
+				},
 			},
 		},
-	}}
+	}
+}
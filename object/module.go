@@ -1,6 +1,9 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 type Module struct {
 	filename string
@@ -53,6 +56,16 @@ func (m *Module) AddExport(name string) {
 	m.export[name] = true
 }
 
+// Exports 返回导出的名字，按字母排序
+func (m *Module) Exports() []string {
+	names := make([]string, 0, len(m.export))
+	for name := range m.export {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func NewModule(filename string) *Module {
 	env := NewEnvironment()
 	weiObj := newWei()
@@ -65,3 +78,13 @@ func NewModule(filename string) *Module {
 		export:   export,
 	}
 }
+
+// NewBuiltinModule 创建 Go 实现的内置模块，members 里的成员全部导出
+func NewBuiltinModule(name string, members map[string]Object) *Module {
+	mod := NewModule(name)
+	for memberName, member := range members {
+		mod.env.Add(memberName, member, true)
+		mod.AddExport(memberName)
+	}
+	return mod
+}
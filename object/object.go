@@ -1,5 +1,7 @@
 package object
 
+import "sort"
+
 type ObjectType string
 
 const (
@@ -16,6 +18,18 @@ const (
 	LIST_ITERATOR_OBJ        = "list_iterator"
 	DICT_OBJ                 = "dict"
 	DICT_ITERATOR_OBJ        = "dict_iterator"
+	SET_OBJ                  = "set"
+	FROZENSET_OBJ            = "frozenset"
+	SET_ITERATOR_OBJ         = "set_iterator"
+	STOP_ITERATION_OBJ       = "stop_iteration"
+	GENERATOR_OBJ            = "generator"
+	RANGE_OBJ                = "range"
+	RANGE_ITERATOR_OBJ       = "range_iterator"
+	ENUMERATE_OBJ            = "enumerate"
+	ZIP_OBJ                  = "zip"
+	REVERSED_OBJ             = "reversed"
+	MAP_OBJ                  = "map"
+	FILTER_OBJ               = "filter"
 	CONTINUE_VALUE_OBJ       = "continue_value"
 	BREAK_VALUE_OBJ          = "break_value"
 	BUILTIN_METHOD_OBJ       = "builtin_method"
@@ -67,6 +81,34 @@ type attributeStore struct {
 	attribute map[string]Object
 }
 
+// names 返回所有属性名，按字母排序
+func (a *attributeStore) names() []string {
+	names := make([]string, 0, len(a.attribute))
+	for name := range a.attribute {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AttributeNames 返回内置类型 str list dict 的属性和方法名，按字母排序，用于编辑器补全
+func AttributeNames(objectType ObjectType) []string {
+	switch objectType {
+	case STRING_OBJ:
+		return strAttr.names()
+	case LIST_OBJ:
+		return listAttr.names()
+	case DICT_OBJ:
+		return dictAttr.names()
+	case SET_OBJ:
+		return setAttr.names()
+	case FROZENSET_OBJ:
+		return frozensetAttr.names()
+	default:
+		return nil
+	}
+}
+
 func (a *attributeStore) get(object Object, name string) Object {
 	val, ok := a.attribute[name]
 	if ok {
@@ -83,6 +125,12 @@ func (a *attributeStore) get(object Object, name string) Object {
 	return nil
 }
 
+// BinaryOperable 支持二元运算符的对象，比如 time 模块中的 datetime 和 duration
+type BinaryOperable interface {
+	// BinaryOp 计算 this operator right ，不支持该运算时返回 nil
+	BinaryOp(operator string, right Object) Object
+}
+
 type Iterator interface {
 	Next() Object
 }
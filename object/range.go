@@ -0,0 +1,131 @@
+package object
+
+import (
+	"fmt"
+	"math"
+)
+
+// Range range(start, stop, step) 返回的整数序列，不保存元素，可以重复迭代
+type Range struct {
+	Start int64
+	Stop  int64
+	Step  int64
+}
+
+// NewRange 创建整数序列，step 为 0 或者元素个数超过 int64 的范围时返回错误
+func NewRange(start, stop, step int64) (*Range, *Error) {
+	if step == 0 {
+		return nil, NewError("range() arg 3 must not be zero")
+	}
+	if rangeLen(start, stop, step) > math.MaxInt64 {
+		return nil, NewError("range() result has too many items")
+	}
+	return &Range{Start: start, Stop: stop, Step: step}, nil
+}
+
+// rangeLen 计算元素个数，stop - start 可能超过 int64 的范围，使用 uint64 计算
+func rangeLen(start, stop, step int64) uint64 {
+	if step > 0 && start < stop {
+		return (uint64(stop)-uint64(start)-1)/uint64(step) + 1
+	}
+	// -step 在 step 为最小值时溢出，但是转换为 uint64 后仍然是正确的绝对值
+	if step < 0 && start > stop {
+		return (uint64(start)-uint64(stop)-1)/uint64(-step) + 1
+	}
+	return 0
+}
+
+func (r *Range) Type() ObjectType {
+	return RANGE_OBJ
+}
+
+func (r *Range) TypeIs(objectType ObjectType) bool {
+	return r.Type() == objectType
+}
+
+func (r *Range) TypeNotIs(objectType ObjectType) bool {
+	return r.Type() != objectType
+}
+
+func (r *Range) String() string {
+	if r.Step == 1 {
+		return fmt.Sprintf("range(%d, %d)", r.Start, r.Stop)
+	}
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.Stop, r.Step)
+}
+
+// Len 序列中元素的个数
+func (r *Range) Len() int64 {
+	return int64(rangeLen(r.Start, r.Stop, r.Step))
+}
+
+// Has 判断 value 是否在序列中，不需要遍历
+func (r *Range) Has(value Object) bool {
+	i, ok := value.(*Integer)
+	if !ok {
+		return false
+	}
+	if r.Step > 0 {
+		if i.Value < r.Start || i.Value >= r.Stop {
+			return false
+		}
+		return (uint64(i.Value)-uint64(r.Start))%uint64(r.Step) == 0
+	}
+	if i.Value > r.Start || i.Value <= r.Stop {
+		return false
+	}
+	return (uint64(r.Start)-uint64(i.Value))%uint64(-r.Step) == 0
+}
+
+// ReversedIter 返回从最后一个元素开始、从后往前的迭代器
+//
+// 反向的序列的 stop 可能超过 int64 的范围，不能表示为 Range 。
+// 迭代器中的加法按 2^64 取模，结果都在序列中，所以仍然是正确的
+func (r *Range) ReversedIter() *RangeIterator {
+	n := r.Len()
+	if n == 0 {
+		return &RangeIterator{}
+	}
+	last := int64(uint64(r.Start) + uint64(n-1)*uint64(r.Step))
+	return &RangeIterator{next: last, remaining: n, step: -r.Step}
+}
+
+func (r *Range) Iter() Iterator {
+	return &RangeIterator{next: r.Start, remaining: r.Len(), step: r.Step}
+}
+
+type RangeIterator struct {
+	next      int64
+	remaining int64
+	step      int64
+}
+
+func (ri *RangeIterator) Type() ObjectType {
+	return RANGE_ITERATOR_OBJ
+}
+
+func (ri *RangeIterator) TypeIs(objectType ObjectType) bool {
+	return ri.Type() == objectType
+}
+
+func (ri *RangeIterator) TypeNotIs(objectType ObjectType) bool {
+	return ri.Type() != objectType
+}
+
+func (ri *RangeIterator) String() string {
+	return "<range_iterator>"
+}
+
+func (ri *RangeIterator) Iter() Iterator {
+	return ri
+}
+
+func (ri *RangeIterator) Next() Object {
+	if ri.remaining == 0 {
+		return StopIteration
+	}
+	val := ri.next
+	ri.next += ri.step
+	ri.remaining--
+	return NewInteger(val)
+}
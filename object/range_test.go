@@ -0,0 +1,104 @@
+package object
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		start, stop, step int64
+		values            []int64
+	}{
+		{0, 5, 1, []int64{0, 1, 2, 3, 4}},
+		{1, 10, 3, []int64{1, 4, 7}},
+		{10, 0, -3, []int64{10, 7, 4, 1}},
+		{5, 5, 1, nil},
+		{5, 0, 1, nil},
+		{0, 5, -1, nil},
+		{-3, 3, 2, []int64{-3, -1, 1}},
+	}
+	for _, tt := range tests {
+		r, err := NewRange(tt.start, tt.stop, tt.step)
+		if err != nil {
+			t.Fatalf("%d %d %d: %v", tt.start, tt.stop, tt.step, err)
+		}
+		if r.Len() != int64(len(tt.values)) {
+			t.Errorf("%s: len = %d, want %d", r, r.Len(), len(tt.values))
+		}
+		values, _ := Collect(r.Iter())
+		reversed, _ := Collect(r.ReversedIter())
+		if len(values) != len(tt.values) || len(reversed) != len(tt.values) {
+			t.Fatalf("%s: values = %v, reversed = %v, want %v", r, values, reversed, tt.values)
+		}
+		for i, want := range tt.values {
+			if values[i].(*Integer).Value != want {
+				t.Errorf("%s: values[%d] = %s, want %d", r, i, values[i], want)
+			}
+			if got := reversed[len(reversed)-1-i].(*Integer).Value; got != want {
+				t.Errorf("%s: reversed[%d] = %d, want %d", r, len(reversed)-1-i, got, want)
+			}
+			if !r.Has(NewInteger(want)) {
+				t.Errorf("%s: Has(%d) = false", r, want)
+			}
+		}
+		for _, v := range []int64{tt.start - 1, tt.stop, tt.start + 1} {
+			in := false
+			for _, want := range tt.values {
+				in = in || want == v
+			}
+			if r.Has(NewInteger(v)) != in {
+				t.Errorf("%s: Has(%d) = %v, want %v", r, v, !in, in)
+			}
+		}
+	}
+	if _, err := NewRange(0, 1, 0); err == nil {
+		t.Errorf("expected error for zero step")
+	}
+}
+
+// TestRangeOverflow stop - start 超过 int64 范围的序列
+func TestRangeOverflow(t *testing.T) {
+	if _, err := NewRange(-math.MaxInt64, math.MaxInt64, 1); err == nil {
+		t.Errorf("expected error for too many items")
+	}
+	if _, err := NewRange(math.MaxInt64, math.MinInt64, -1); err == nil {
+		t.Errorf("expected error for too many items")
+	}
+
+	tests := []struct {
+		start, stop, step int64
+		values            []int64
+	}{
+		{math.MinInt64, math.MaxInt64, math.MaxInt64, []int64{math.MinInt64, -1, math.MaxInt64 - 1}},
+		{math.MaxInt64, math.MinInt64, math.MinInt64, []int64{math.MaxInt64, -1}},
+		{math.MinInt64, 0, math.MaxInt64, []int64{math.MinInt64, -1}},
+		{math.MaxInt64 - 2, math.MaxInt64, 1, []int64{math.MaxInt64 - 2, math.MaxInt64 - 1}},
+		{math.MinInt64 + 2, math.MinInt64, -1, []int64{math.MinInt64 + 2, math.MinInt64 + 1}},
+	}
+	for _, tt := range tests {
+		r, err := NewRange(tt.start, tt.stop, tt.step)
+		if err != nil {
+			t.Fatalf("%d %d %d: %v", tt.start, tt.stop, tt.step, err)
+		}
+		if r.Len() != int64(len(tt.values)) {
+			t.Fatalf("%s: len = %d, want %d", r, r.Len(), len(tt.values))
+		}
+		values, _ := Collect(r.Iter())
+		reversed, _ := Collect(r.ReversedIter())
+		for i, want := range tt.values {
+			if values[i].(*Integer).Value != want {
+				t.Errorf("%s: values[%d] = %s, want %d", r, i, values[i], want)
+			}
+			if got := reversed[len(reversed)-1-i].(*Integer).Value; got != want {
+				t.Errorf("%s: reversed[%d] = %d, want %d", r, len(reversed)-1-i, got, want)
+			}
+			if !r.Has(NewInteger(want)) {
+				t.Errorf("%s: Has(%d) = false", r, want)
+			}
+		}
+		if tt.step/2 != 0 && r.Has(NewInteger(tt.values[0]+tt.step/2)) {
+			t.Errorf("%s: Has(%d) = true", r, tt.values[0]+tt.step/2)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+package object
+
+import (
+	"bytes"
+	"hash/fnv"
+	"strings"
+)
+
+// SetLike set 和 frozenset 共有的方法
+type SetLike interface {
+	Object
+	// Len 返回元素的个数
+	Len() int
+	// Has 判断集合中是否有 element ，element 不可哈希时返回错误
+	Has(element Object) (bool, *Error)
+	// Elements 按插入顺序返回所有元素
+	Elements() []Object
+}
+
+// setItems 集合的元素保存在 Dict 的键中，值都为 NULL
+type setItems struct {
+	items *Dict
+}
+
+func newSetItems() setItems {
+	return setItems{items: NewDict()}
+}
+
+func (s setItems) Len() int {
+	return s.items.Len()
+}
+
+func (s setItems) Has(element Object) (bool, *Error) {
+	_, ok, err := s.items.Get(element)
+	return ok, err
+}
+
+func (s setItems) Elements() []Object {
+	pairs := s.items.Pairs()
+	elements := make([]Object, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = pair.Key
+	}
+	return elements
+}
+
+func (s setItems) add(element Object) *Error {
+	return s.items.Set(element, NULL)
+}
+
+// Set 可变的集合，和字典一样按插入顺序排列
+type Set struct {
+	*attributeStore
+	setItems
+}
+
+func NewSet() *Set {
+	return &Set{attributeStore: setAttr, setItems: newSetItems()}
+}
+
+func (s *Set) Type() ObjectType {
+	return SET_OBJ
+}
+
+func (s *Set) TypeIs(objectType ObjectType) bool {
+	return s.Type() == objectType
+}
+
+func (s *Set) TypeNotIs(objectType ObjectType) bool {
+	return s.Type() != objectType
+}
+
+func (s *Set) String() string {
+	if s.Len() == 0 {
+		return "set()"
+	}
+	return setString(s)
+}
+
+// Add 添加元素，element 不可哈希时返回错误
+func (s *Set) Add(element Object) *Error {
+	return s.add(element)
+}
+
+// Discard 删除元素，返回元素是否存在
+func (s *Set) Discard(element Object) (bool, *Error) {
+	_, ok, err := s.items.Delete(element)
+	return ok, err
+}
+
+func (s *Set) Iter() Iterator {
+	return NewSetIterator(s)
+}
+
+func (s *Set) GetAttribute(name string) Object {
+	ret := s.attributeStore.get(s, name)
+	if ret != nil {
+		return ret
+	}
+	return attributeError(string(s.Type()), name)
+}
+
+func (s *Set) SetAttribute(name string, _ Object) Object {
+	return attributeError(string(s.Type()), name)
+}
+
+func (s *Set) BinaryOp(operator string, right Object) Object {
+	return setBinaryOp(s, operator, right)
+}
+
+// FrozenSet 不可变的集合，可以作为字典的键和集合的元素
+type FrozenSet struct {
+	*attributeStore
+	setItems
+	hash uint64
+}
+
+// NewFrozenSet 创建不可变集合，重复的元素只保留第一个
+func NewFrozenSet(elements []Object) (*FrozenSet, *Error) {
+	s := &FrozenSet{attributeStore: frozensetAttr, setItems: newSetItems()}
+	for _, element := range elements {
+		if err := s.add(element); err != nil {
+			return nil, err
+		}
+	}
+	// 元素的哈希值打散后异或，结果和元素的顺序无关
+	for _, element := range s.Elements() {
+		s.hash ^= mixHashKey(element.(Hashable).HashKey())
+	}
+	return s, nil
+}
+
+// mixHashKey 把类型和哈希值混合成一个数，避免不同元素异或时相互抵消
+func mixHashKey(key HashKey) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key.Type))
+	x := h.Sum64() ^ key.Value
+	// splitmix64 的混合函数
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func (s *FrozenSet) Type() ObjectType {
+	return FROZENSET_OBJ
+}
+
+func (s *FrozenSet) TypeIs(objectType ObjectType) bool {
+	return s.Type() == objectType
+}
+
+func (s *FrozenSet) TypeNotIs(objectType ObjectType) bool {
+	return s.Type() != objectType
+}
+
+func (s *FrozenSet) String() string {
+	if s.Len() == 0 {
+		return "frozenset()"
+	}
+	return "frozenset(" + setString(s) + ")"
+}
+
+func (s *FrozenSet) HashKey() HashKey {
+	return HashKey{Type: s.Type(), Value: s.hash}
+}
+
+func (s *FrozenSet) Iter() Iterator {
+	return NewSetIterator(s)
+}
+
+func (s *FrozenSet) GetAttribute(name string) Object {
+	ret := s.attributeStore.get(s, name)
+	if ret != nil {
+		return ret
+	}
+	return attributeError(string(s.Type()), name)
+}
+
+func (s *FrozenSet) SetAttribute(name string, _ Object) Object {
+	return attributeError(string(s.Type()), name)
+}
+
+func (s *FrozenSet) BinaryOp(operator string, right Object) Object {
+	return setBinaryOp(s, operator, right)
+}
+
+func setString(s SetLike) string {
+	var out bytes.Buffer
+	var elements []string
+	for _, element := range s.Elements() {
+		elements = append(elements, element.String())
+	}
+	out.WriteString("{")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+// NewSetFromIterable 用可迭代对象中的元素创建集合，frozen 为 true 时创建不可变集合
+func NewSetFromIterable(obj Object, frozen bool) Object {
+	elements, err := IterValues(obj)
+	if err != nil {
+		return err
+	}
+	return newSetLike(frozen, elements)
+}
+
+func newSetLike(frozen bool, elements []Object) Object {
+	if frozen {
+		s, err := NewFrozenSet(elements)
+		if err != nil {
+			return err
+		}
+		return s
+	}
+	s := NewSet()
+	for _, element := range elements {
+		if err := s.Add(element); err != nil {
+			return err
+		}
+	}
+	return s
+}
+
+// toSetLike 集合方法的参数可以是任意可迭代对象，不是集合时转化为集合
+func toSetLike(obj Object) (SetLike, *Error) {
+	if s, ok := obj.(SetLike); ok {
+		return s, nil
+	}
+	s := NewSetFromIterable(obj, false)
+	if err, ok := s.(*Error); ok {
+		return nil, err
+	}
+	return s.(*Set), nil
+}
+
+func setContains(s SetLike, element Object) bool {
+	ok, _ := s.Has(element)
+	return ok
+}
+
+func setUnion(a, b SetLike) []Object {
+	elements := a.Elements()
+	for _, element := range b.Elements() {
+		if !setContains(a, element) {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+func setIntersection(a, b SetLike) []Object {
+	var elements []Object
+	for _, element := range a.Elements() {
+		if setContains(b, element) {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+func setDifference(a, b SetLike) []Object {
+	var elements []Object
+	for _, element := range a.Elements() {
+		if !setContains(b, element) {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+func setSymmetricDifference(a, b SetLike) []Object {
+	return append(setDifference(a, b), setDifference(b, a)...)
+}
+
+// isSubset 判断 a 是否为 b 的子集
+func isSubset(a, b SetLike) bool {
+	if a.Len() > b.Len() {
+		return false
+	}
+	for _, element := range a.Elements() {
+		if !setContains(b, element) {
+			return false
+		}
+	}
+	return true
+}
+
+// setBinaryOp 集合的运算符，结果的类型和左边的集合相同，right 不是集合时返回 nil
+func setBinaryOp(left SetLike, operator string, right Object) Object {
+	other, ok := right.(SetLike)
+	if !ok {
+		return nil
+	}
+	frozen := left.TypeIs(FROZENSET_OBJ)
+	switch operator {
+	case "|":
+		return newSetLike(frozen, setUnion(left, other))
+	case "&":
+		return newSetLike(frozen, setIntersection(left, other))
+	case "-":
+		return newSetLike(frozen, setDifference(left, other))
+	case "^":
+		return newSetLike(frozen, setSymmetricDifference(left, other))
+	case "<=":
+		return NativeBoolToBooleanObject(isSubset(left, other))
+	case "<":
+		return NativeBoolToBooleanObject(left.Len() < other.Len() && isSubset(left, other))
+	case ">=":
+		return NativeBoolToBooleanObject(isSubset(other, left))
+	case ">":
+		return NativeBoolToBooleanObject(left.Len() > other.Len() && isSubset(other, left))
+	case "==":
+		return NativeBoolToBooleanObject(left.Len() == other.Len() && isSubset(left, other))
+	case "!=":
+		return NativeBoolToBooleanObject(left.Len() != other.Len() || !isSubset(left, other))
+	default:
+		return nil
+	}
+}
+
+// ================================
+// set 和 frozenset 对象的内置属性和方法
+// ================================
+
+// setOperationMethod 参数为可迭代对象的集合运算方法
+func setOperationMethod(ctype ObjectType, name string, op func(a, b SetLike) []Object) *BuiltinMethod {
+	return &BuiltinMethod{
+		ctype: ctype,
+		name:  name,
+		Fn: func(obj Object, args ...Object) Object {
+			if len(args) != 1 {
+				return WrongNumberArgument(len(args), 1)
+			}
+			this := obj.(SetLike)
+			other, err := toSetLike(args[0])
+			if err != nil {
+				return err
+			}
+			return newSetLike(this.TypeIs(FROZENSET_OBJ), op(this, other))
+		},
+	}
+}
+
+// setPredicateMethod 参数为可迭代对象，返回 bool 的方法
+func setPredicateMethod(ctype ObjectType, name string, predicate func(a, b SetLike) bool) *BuiltinMethod {
+	return &BuiltinMethod{
+		ctype: ctype,
+		name:  name,
+		Fn: func(obj Object, args ...Object) Object {
+			if len(args) != 1 {
+				return WrongNumberArgument(len(args), 1)
+			}
+			other, err := toSetLike(args[0])
+			if err != nil {
+				return err
+			}
+			return NativeBoolToBooleanObject(predicate(obj.(SetLike), other))
+		},
+	}
+}
+
+// setMethods set 和 frozenset 共有的方法
+func setMethods(ctype ObjectType) map[string]Object {
+	return map[string]Object{
+		// s.has(element)
+		"has": &BuiltinMethod{
+			ctype: ctype,
+			name:  "has",
+			Fn: func(obj Object, args ...Object) Object {
+				if len(args) != 1 {
+					return WrongNumberArgument(len(args), 1)
+				}
+				ok, err := obj.(SetLike).Has(args[0])
+				if err != nil {
+					return err
+				}
+				return NativeBoolToBooleanObject(ok)
+			},
+		},
+		// s.copy()
+		"copy": &BuiltinMethod{
+			ctype: ctype,
+			name:  "copy",
+			Fn: func(obj Object, args ...Object) Object {
+				if len(args) != 0 {
+					return WrongNumberArgument(len(args), 0)
+				}
+				this := obj.(SetLike)
+				return newSetLike(this.TypeIs(FROZENSET_OBJ), this.Elements())
+			},
+		},
+		"union":                setOperationMethod(ctype, "union", setUnion),
+		"intersection":         setOperationMethod(ctype, "intersection", setIntersection),
+		"difference":           setOperationMethod(ctype, "difference", setDifference),
+		"symmetric_difference": setOperationMethod(ctype, "symmetric_difference", setSymmetricDifference),
+		"issubset":             setPredicateMethod(ctype, "issubset", isSubset),
+		"issuperset": setPredicateMethod(ctype, "issuperset", func(a, b SetLike) bool {
+			return isSubset(b, a)
+		}),
+		"isdisjoint": setPredicateMethod(ctype, "isdisjoint", func(a, b SetLike) bool {
+			return len(setIntersection(a, b)) == 0
+		}),
+	}
+}
+
+// 方法中会创建新的集合，在 init 中赋值以避免初始化循环
+var setAttr, frozensetAttr *attributeStore
+
+func init() {
+	frozensetAttr = &attributeStore{attribute: setMethods(FROZENSET_OBJ)}
+	setAttr = &attributeStore{attribute: setMutableMethods()}
+}
+
+// setMutableMethods set 的方法，包括修改集合的方法
+func setMutableMethods() map[string]Object {
+	attribute := setMethods(SET_OBJ)
+	// s.add(element)
+	attribute["add"] = &BuiltinMethod{
+		ctype: SET_OBJ,
+		name:  "add",
+		Fn: func(obj Object, args ...Object) Object {
+			if len(args) != 1 {
+				return WrongNumberArgument(len(args), 1)
+			}
+			this := obj.(*Set)
+			if err := this.Add(args[0]); err != nil {
+				return err
+			}
+			return this
+		},
+	}
+	// s.remove(element) 元素不存在时报错
+	attribute["remove"] = &BuiltinMethod{
+		ctype: SET_OBJ,
+		name:  "remove",
+		Fn: func(obj Object, args ...Object) Object {
+			if len(args) != 1 {
+				return WrongNumberArgument(len(args), 1)
+			}
+			this := obj.(*Set)
+			ok, err := this.Discard(args[0])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return NewError("object not in set")
+			}
+			return this
+		},
+	}
+	// s.discard(element) 元素不存在时什么也不做
+	attribute["discard"] = &BuiltinMethod{
+		ctype: SET_OBJ,
+		name:  "discard",
+		Fn: func(obj Object, args ...Object) Object {
+			if len(args) != 1 {
+				return WrongNumberArgument(len(args), 1)
+			}
+			this := obj.(*Set)
+			if _, err := this.Discard(args[0]); err != nil {
+				return err
+			}
+			return this
+		},
+	}
+	// s.clear()
+	attribute["clear"] = &BuiltinMethod{
+		ctype: SET_OBJ,
+		name:  "clear",
+		Fn: func(obj Object, args ...Object) Object {
+			if len(args) != 0 {
+				return WrongNumberArgument(len(args), 0)
+			}
+			this := obj.(*Set)
+			this.setItems = newSetItems()
+			return this
+		},
+	}
+	return attribute
+}
@@ -0,0 +1,42 @@
+package object
+
+type SetIterator struct {
+	elements []Object
+	index    int
+}
+
+func NewSetIterator(s SetLike) *SetIterator {
+	return &SetIterator{
+		elements: s.Elements(),
+		index:    0,
+	}
+}
+
+func (si *SetIterator) Type() ObjectType {
+	return SET_ITERATOR_OBJ
+}
+
+func (si *SetIterator) TypeIs(objectType ObjectType) bool {
+	return si.Type() == objectType
+}
+
+func (si *SetIterator) TypeNotIs(objectType ObjectType) bool {
+	return si.Type() != objectType
+}
+
+func (si *SetIterator) String() string {
+	return "<set_iterator>"
+}
+
+func (si *SetIterator) Iter() Iterator {
+	return si
+}
+
+func (si *SetIterator) Next() Object {
+	if si.index == len(si.elements) {
+		return StopIteration
+	}
+	element := si.elements[si.index]
+	si.index++
+	return element
+}
@@ -0,0 +1,36 @@
+package object
+
+import "testing"
+
+func TestFrozenSetHashKey(t *testing.T) {
+	newFrozenSet := func(elements ...Object) *FrozenSet {
+		t.Helper()
+		s, err := NewFrozenSet(elements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	a := newFrozenSet(NewInteger(1), NewString("a"), TRUE)
+	b := newFrozenSet(TRUE, NewString("a"), NewInteger(1), NewInteger(1))
+	c := newFrozenSet(NewInteger(1), NewString("b"))
+	if a.HashKey() != b.HashKey() {
+		t.Errorf("frozensets with same elements have different hash keys")
+	}
+	if a.HashKey() == c.HashKey() {
+		t.Errorf("frozensets with different elements have same hash keys")
+	}
+	// 1 和 true 的哈希值相同，类型不同
+	if newFrozenSet(NewInteger(1)).HashKey() == newFrozenSet(TRUE).HashKey() {
+		t.Errorf("frozenset({1}) and frozenset({true}) have same hash keys")
+	}
+	if _, err := NewFrozenSet([]Object{NewList(nil)}); err == nil {
+		t.Errorf("expected unhashable error")
+	}
+
+	d := NewDict()
+	d.Set(a, NewInteger(1))
+	if value, ok, _ := d.Get(b); !ok || value.(*Integer).Value != 1 {
+		t.Errorf("get frozenset key = %v, %v", value, ok)
+	}
+}
@@ -0,0 +1,145 @@
+package object
+
+import "sort"
+
+// typeOrder 不同类型的值比较时的顺序，不在表中的类型不能比较
+var typeOrder = map[ObjectType]int{
+	NULL_OBJ:     0,
+	BOOLEAN_OBJ:  1,
+	INTEGER_OBJ:  2,
+	STRING_OBJ:   3,
+	TUPLE_OBJ:    4,
+	LIST_OBJ:     5,
+	INSTANCE_OBJ: 6,
+}
+
+// typeName 错误信息中的类型名，实例使用类名
+func typeName(obj Object) string {
+	if ins, ok := obj.(*Instance); ok {
+		return ins.ClassName()
+	}
+	return string(obj.Type())
+}
+
+func notOrderable(a, b Object) *Error {
+	return NewError("'<' not supported between instances of '%s' and '%s'", typeName(a), typeName(b))
+}
+
+// Less 判断 a 是否排在 b 前面
+//
+// 相同类型时：整数按数值，false 在 true 前面，字符串按 Unicode 码点逐个比较，
+// 列表、元组按元素逐个比较，实例调用 a.__lt__(b) ；
+// 不同类型时按 null < bool < int < str < tuple < list < 实例 的顺序，
+// 其他类型（字典、集合、函数等）和没有 __lt__ 方法的实例不能比较
+func Less(rt Runtime, a, b Object) (bool, *Error) {
+	if ins, ok := a.(*Instance); ok {
+		if method := ins.GetMethod("__lt__"); method != nil {
+			ret := rt.CallFunction(method, b)
+			if err, ok := ret.(*Error); ok {
+				return false, err
+			}
+			return Truthy(ret), nil
+		}
+	}
+
+	orderA, okA := typeOrder[a.Type()]
+	orderB, okB := typeOrder[b.Type()]
+	if !okA || !okB {
+		return false, notOrderable(a, b)
+	}
+	if orderA != orderB {
+		return orderA < orderB, nil
+	}
+
+	switch a := a.(type) {
+	case *Null:
+		return false, nil
+	case *Boolean:
+		return !a.Value && b.(*Boolean).Value, nil
+	case *Integer:
+		return a.Value < b.(*Integer).Value, nil
+	case *String:
+		// UTF-8 编码的字节顺序和码点顺序相同
+		return a.Value < b.(*String).Value, nil
+	case *Tuple:
+		return lessElements(rt, a.Elements, b.(*Tuple).Elements)
+	case *List:
+		return lessElements(rt, a.Elements, b.(*List).Elements)
+	}
+	return false, notOrderable(a, b)
+}
+
+// lessElements 按字典序比较两个序列：找到第一对不相等的元素比较，都相等时短的在前面
+func lessElements(rt Runtime, a, b []Object) (bool, *Error) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] || Equal(a[i], b[i]) {
+			continue
+		}
+		return Less(rt, a[i], b[i])
+	}
+	return len(a) < len(b), nil
+}
+
+// Sort 返回排好序的新切片，elements 不会被修改
+//
+// 排序是稳定的，比较相等的值保持原来的顺序，reverse 为 true 时也是如此。
+// key 不为 null 时对每个值调用一次 key(x) ，按返回值排序
+func Sort(rt Runtime, elements []Object, key Object, reverse bool) ([]Object, *Error) {
+	keys := elements
+	if key != NULL {
+		keys = make([]Object, len(elements))
+		for i, element := range elements {
+			k := rt.CallFunction(key, element)
+			if err, ok := k.(*Error); ok {
+				return nil, err
+			}
+			keys[i] = k
+		}
+	}
+
+	indexes := make([]int, len(elements))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	var err *Error
+	sort.SliceStable(indexes, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		a, b := keys[indexes[i]], keys[indexes[j]]
+		if reverse {
+			a, b = b, a
+		}
+		less, e := Less(rt, a, b)
+		if e != nil {
+			err = e
+		}
+		return less
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]Object, len(elements))
+	for i, index := range indexes {
+		sorted[i] = elements[index]
+	}
+	return sorted, nil
+}
+
+// SortOptions 解析 sort 和 sorted 的可选参数 key 、reverse ，at 为 key 在参数中的位置，用于错误信息
+func SortOptions(args []Object, at int) (Object, bool, *Error) {
+	key := Object(NULL)
+	reverse := false
+	if len(args) > 0 {
+		key = args[0]
+	}
+	if len(args) > 1 {
+		b, ok := args[1].(*Boolean)
+		if !ok {
+			return nil, false, WrongArgumentTypeAt(args[1].Type(), at+1)
+		}
+		reverse = b.Value
+	}
+	return key, reverse, nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/thinkeridea/go-extend/exunicode/exutf8"
 	"hash/fnv"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -65,6 +66,20 @@ func (s *String) slice(start, end int) string {
 	return exutf8.RuneSubString(s.Value, start, end-start)
 }
 
+// MaxStringLength 重复、填充得到的字符串的最大字节数，超过时返回错误，而不是让 Go 运行时分配内存失败
+const MaxStringLength = 1 << 30
+
+// Repeat 返回 s 重复 n 次组成的新字符串，n 小于等于 0 时为空字符串
+func (s *String) Repeat(n int64) (*String, *Error) {
+	if n <= 0 || len(s.Value) == 0 {
+		return NewString(""), nil
+	}
+	if n > MaxStringLength/int64(len(s.Value)) {
+		return nil, NewError("repeated string is too long")
+	}
+	return NewString(strings.Repeat(s.Value, int(n))), nil
+}
+
 func (s *String) Iter() Iterator {
 	return NewStringIterator(s)
 }
@@ -168,47 +183,82 @@ func endswithMethod(obj Object, args ...Object) Object {
 	return NativeBoolToBooleanObject(s == sub.Value)
 }
 
-// findMethod 返回子字符串第一次出现的位置
-// str.find(sub[, start[, end]])
-func findMethod(obj Object, args ...Object) Object {
+// searchArgs 解析 find 、rfind 、index 的参数 sub[, start[, end]] ，
+// 返回的 start 、end 是转换到字符串范围内的字符下标
+func searchArgs(this *String, args []Object) (*String, int, int, *Error) {
 	argc := len(args)
 	if argc < 1 || argc > 3 {
-		return WrongNumberArgument2(argc, 1, 3)
+		return nil, 0, 0, WrongNumberArgument2(argc, 1, 3)
 	}
-	this := obj.(*String)
-
 	sub, ok := args[0].(*String)
 	if !ok {
-		return WrongArgumentTypeAt(args[0].Type(), 1)
+		return nil, 0, 0, WrongArgumentTypeAt(args[0].Type(), 1)
 	}
-
-	if argc == 1 {
-		byteIndex := strings.Index(this.Value, sub.Value)
-		if byteIndex != -1 {
-			byteIndex = utf8.RuneCountInString(this.Value[:byteIndex])
+	bounds := []int{0, this.Length}
+	for i, arg := range args[1:] {
+		integer, ok := arg.(*Integer)
+		if !ok {
+			return nil, 0, 0, WrongArgumentTypeAt(arg.Type(), i+2)
 		}
-		return NewInteger(int64(byteIndex))
+		bounds[i] = convertRange(int(integer.Value), this.Length)
 	}
+	return sub, bounds[0], bounds[1], nil
+}
 
-	startObj, ok := args[1].(*Integer)
-	if !ok {
-		return WrongArgumentTypeAt(args[1].Type(), 2)
+// search 在字符下标 start 到 end 之间查找 sub ，返回字符下标，找不到时返回 -1 ；
+// last 为 true 时查找最后一次出现的位置
+func (s *String) search(sub string, start, end int, last bool) int {
+	if start > end {
+		return -1
 	}
-	start := int(startObj.Value)
-	end := this.Length
-	if argc == 3 {
-		endObj, ok := args[2].(*Integer)
-		if !ok {
-			return WrongArgumentTypeAt(args[2].Type(), 3)
-		}
-		end = int(endObj.Value)
+	window := s.slice(start, end)
+	var byteIndex int
+	if last {
+		byteIndex = strings.LastIndex(window, sub)
+	} else {
+		byteIndex = strings.Index(window, sub)
 	}
-	s := this.slice(start, end)
-	byteIndex := strings.Index(s, sub.Value)
-	if byteIndex != -1 {
-		byteIndex = utf8.RuneCountInString(s[:byteIndex]) + convertRange(start, this.Length)
+	if byteIndex == -1 {
+		return -1
+	}
+	return utf8.RuneCountInString(window[:byteIndex]) + start
+}
+
+// findMethod 返回子字符串第一次出现的位置
+// str.find(sub[, start[, end]])
+func findMethod(obj Object, args ...Object) Object {
+	this := obj.(*String)
+	sub, start, end, err := searchArgs(this, args)
+	if err != nil {
+		return err
+	}
+	return NewInteger(int64(this.search(sub.Value, start, end, false)))
+}
+
+// rfindMethod 返回子字符串最后一次出现的位置
+// str.rfind(sub[, start[, end]])
+func rfindMethod(obj Object, args ...Object) Object {
+	this := obj.(*String)
+	sub, start, end, err := searchArgs(this, args)
+	if err != nil {
+		return err
+	}
+	return NewInteger(int64(this.search(sub.Value, start, end, true)))
+}
+
+// indexMethod 和 find 相同，但是找不到时报错
+// str.index(sub[, start[, end]])
+func indexMethod(obj Object, args ...Object) Object {
+	this := obj.(*String)
+	sub, start, end, err := searchArgs(this, args)
+	if err != nil {
+		return err
+	}
+	index := this.search(sub.Value, start, end, false)
+	if index == -1 {
+		return NewError("substring not found")
 	}
-	return NewInteger(int64(byteIndex))
+	return NewInteger(int64(index))
 }
 
 // formatMethod 格式化字符串
@@ -385,19 +435,298 @@ func startswithMethod(obj Object, args ...Object) Object {
 	return NativeBoolToBooleanObject(s == sub.Value)
 }
 
+// trimMethod 返回移除字符串前后指定字符的方法，chars 中的每个字符都会被移除，
+// 没有传入 chars 时移除空白字符
+func trimMethod(
+	trim func(s, cutset string) string,
+	trimFunc func(s string, f func(rune) bool) string,
+) BuiltinMethodFunction {
+	return func(obj Object, args ...Object) Object {
+		if len(args) > 1 {
+			return WrongNumberArgument2(len(args), 0, 1)
+		}
+		this := obj.(*String)
+		if len(args) == 0 {
+			return NewString(trimFunc(this.Value, unicode.IsSpace))
+		}
+		arg, ok := args[0].(*String)
+		if !ok {
+			return wrongArgumentType(args[0].Type())
+		}
+		return NewString(trim(this.Value, arg.Value))
+	}
+}
+
 // stripMethod 移除字符串前后指定字符
-// str.strip(chars)
-func stripMethod(obj Object, args ...Object) Object {
+// str.strip([chars])
+var stripMethod = trimMethod(strings.Trim, strings.TrimFunc)
+
+// lstripMethod 移除字符串开头指定字符
+// str.lstrip([chars])
+var lstripMethod = trimMethod(strings.TrimLeft, strings.TrimLeftFunc)
+
+// rstripMethod 移除字符串末尾指定字符
+// str.rstrip([chars])
+var rstripMethod = trimMethod(strings.TrimRight, strings.TrimRightFunc)
+
+// replaceMethod 把子字符串 old 替换为 new ，传入 count 时只替换前 count 个
+// str.replace(old, new[, count])
+//
+// 例子
+//
+//	"a-b-c".replace("-", "+") => "a+b+c"
+//	"a-b-c".replace("-", "", 1) => "ab-c"
+func replaceMethod(obj Object, args ...Object) Object {
+	argc := len(args)
+	if argc < 2 || argc > 3 {
+		return WrongNumberArgument2(argc, 2, 3)
+	}
+	this := obj.(*String)
+	old, ok := args[0].(*String)
+	if !ok {
+		return WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	replacement, ok := args[1].(*String)
+	if !ok {
+		return WrongArgumentTypeAt(args[1].Type(), 2)
+	}
+	count := -1
+	if argc == 3 {
+		countObj, ok := args[2].(*Integer)
+		if !ok {
+			return WrongArgumentTypeAt(args[2].Type(), 3)
+		}
+		count = int(countObj.Value)
+	}
+	return NewString(strings.Replace(this.Value, old.Value, replacement.Value, count))
+}
+
+// splitlinesMethod 按行分割字符串，行尾为 \n 、\r\n 或 \r
+// str.splitlines([keepends])
+//
+// 例子
+//
+//	"a\nb\r\n".splitlines() => ["a", "b"]
+//	"a\nb\r\n".splitlines(true) => ["a\n", "b\r\n"]
+func splitlinesMethod(obj Object, args ...Object) Object {
+	if len(args) > 1 {
+		return WrongNumberArgument2(len(args), 0, 1)
+	}
+	keepends := false
+	if len(args) == 1 {
+		b, ok := args[0].(*Boolean)
+		if !ok {
+			return WrongArgumentTypeAt(args[0].Type(), 1)
+		}
+		keepends = b.Value
+	}
+	this := obj.(*String)
+	value := this.Value
+	elements := []Object{}
+	start := 0
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\n' && value[i] != '\r' {
+			continue
+		}
+		end := i
+		if value[i] == '\r' && i+1 < len(value) && value[i+1] == '\n' {
+			i++
+		}
+		if keepends {
+			end = i + 1
+		}
+		elements = append(elements, NewString(value[start:end]))
+		start = i + 1
+	}
+	if start < len(value) {
+		elements = append(elements, NewString(value[start:]))
+	}
+	return NewList(elements)
+}
+
+// partitionMethod 在 sep 第一次出现的位置把字符串分成三部分，返回 (前面部分, sep, 后面部分) ，
+// 没有找到 sep 时返回 (字符串本身, "", "")
+// str.partition(sep)
+func partitionMethod(obj Object, args ...Object) Object {
 	if len(args) != 1 {
 		return WrongNumberArgument(len(args), 1)
 	}
 	this := obj.(*String)
-	arg, ok := args[0].(*String)
+	sep, ok := args[0].(*String)
 	if !ok {
-		return wrongArgumentType(args[0].Type())
+		return WrongArgumentTypeAt(args[0].Type(), 1)
 	}
-	s := strings.Trim(this.Value, arg.Value)
-	return NewString(s)
+	if sep.Length == 0 {
+		return NewError("empty separator")
+	}
+	before, after, found := strings.Cut(this.Value, sep.Value)
+	if !found {
+		return NewTuple([]Object{this, NewString(""), NewString("")})
+	}
+	return NewTuple([]Object{NewString(before), sep, NewString(after)})
+}
+
+var paddedTooLong = NewError("padded string is too long")
+
+// padArgs 解析 ljust 、rjust 、center 的参数 width[, fillchar] ，fillchar 默认为空格
+func padArgs(args []Object) (int, string, *Error) {
+	argc := len(args)
+	if argc < 1 || argc > 2 {
+		return 0, "", WrongNumberArgument2(argc, 1, 2)
+	}
+	width, ok := args[0].(*Integer)
+	if !ok {
+		return 0, "", WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	if width.Value > MaxStringLength {
+		return 0, "", paddedTooLong
+	}
+	fill := " "
+	if argc == 2 {
+		fillObj, ok := args[1].(*String)
+		if !ok {
+			return 0, "", WrongArgumentTypeAt(args[1].Type(), 2)
+		}
+		if fillObj.Length != 1 {
+			return 0, "", NewError("the fill character must be exactly one character long")
+		}
+		fill = fillObj.Value
+	}
+	return int(width.Value), fill, nil
+}
+
+// padMethod 返回用填充字符把字符串补齐到 width 个字符的方法，
+// left 计算左边需要填充的字符数，margin 为需要填充的总字符数
+func padMethod(left func(margin, width int) int) BuiltinMethodFunction {
+	return func(obj Object, args ...Object) Object {
+		width, fill, err := padArgs(args)
+		if err != nil {
+			return err
+		}
+		this := obj.(*String)
+		margin := width - this.Length
+		if margin <= 0 {
+			return this
+		}
+		if margin*len(fill)+len(this.Value) > MaxStringLength {
+			return paddedTooLong
+		}
+		l := left(margin, width)
+		return NewString(strings.Repeat(fill, l) + this.Value + strings.Repeat(fill, margin-l))
+	}
+}
+
+// ljustMethod 左对齐，在右边填充
+// str.ljust(width[, fillchar])
+var ljustMethod = padMethod(func(margin, width int) int {
+	return 0
+})
+
+// rjustMethod 右对齐，在左边填充
+// str.rjust(width[, fillchar])
+var rjustMethod = padMethod(func(margin, width int) int {
+	return margin
+})
+
+// centerMethod 居中，不能平分时和 Python 一样由 width 的奇偶决定多出来的字符在哪边
+// str.center(width[, fillchar])
+var centerMethod = padMethod(func(margin, width int) int {
+	return margin/2 + (margin & width & 1)
+})
+
+// zfillMethod 在左边填充 0 补齐到 width 个字符，正负号保留在最前面
+// str.zfill(width)
+//
+// 例子
+//
+//	"42".zfill(5) => "00042"
+//	"-42".zfill(5) => "-0042"
+func zfillMethod(obj Object, args ...Object) Object {
+	if len(args) != 1 {
+		return WrongNumberArgument(len(args), 1)
+	}
+	width, ok := args[0].(*Integer)
+	if !ok {
+		return WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	if width.Value > MaxStringLength {
+		return paddedTooLong
+	}
+	this := obj.(*String)
+	margin := int(width.Value) - this.Length
+	if margin <= 0 {
+		return this
+	}
+	value := this.Value
+	sign := ""
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		sign, value = value[:1], value[1:]
+	}
+	return NewString(sign + strings.Repeat("0", margin) + value)
+}
+
+// runeClassMethod 返回判断字符串是否非空并且每个字符都满足 is 的方法
+func runeClassMethod(is func(r rune) bool) BuiltinMethodFunction {
+	return func(obj Object, args ...Object) Object {
+		if len(args) != 0 {
+			return WrongNumberArgument(len(args), 0)
+		}
+		this := obj.(*String)
+		if this.Length == 0 {
+			return NativeBoolToBooleanObject(false)
+		}
+		for _, r := range this.Value {
+			if !is(r) {
+				return NativeBoolToBooleanObject(false)
+			}
+		}
+		return NativeBoolToBooleanObject(true)
+	}
+}
+
+// isupperMethod 字符串中至少有一个区分大小写的字符，并且这些字符都是大写时返回 true
+// str.isupper()
+func isupperMethod(obj Object, args ...Object) Object {
+	if len(args) != 0 {
+		return WrongNumberArgument(len(args), 0)
+	}
+	this := obj.(*String)
+	cased := false
+	for _, r := range this.Value {
+		if unicode.IsLower(r) || unicode.IsTitle(r) {
+			return NativeBoolToBooleanObject(false)
+		}
+		if unicode.IsUpper(r) {
+			cased = true
+		}
+	}
+	return NativeBoolToBooleanObject(cased)
+}
+
+// titleMethod 每个单词的首字母大写，其余字母小写，单词为连续的字母
+// str.title()
+//
+// 例子
+//
+//	"hello wORLD".title() => "Hello World"
+//	"they're".title() => "They'Re"
+func titleMethod(obj Object, args ...Object) Object {
+	if len(args) != 0 {
+		return WrongNumberArgument(len(args), 0)
+	}
+	this := obj.(*String)
+	var out strings.Builder
+	out.Grow(len(this.Value))
+	inWord := false
+	for _, r := range this.Value {
+		if inWord {
+			out.WriteRune(unicode.ToLower(r))
+		} else {
+			out.WriteRune(unicode.ToTitle(r))
+		}
+		inWord = unicode.IsLetter(r)
+	}
+	return NewString(out.String())
 }
 
 func upperMethod(obj Object, args ...Object) Object {
@@ -443,6 +772,16 @@ func init() {
 				name:  "find",
 				Fn:    findMethod,
 			},
+			"rfind": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "rfind",
+				Fn:    rfindMethod,
+			},
+			"index": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "index",
+				Fn:    indexMethod,
+			},
 			"format": &BuiltinMethod{
 				ctype: STRING_OBJ,
 				name:  "format",
@@ -468,6 +807,23 @@ func init() {
 					return NativeBoolToBooleanObject(true)
 				},
 			},
+			// str.isalpha() -> bool
+			"isalpha": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "isalpha",
+				Fn:    runeClassMethod(unicode.IsLetter),
+			},
+			// str.isspace() -> bool
+			"isspace": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "isspace",
+				Fn:    runeClassMethod(unicode.IsSpace),
+			},
+			"isupper": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "isupper",
+				Fn:    isupperMethod,
+			},
 			"join": &BuiltinMethod{
 				ctype: STRING_OBJ,
 				name:  "join",
@@ -478,11 +834,46 @@ func init() {
 				name:  "lower",
 				Fn:    lowerMethod,
 			},
+			"ljust": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "ljust",
+				Fn:    ljustMethod,
+			},
+			"rjust": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "rjust",
+				Fn:    rjustMethod,
+			},
+			"center": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "center",
+				Fn:    centerMethod,
+			},
+			"zfill": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "zfill",
+				Fn:    zfillMethod,
+			},
+			"partition": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "partition",
+				Fn:    partitionMethod,
+			},
+			"replace": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "replace",
+				Fn:    replaceMethod,
+			},
 			"split": &BuiltinMethod{
 				ctype: STRING_OBJ,
 				name:  "split",
 				Fn:    splitMethod,
 			},
+			"splitlines": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "splitlines",
+				Fn:    splitlinesMethod,
+			},
 			"startswith": &BuiltinMethod{
 				ctype: STRING_OBJ,
 				name:  "startswith",
@@ -493,6 +884,21 @@ func init() {
 				name:  "strip",
 				Fn:    stripMethod,
 			},
+			"lstrip": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "lstrip",
+				Fn:    lstripMethod,
+			},
+			"rstrip": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "rstrip",
+				Fn:    rstripMethod,
+			},
+			"title": &BuiltinMethod{
+				ctype: STRING_OBJ,
+				name:  "title",
+				Fn:    titleMethod,
+			},
 			"upper": &BuiltinMethod{
 				ctype: STRING_OBJ,
 				name:  "upper",
@@ -20,12 +20,66 @@ func convertRange(i, n int) int {
 	}
 }
 
+// Truthy 判断对象作为条件时是否为真：false 、null 、0 、空字符串和空容器为假，其他为真
+func Truthy(obj Object) bool {
+	switch obj := obj.(type) {
+	case *Boolean:
+		return obj.Value
+	case *Null:
+		return false
+	case *Integer:
+		return obj.Value != 0
+	case *String:
+		return len(obj.Value) != 0
+	case *List:
+		return len(obj.Elements) != 0
+	case *Tuple:
+		return len(obj.Elements) != 0
+	case *Dict:
+		return obj.Len() != 0
+	case SetLike:
+		return obj.Len() != 0
+	case *Range:
+		return obj.Len() != 0
+	}
+	return true
+}
+
+// Equal 比较两个对象的值，列表、元组、字典、集合比较其中的元素
+func Equal(a, b Object) bool {
+	return equal(a, b)
+}
+
 func equal(a, b Object) bool {
-	visited := make(map[Object]bool)
+	visited := make(map[objectPair]bool)
 	return recursiveEqual(a, b, visited)
 }
 
-func recursiveEqual(a, b Object, visited map[Object]bool) bool {
+// objectPair 正在比较的一对容器
+type objectPair struct {
+	a, b Object
+}
+
+// enterPair 开始比较容器 a 、b ，返回 false 表示这一对已经在比较中（存在循环引用），
+// 此时认为它们相等，由外层的比较决定结果
+func enterPair(a, b Object, visited map[objectPair]bool) bool {
+	pair := objectPair{a, b}
+	if visited[pair] {
+		return false
+	}
+	visited[pair] = true
+	return true
+}
+
+func recursiveEqual(a, b Object, visited map[objectPair]bool) bool {
+	if a == b {
+		return true
+	}
+	// set 和 frozenset 的元素相同时相等
+	if as, ok := a.(SetLike); ok {
+		bs, ok := b.(SetLike)
+		return ok && as.Len() == bs.Len() && isSubset(as, bs)
+	}
 	if a.TypeNotIs(b.Type()) {
 		return false
 	}
@@ -37,46 +91,24 @@ func recursiveEqual(a, b Object, visited map[Object]bool) bool {
 		bt := b.(*String)
 		return at.Value == bt.Value
 	case *List:
-		bt := b.(*List)
-		if len(at.Elements) != len(bt.Elements) {
-			return false
-		}
-		va := visited[a]
-		vb := visited[b]
-		if va && vb {
-			return true
-		} else if va || vb {
-			return false
-		}
-		visited[a] = true
-		visited[b] = true
-		for i, ae := range at.Elements {
-			be := bt.Elements[i]
-			if !recursiveEqual(ae, be, visited) {
-				return false
-			}
-		}
-		return true
+		return elementsEqual(a, b, at.Elements, b.(*List).Elements, visited)
+	case *Tuple:
+		return elementsEqual(a, b, at.Elements, b.(*Tuple).Elements, visited)
 	case *Dict:
 		bt := b.(*Dict)
-		if len(at.Pairs) != len(bt.Pairs) {
+		if at.Len() != bt.Len() {
 			return false
 		}
-		va := visited[a]
-		vb := visited[b]
-		if va && vb {
+		if !enterPair(a, b, visited) {
 			return true
-		} else if va || vb {
-			return false
 		}
-		visited[a] = true
-		visited[b] = true
-		for key, ap := range at.Pairs {
-			bp, ok := bt.Pairs[key]
-			if !ok || ap.Key != bp.Key {
+		defer delete(visited, objectPair{a, b})
+		for _, ap := range at.Pairs() {
+			bv, ok, _ := bt.Get(ap.Key)
+			if !ok {
 				return false
 			}
-			if !recursiveEqual(ap.Value, bp.Value, visited) {
+			if !recursiveEqual(ap.Value, bv, visited) {
 				return false
 			}
 		}
@@ -86,6 +118,23 @@ func recursiveEqual(a, b Object, visited map[Object]bool) bool {
 	}
 }
 
+// elementsEqual 逐个比较列表、元组 a 、b 的元素 ae 、be
+func elementsEqual(a, b Object, ae, be []Object, visited map[objectPair]bool) bool {
+	if len(ae) != len(be) {
+		return false
+	}
+	if !enterPair(a, b, visited) {
+		return true
+	}
+	defer delete(visited, objectPair{a, b})
+	for i, e := range ae {
+		if !recursiveEqual(e, be[i], visited) {
+			return false
+		}
+	}
+	return true
+}
+
 // objectString 递归地将对象转化为字符串
 func objectString(obj Object, visited map[Object]bool) string {
 	switch obj := obj.(type) {
@@ -137,7 +186,7 @@ func objectString(obj Object, visited map[Object]bool) string {
 		var out bytes.Buffer
 
 		var elements []string
-		for _, pair := range obj.Pairs {
+		for _, pair := range obj.Pairs() {
 			vs := objectString(pair.Value, visited)
 			elements = append(elements, fmt.Sprintf("%s: %s", pair.Key.String(), vs))
 		}
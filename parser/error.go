@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"weilang/diagnostic"
+	"weilang/token"
+)
+
+// SyntaxError 语法错误，Start End 为出错 token 的起止位置（行列从 0 开始）
+type SyntaxError struct {
+	Filename string
+	Start    token.Position
+	End      token.Position
+	Msg      string
+	// line 出错的源码行，用来标注错误位置
+	line string
+}
+
+func (e *SyntaxError) Error() string {
+	// 标注错误的位置
+	template := `
+File "%s", line %d
+  %s
+  %s
+SyntaxError: %s`
+	return fmt.Sprintf(template, e.Filename, e.Start.Line+1, e.line, strRjust("^", e.Start.Column+1), e.Msg)
+}
+
+// Diagnostic 转换为结构化的诊断信息
+func (e *SyntaxError) Diagnostic() diagnostic.Diagnostic {
+	return diagnostic.Diagnostic{
+		Severity: diagnostic.SeverityError,
+		Code:     diagnostic.CodeSyntaxError,
+		Message:  e.Msg,
+		Filename: e.Filename,
+		Start:    e.Start,
+		End:      e.End,
+	}
+}
+
+// ErrorList 解析过程中遇到的所有语法错误，按出现的顺序排列
+type ErrorList []*SyntaxError
+
+func (l ErrorList) Error() string {
+	var out strings.Builder
+	for _, e := range l {
+		out.WriteString(e.Error())
+	}
+	return out.String()
+}
+
+// Diagnostics 转换为结构化的诊断信息
+func (l ErrorList) Diagnostics() []diagnostic.Diagnostic {
+	var diagnostics []diagnostic.Diagnostic
+	for _, e := range l {
+		diagnostics = append(diagnostics, e.Diagnostic())
+	}
+	return diagnostics
+}
+
+// add 添加语法错误，同一位置只保留第一个错误，避免恢复解析时产生重复的错误
+func (l *ErrorList) add(e *SyntaxError) {
+	if n := len(*l); n > 0 {
+		last := (*l)[n-1]
+		if last.Start.Equal(&e.Start) {
+			return
+		}
+	}
+	*l = append(*l, e)
+}
+
+func strRjust(s string, n int) string {
+	if len(s) >= n {
+		return s
+	}
+	return strings.Repeat(" ", n-len(s)) + s
+}
@@ -205,6 +205,18 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a + 1 in b | c",
+			"((a + 1) in (b | c))",
+		},
+		{
+			"not a not in b and c in d",
+			"((not(a not in b)) and (c in d))",
+		},
+		{
+			"a in b == c",
+			"((a in b) == c)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -272,6 +284,43 @@ func TestFunctionLiteralParsing(t *testing.T) {
 
 }
 
+func TestYieldExpression(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		isGenerator bool
+	}{
+		{"fn() { yield 1 }", "(yield 1)", true},
+		{"fn() { yield }", "(yield)", true},
+		{"fn() { var a = yield b + 1 }", "var a = (yield (b + 1));", true},
+		{"fn() { f(yield, yield a) }", "f((yield), (yield a))", true},
+		{"fn() { return 1 }", "return 1;", false},
+		// 嵌套函数中的 yield 属于嵌套的函数
+		{"fn() { var g = fn() { yield 1 } }", "var g = fn() {\n(yield 1)\n};", false},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program, err := p.ParseProgram()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+		}
+		body := function.Body.Statements[0].String()
+		if body != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, body)
+		}
+		if function.IsGenerator != tt.isGenerator {
+			t.Errorf("%s: IsGenerator expected=%v, got=%v", tt.input, tt.isGenerator, function.IsGenerator)
+		}
+	}
+}
+
 func TestParsingEmptyArrayLiterals(t *testing.T) {
 	input := "[]"
 
@@ -318,6 +367,70 @@ func TestParsingArrayLiterals(t *testing.T) {
 	testBinaryOpExpression(t, array.Elements[2], 3, "+", 3)
 }
 
+func TestParsingSetLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"{1}", 1},
+		{"{1,}", 1},
+		{"{1, 2 * 2, 3 + 3}", 3},
+		{"{1,\n2,\n3,\n}", 3},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program, err := p.ParseProgram()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		set, ok := stmt.Expression.(*ast.SetLiteral)
+		if !ok {
+			t.Fatalf("exp not ast.SetLiteral. got=%T", stmt.Expression)
+		}
+		if len(set.Elements) != tt.expected {
+			t.Fatalf("len(set.Elements) not %d. got=%d", tt.expected, len(set.Elements))
+		}
+		testIntegerLiteral(t, set.Elements[0], 1)
+	}
+}
+
+func TestParsingComprehensions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[x * 2 for x in items if x > 0]", "[(x * 2) for x in items if (x > 0)]"},
+		{"[v for i, v in l]", "[v for i, v in l]"},
+		{"{k: v for k, v in d}", "{k:v for k, v in d}"},
+		{"{x for x in s}", "{x for x in s}"},
+		{"{x % 3 for x in range(10) if x and y}", "{(x % 3) for x in range(10) if (x and y)}"},
+		{"[\n  x\n  for x in l\n  if x\n]", "[x for x in l if x]"},
+		{"[[y for y in x] for x in l]", "[[y for y in x] for x in l]"},
+		{"[x in l for x in m]", "[(x in l) for x in m]"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program, err := p.ParseProgram()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		switch stmt.Expression.(type) {
+		case *ast.ListComprehension, *ast.SetComprehension, *ast.DictComprehension:
+		default:
+			t.Fatalf("%s: not a comprehension. got=%T", tt.input, stmt.Expression)
+		}
+		if stmt.Expression.String() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, stmt.Expression.String())
+		}
+	}
+}
+
 func TestParsingEmptyDictLiteral(t *testing.T) {
 	input := "{}"
 
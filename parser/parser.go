@@ -1,9 +1,9 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
-	"strings"
 
 	"weilang/ast"
 	"weilang/lexer"
@@ -20,8 +20,13 @@ import (
 type dumpInfo struct {
 	parenCount int
 	whileStack []int
-	index      int
-	token      token.Token
+	// functionDepth 进入的函数定义层数，用于检查 yield 是否在函数中
+	functionDepth int
+	braceDepth    int
+	index         int
+	token         token.Token
+	prevEnd       token.Position
+	errorCount    int
 }
 
 type Parser struct {
@@ -47,6 +52,14 @@ type Parser struct {
 	//   }
 	// }
 	whileStack []int
+	// functionDepth 进入的函数定义层数，为 0 时不能使用 yield
+	functionDepth int
+	// braceDepth 已经读过但还没有闭合的 "{" 数量，出错恢复时用来跳过语句中未闭合的语句块
+	braceDepth int
+	// errors 已经恢复过的语法错误
+	errors ErrorList
+	// prevEnd 上一个 token （不包括分号）的结束位置，也就是已经解析的节点的结束位置
+	prevEnd token.Position
 }
 
 func New(l *lexer.Lexer) *Parser {
@@ -67,8 +80,17 @@ func New(l *lexer.Lexer) *Parser {
 2023-10-21 14:47:42 表达式解析决定改用《用Go语言自制解释器》中的解析方法
 */
 
+// ParseProgram 解析整个文件，遇到语法错误时会跳到下一条语句继续解析，
+// 有错误时返回 ErrorList ，包含所有的语法错误
 func (p *Parser) ParseProgram() (*ast.Program, error) {
-	return p.program()
+	program, err := p.program()
+	if err != nil {
+		p.addError(err)
+	}
+	if len(p.errors) > 0 {
+		return nil, p.errors
+	}
+	return program, nil
 }
 
 // program ::= (statement)* EOF
@@ -78,9 +100,11 @@ func (p *Parser) program() (*ast.Program, error) {
 	program.Statements = []ast.Statement{}
 
 	for !p.currTokenIs(token.EOF) {
+		info := p.dump()
 		stmt, err := p.statement()
 		if err != nil {
-			return nil, err
+			p.recover(info, err, false)
+			continue
 		}
 		program.Statements = append(program.Statements, stmt)
 	}
@@ -93,6 +117,60 @@ func (p *Parser) program() (*ast.Program, error) {
 	return program, nil
 }
 
+// recover 记录语句的语法错误，然后跳到下一条语句的开头（ panic-mode 错误恢复）
+// 语句以分号（包括行末自动插入的分号）结束，或者是语句块的最后一条语句（后面跟着 "}"）
+// 跳过的 token 中如果有成对的 {} ，会把整个语句块跳过，语句中在出错前打开的 "{" 也会跳到对应的 "}" 后面；
+// 跳过的非法 token 也会记录为错误
+// inBlock 为 false 时，多余的 "}" 也会被跳过，保证解析可以继续
+func (p *Parser) recover(info *dumpInfo, err error, inBlock bool) {
+	p.addError(err)
+	// 语句可能在括号或者 while 语句块中出错，恢复到语句开始时的状态
+	p.parenCount = info.parenCount
+	p.whileStack = info.whileStack
+	p.functionDepth = info.functionDepth
+
+	depth := p.braceDepth - info.braceDepth
+	defer func() { p.braceDepth = info.braceDepth }()
+	for {
+		switch p.currToken.Type {
+		case token.EOF:
+			return
+		case token.ILLEGAL:
+			// 跳过的非法 token 也要报告
+			p.addError(p.syntaxError(p.currToken.Literal))
+		case token.SEMICOLON:
+			if depth == 0 {
+				p.nextToken()
+				return
+			}
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				if !inBlock {
+					p.nextToken()
+					// "}" 后面自动插入的分号也要跳过，否则会被当成下一条语句
+					if p.currTokenIs(token.SEMICOLON) {
+						p.nextToken()
+					}
+				}
+				return
+			}
+			depth--
+		}
+		p.nextToken()
+	}
+}
+
+// addError 记录语法错误，不是 SyntaxError 的错误使用当前 token 的位置
+func (p *Parser) addError(err error) {
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		syntaxErr = p.syntaxError(err.Error()).(*SyntaxError)
+	}
+	p.errors.add(syntaxErr)
+}
+
 // block_statement ::= statement_block ";"
 // statement_block ::= "{" (statement)* "}"
 func (p *Parser) blockStatement() (*ast.BlockStatement, error) {
@@ -115,10 +193,12 @@ func (p *Parser) statementBlock() (*ast.BlockStatement, error) {
 		return nil, err
 	}
 	block := &ast.BlockStatement{Location: location, Token: tok}
-	for !p.currTokenIs(token.RBRACE) {
+	for !p.currTokenIn(token.RBRACE, token.EOF) {
+		info := p.dump()
 		stmt, err := p.statement()
 		if err != nil {
-			return nil, err
+			p.recover(info, err, true)
+			continue
 		}
 		block.Statements = append(block.Statements, stmt)
 	}
@@ -126,6 +206,8 @@ func (p *Parser) statementBlock() (*ast.BlockStatement, error) {
 	if err != nil {
 		return nil, err
 	}
+	// 语句块的结束位置为 "}"
+	location.End = p.prevEnd
 
 	return block, nil
 }
@@ -155,6 +237,16 @@ func (p *Parser) isStatementEnd() bool {
 // | function_define_statement
 // | class_define_statement
 func (p *Parser) statement() (ast.Statement, error) {
+	stmt, err := p.doStatement()
+	if err != nil {
+		return nil, err
+	}
+	// 语句的结束位置，不包括结尾的分号
+	stmt.GetFileLocation().End = p.prevEnd
+	return stmt, nil
+}
+
+func (p *Parser) doStatement() (ast.Statement, error) {
 	p.skipNewline()
 	defer func() { p.skipNewline() }()
 	info := p.dump()
@@ -168,12 +260,11 @@ func (p *Parser) statement() (ast.Statement, error) {
 	case token.RETURN:
 		return p.returnStatement()
 	case token.IDENT:
-		stmt, err := p.expressionStatement()
-		if err == nil {
-			return stmt, nil
-		}
-		p.restore(info)
-		return p.assignStatement()
+		return p.either(info, func() (ast.Statement, error) {
+			return p.expressionStatement()
+		}, func() (ast.Statement, error) {
+			return p.assignStatement()
+		})
 	case token.IF:
 		return p.ifStatement()
 	case token.WHILE:
@@ -183,19 +274,17 @@ func (p *Parser) statement() (ast.Statement, error) {
 	case token.BREAK:
 		return p.breakStatement()
 	case token.WEI:
-		stmt, err := p.weiExportStatement()
-		if err == nil {
-			return stmt, nil
-		}
-		p.restore(info)
-		return p.expressionStatement()
+		return p.either(info, func() (ast.Statement, error) {
+			return p.weiExportStatement()
+		}, func() (ast.Statement, error) {
+			return p.expressionStatement()
+		})
 	case token.FUNCTION:
-		stmt, err := p.expressionStatement()
-		if err == nil {
-			return stmt, nil
-		}
-		p.restore(info)
-		return p.functionDefineStatement()
+		return p.either(info, func() (ast.Statement, error) {
+			return p.expressionStatement()
+		}, func() (ast.Statement, error) {
+			return p.functionDefineStatement()
+		})
 	case token.CLASS:
 		return p.classDefineStatement()
 	default:
@@ -203,6 +292,37 @@ func (p *Parser) statement() (ast.Statement, error) {
 	}
 }
 
+// either 先按 first 解析，失败时回溯到 info 再按 second 解析
+// 两种都失败时返回解析得更远的那一次的错误，解析器的状态也停在那一次出错的位置，位置相同时使用 second 的错误
+func (p *Parser) either(info *dumpInfo, first, second func() (ast.Statement, error)) (ast.Statement, error) {
+	stmt, firstErr := first()
+	if firstErr == nil {
+		return stmt, nil
+	}
+	firstState, firstErrors := p.dump(), append(ErrorList{}, p.errors...)
+	p.restore(info)
+	stmt, secondErr := second()
+	if secondErr == nil {
+		return stmt, nil
+	}
+	if !errorAfter(firstErr, secondErr) {
+		return nil, secondErr
+	}
+	p.errors = firstErrors
+	p.restore(firstState)
+	return nil, firstErr
+}
+
+// errorAfter 判断语法错误 a 的位置是否在 b 之后
+func errorAfter(a, b error) bool {
+	var errA, errB *SyntaxError
+	if !errors.As(a, &errA) || !errors.As(b, &errB) {
+		return false
+	}
+	return errA.Start.Line > errB.Start.Line ||
+		errA.Start.Line == errB.Start.Line && errA.Start.Column > errB.Start.Column
+}
+
 // class_define_statement ::= "class" IDENT ["(" IDENT ")"] class_block_statement
 func (p *Parser) classDefineStatement() (*ast.ClassDefineStatement, error) {
 	location := p.currFileLocation()
@@ -212,6 +332,7 @@ func (p *Parser) classDefineStatement() (*ast.ClassDefineStatement, error) {
 		return nil, err
 	}
 	name := p.currToken.Literal
+	nameLocation := p.currFileLocation()
 	err = p.eat(token.IDENT)
 	if err != nil {
 		return nil, err
@@ -239,11 +360,12 @@ func (p *Parser) classDefineStatement() (*ast.ClassDefineStatement, error) {
 		return nil, p.expectError(token.NEWLINE)
 	}
 	stmt := &ast.ClassDefineStatement{
-		Location: location,
-		Token:    tok,
-		Name:     name,
-		Parent:   parent,
-		Body:     body,
+		Location:     location,
+		Token:        tok,
+		Name:         name,
+		NameLocation: nameLocation,
+		Parent:       parent,
+		Body:         body,
 	}
 	return stmt, nil
 }
@@ -272,11 +394,21 @@ func (p *Parser) classBlockStatement() (*ast.ClassBlockStatement, error) {
 	if err != nil {
 		return nil, err
 	}
+	location.End = p.prevEnd
 	return block, nil
 }
 
 // class_statement ::= class_variable_declaration_statement | class_method_define_statement
 func (p *Parser) classStatement() (ast.Statement, error) {
+	stmt, err := p.doClassStatement()
+	if err != nil {
+		return nil, err
+	}
+	stmt.GetFileLocation().End = p.prevEnd
+	return stmt, nil
+}
+
+func (p *Parser) doClassStatement() (ast.Statement, error) {
 	p.skipNewline()
 	defer func() { p.skipNewline() }()
 	//info := p.dump()
@@ -358,6 +490,7 @@ func (p *Parser) classMethodDefineStatement() (*ast.ClassMethodDefineStatement,
 		class = true
 	}
 	name := p.currToken.Literal
+	nameLocation := p.currFileLocation()
 	err = p.eat(token.IDENT)
 	if err != nil {
 		return nil, err
@@ -376,7 +509,7 @@ func (p *Parser) classMethodDefineStatement() (*ast.ClassMethodDefineStatement,
 	if err != nil {
 		return nil, err
 	}
-	block, err := p.statementBlock()
+	block, err := p.functionBody()
 	if err != nil {
 		return nil, err
 	}
@@ -388,11 +521,13 @@ func (p *Parser) classMethodDefineStatement() (*ast.ClassMethodDefineStatement,
 		Token:    tok,
 		Class:    class,
 		Function: &ast.FunctionLiteral{
-			Location:   location,
-			Token:      tok,
-			Name:       name,
-			Parameters: params,
-			Body:       block,
+			Location:     location,
+			Token:        tok,
+			Name:         name,
+			NameLocation: nameLocation,
+			Parameters:   params,
+			Body:         block,
+			IsGenerator:  containsYield(block),
 		},
 	}
 	return stmt, nil
@@ -407,6 +542,7 @@ func (p *Parser) functionDefineStatement() (*ast.FunctionDefineStatement, error)
 		return nil, err
 	}
 	name := p.currToken.Literal
+	nameLocation := p.currFileLocation()
 	err = p.eat(token.IDENT)
 	if err != nil {
 		return nil, err
@@ -426,7 +562,7 @@ func (p *Parser) functionDefineStatement() (*ast.FunctionDefineStatement, error)
 		return nil, err
 	}
 	p.whileStack = append(p.whileStack, 0)
-	block, err := p.statementBlock()
+	block, err := p.functionBody()
 	if err != nil {
 		return nil, err
 	}
@@ -438,11 +574,13 @@ func (p *Parser) functionDefineStatement() (*ast.FunctionDefineStatement, error)
 		Location: location,
 		Token:    tok,
 		Function: &ast.FunctionLiteral{
-			Location:   location,
-			Token:      tok,
-			Name:       name,
-			Parameters: paramters,
-			Body:       block,
+			Location:     location,
+			Token:        tok,
+			Name:         name,
+			NameLocation: nameLocation,
+			Parameters:   paramters,
+			Body:         block,
+			IsGenerator:  containsYield(block),
 		},
 	}
 	return fs, nil
@@ -703,7 +841,6 @@ func (p *Parser) primary() (ast.Expression, error) {
 	}
 	for p.currTokenIn(token.LBRACKET, token.DOT) {
 		tok = p.currToken
-		location := p.currFileLocation()
 		switch p.currToken.Type {
 		case token.LBRACKET:
 			p.nextToken()
@@ -711,16 +848,16 @@ func (p *Parser) primary() (ast.Expression, error) {
 			if err != nil {
 				return nil, err
 			}
+			err = p.eat(token.RBRACKET)
+			if err != nil {
+				return nil, err
+			}
 			expr = &ast.SubscriptionExpression{
-				Location: location,
+				Location: p.locationFrom(expr.GetFileLocation()),
 				Token:    tok,
 				Left:     expr,
 				Index:    index,
 			}
-			err = p.eat(token.RBRACKET)
-			if err != nil {
-				return nil, err
-			}
 		case token.DOT:
 			p.nextToken()
 			ident, err := p.ident()
@@ -728,7 +865,7 @@ func (p *Parser) primary() (ast.Expression, error) {
 				return nil, err
 			}
 			expr = &ast.AttributeExpression{
-				Location:  location,
+				Location:  p.locationFrom(expr.GetFileLocation()),
 				Token:     tok,
 				Left:      expr,
 				Attribute: ident,
@@ -917,16 +1054,42 @@ https://docs.python.org/3/reference/expressions.html#operator-precedence
 
 // expression 解析表达式
 //
-// expression ::= orExpression
+// expression ::= yield_expression | or_expression
 func (p *Parser) expression() (ast.Expression, error) {
+	if p.currTokenIs(token.YIELD) {
+		return p.yieldExpression()
+	}
 	return p.orExpression()
 }
 
+// yieldExpression 解析 yield 表达式，只能在函数中使用，后面的表达式可以省略
+//
+// yield_expression ::= "yield" [expression]
+func (p *Parser) yieldExpression() (ast.Expression, error) {
+	if p.functionDepth == 0 {
+		return nil, p.syntaxError("'yield' outside function")
+	}
+	location := p.currFileLocation()
+	tok := p.currToken
+	_ = p.eat(token.YIELD)
+	expr := &ast.YieldExpression{Location: location, Token: tok}
+	switch p.currToken.Type {
+	case token.SEMICOLON, token.RBRACE, token.EOF, token.RPAREN, token.RBRACKET, token.COMMA:
+	default:
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		expr.Value = value
+	}
+	expr.Location = p.locationFrom(location)
+	return expr, nil
+}
+
 // orExpression 解析 or 逻辑表达式
 //
 // or_expression ::= and_expression ("or" and_expression)*
 func (p *Parser) orExpression() (ast.Expression, error) {
-	location := p.currFileLocation()
 	tok := p.currToken
 	expr, err := p.andExpression()
 	if err != nil {
@@ -940,7 +1103,7 @@ func (p *Parser) orExpression() (ast.Expression, error) {
 			return nil, err
 		}
 		expr = &ast.BinaryOpExpression{
-			Location: location,
+			Location: p.locationFrom(expr.GetFileLocation()),
 			Token:    tok,
 			Left:     expr,
 			Operator: op,
@@ -954,7 +1117,6 @@ func (p *Parser) orExpression() (ast.Expression, error) {
 //
 // and_expression ::= not_expression ("and" not_expression)*
 func (p *Parser) andExpression() (ast.Expression, error) {
-	location := p.currFileLocation()
 	tok := p.currToken
 	expr, err := p.notExpression()
 	if err != nil {
@@ -968,7 +1130,7 @@ func (p *Parser) andExpression() (ast.Expression, error) {
 			return nil, err
 		}
 		expr = &ast.BinaryOpExpression{
-			Location: location,
+			Location: p.locationFrom(expr.GetFileLocation()),
 			Token:    tok,
 			Left:     expr,
 			Operator: op,
@@ -994,7 +1156,7 @@ func (p *Parser) notExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	expr := &ast.UnaryExpression{
-		Location: location,
+		Location: p.locationFrom(location),
 		Token:    tok,
 		Operator: op,
 		Operand:  right,
@@ -1004,7 +1166,8 @@ func (p *Parser) notExpression() (ast.Expression, error) {
 
 // comparisonExpression 解析关系表达式
 //
-// comparison_expression ::= bitwise_xor_expression (("<" | "<=" | ">" | ">=" | "!=" | "==" ) bitwise_xor_expression)*
+// comparison_expression ::= bitwise_or_expression (comparison_operator bitwise_or_expression)*
+// comparison_operator   ::= "<" | "<=" | ">" | ">=" | "!=" | "==" | "in" | "not" "in"
 func (p *Parser) comparisonExpression() (ast.Expression, error) {
 	expr, err := p.bitwiseOrExpression()
 	if err != nil {
@@ -1012,19 +1175,22 @@ func (p *Parser) comparisonExpression() (ast.Expression, error) {
 	}
 	optypes := []token.TokenType{
 		token.LESS_THAN, token.LESS_EQUAL_THAN, token.GREAT_THAN, token.GREAT_EQUAL_THAN,
-		token.NOT_EQ, token.EQ,
+		token.NOT_EQ, token.EQ, token.IN,
 	}
-	for p.currTokenIn(optypes...) {
-		location := p.currFileLocation()
+	for p.currTokenIn(optypes...) || p.currTokenIs(token.NOT) && p.peekTokenIs(token.IN) {
 		tok := p.currToken
 		op := p.currToken.Literal
+		if p.currTokenIs(token.NOT) {
+			_ = p.eat(token.NOT)
+			op = "not in"
+		}
 		_ = p.eatIn(optypes...)
 		right, err := p.bitwiseOrExpression()
 		if err != nil {
 			return nil, err
 		}
 		expr = &ast.BinaryOpExpression{
-			Location: location,
+			Location: p.locationFrom(expr.GetFileLocation()),
 			Token:    tok,
 			Left:     expr,
 			Operator: op,
@@ -1043,7 +1209,6 @@ func (p *Parser) bitwiseOrExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	for p.currTokenIs(token.BITWISE_OR) {
-		location := p.currFileLocation()
 		tok := p.currToken
 		op := p.currToken.Literal
 		_ = p.eat(token.BITWISE_OR)
@@ -1052,7 +1217,7 @@ func (p *Parser) bitwiseOrExpression() (ast.Expression, error) {
 			return nil, err
 		}
 		expr = &ast.BinaryOpExpression{
-			Location: location,
+			Location: p.locationFrom(expr.GetFileLocation()),
 			Token:    tok,
 			Left:     expr,
 			Operator: op,
@@ -1071,7 +1236,6 @@ func (p *Parser) bitwiseXorExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	for p.currTokenIs(token.BITWISE_XOR) {
-		location := p.currFileLocation()
 		tok := p.currToken
 		op := p.currToken.Literal
 		_ = p.eat(token.BITWISE_XOR)
@@ -1080,7 +1244,7 @@ func (p *Parser) bitwiseXorExpression() (ast.Expression, error) {
 			return nil, err
 		}
 		expr = &ast.BinaryOpExpression{
-			Location: location,
+			Location: p.locationFrom(expr.GetFileLocation()),
 			Token:    tok,
 			Left:     expr,
 			Operator: op,
@@ -1099,7 +1263,6 @@ func (p *Parser) bitwiseAndExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	for p.currTokenIs(token.BITWISE_AND) {
-		location := p.currFileLocation()
 		tok := p.currToken
 		op := p.currToken.Literal
 		_ = p.eat(token.BITWISE_AND)
@@ -1108,7 +1271,7 @@ func (p *Parser) bitwiseAndExpression() (ast.Expression, error) {
 			return nil, err
 		}
 		expr = &ast.BinaryOpExpression{
-			Location: location,
+			Location: p.locationFrom(expr.GetFileLocation()),
 			Token:    tok,
 			Left:     expr,
 			Operator: op,
@@ -1127,7 +1290,6 @@ func (p *Parser) shiftExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	for p.currTokenIn(token.LEFT_SHIFT, token.RIGHT_SHIFT) {
-		location := p.currFileLocation()
 		tok := p.currToken
 		op := p.currToken.Literal
 		_ = p.eatIn(token.LEFT_SHIFT, token.RIGHT_SHIFT)
@@ -1136,7 +1298,7 @@ func (p *Parser) shiftExpression() (ast.Expression, error) {
 			return nil, err
 		}
 		expr = &ast.BinaryOpExpression{
-			Location: location,
+			Location: p.locationFrom(expr.GetFileLocation()),
 			Token:    tok,
 			Left:     expr,
 			Operator: op,
@@ -1155,7 +1317,6 @@ func (p *Parser) plusExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	for p.currTokenIn(token.PLUS, token.MINUS) {
-		location := p.currFileLocation()
 		tok := p.currToken
 		op := p.currToken.Literal
 		_ = p.eatIn(token.PLUS, token.MINUS)
@@ -1164,7 +1325,7 @@ func (p *Parser) plusExpression() (ast.Expression, error) {
 			return nil, err
 		}
 		expr = &ast.BinaryOpExpression{
-			Location: location,
+			Location: p.locationFrom(expr.GetFileLocation()),
 			Token:    tok,
 			Left:     expr,
 			Operator: op,
@@ -1183,7 +1344,6 @@ func (p *Parser) multiplyExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	for p.currTokenIn(token.ASTERISK, token.SLASH, token.MODULO) {
-		location := p.currFileLocation()
 		tok := p.currToken
 		op := p.currToken.Literal
 		_ = p.eatIn(token.ASTERISK, token.SLASH, token.MODULO)
@@ -1192,7 +1352,7 @@ func (p *Parser) multiplyExpression() (ast.Expression, error) {
 			return nil, err
 		}
 		expr = &ast.BinaryOpExpression{
-			Location: location,
+			Location: p.locationFrom(expr.GetFileLocation()),
 			Token:    tok,
 			Left:     expr,
 			Operator: op,
@@ -1221,7 +1381,7 @@ func (p *Parser) unaryExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	expr := &ast.UnaryExpression{
-		Location: location,
+		Location: p.locationFrom(location),
 		Token:    tok,
 		Operator: op,
 		Operand:  right,
@@ -1242,7 +1402,6 @@ func (p *Parser) primaryExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	for p.currTokenIn(token.LBRACKET, token.DOT, token.LPAREN) {
-		location := p.currFileLocation()
 		tok := p.currToken
 		switch p.currToken.Type {
 		case token.LBRACKET:
@@ -1251,16 +1410,16 @@ func (p *Parser) primaryExpression() (ast.Expression, error) {
 			if err != nil {
 				return nil, err
 			}
+			err = p.eat(token.RBRACKET)
+			if err != nil {
+				return nil, err
+			}
 			expr = &ast.SubscriptionExpression{
-				Location: location,
+				Location: p.locationFrom(expr.GetFileLocation()),
 				Token:    tok,
 				Left:     expr,
 				Index:    index,
 			}
-			err = p.eat(token.RBRACKET)
-			if err != nil {
-				return nil, err
-			}
 		case token.DOT:
 			p.nextToken()
 			ident, err := p.ident()
@@ -1268,7 +1427,7 @@ func (p *Parser) primaryExpression() (ast.Expression, error) {
 				return nil, err
 			}
 			expr = &ast.AttributeExpression{
-				Location:  location,
+				Location:  p.locationFrom(expr.GetFileLocation()),
 				Token:     tok,
 				Left:      expr,
 				Attribute: ident,
@@ -1286,7 +1445,7 @@ func (p *Parser) primaryExpression() (ast.Expression, error) {
 				return nil, err
 			}
 			expr = &ast.CallExpression{
-				Location:  location,
+				Location:  p.locationFrom(expr.GetFileLocation()),
 				Token:     tok,
 				Function:  expr,
 				Arguments: arguments,
@@ -1299,7 +1458,7 @@ func (p *Parser) primaryExpression() (ast.Expression, error) {
 // atom 解析表达式的基本单元
 //
 // atom ::= IDENT | INT_LIT | STRING_LIT | BOOL_LIT | NULL_LIT
-// | list_literal | dict_literal | function_literal | "(" expression ")"
+// | list_literal | dict_literal | set_literal | function_literal | "(" expression ")"
 // | wei_expression
 func (p *Parser) atom() (ast.Expression, error) {
 	var expr ast.Expression
@@ -1378,16 +1537,15 @@ func (p *Parser) atom() (ast.Expression, error) {
 	case token.ILLEGAL:
 		return nil, p.syntaxError(p.currToken.Literal)
 	default:
-		fmt.Println("atom invalid error", p.currToken)
 		return nil, p.invalidError()
 	}
 	return expr, nil
 }
 
-// listLiteral 解析列表字面量
+// listLiteral 解析列表字面量，第一个元素后面是 for 时为列表推导式
 //
 // list_literal ::= "[" [expression] ("," expression)* [","] "]"
-func (p *Parser) listLiteral() (*ast.ListLiteral, error) {
+func (p *Parser) listLiteral() (ast.Expression, error) {
 	location := p.currFileLocation()
 	tok := p.currToken
 	p.parenCount++
@@ -1395,9 +1553,26 @@ func (p *Parser) listLiteral() (*ast.ListLiteral, error) {
 	if err != nil {
 		return nil, err
 	}
-	elements, err := p.expressionList(token.RBRACKET)
-	if err != nil {
-		return nil, err
+	var elements []ast.Expression
+	if p.currTokenNotIs(token.RBRACKET) {
+		first, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		// 行末自动插入的分号
+		p.skipIfSemicolon()
+		if p.currTokenIs(token.FOR) {
+			return p.listComprehension(location, tok, first)
+		}
+		elements = append(elements, first)
+		if p.currTokenIs(token.COMMA) {
+			p.nextToken()
+			rest, err := p.expressionList(token.RBRACKET)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, rest...)
+		}
 	}
 	p.parenCount--
 	err = p.eat(token.RBRACKET)
@@ -1405,7 +1580,7 @@ func (p *Parser) listLiteral() (*ast.ListLiteral, error) {
 		return nil, err
 	}
 	expr := &ast.ListLiteral{
-		Location: location,
+		Location: p.locationFrom(location),
 		Token:    tok,
 		Elements: elements,
 	}
@@ -1413,6 +1588,71 @@ func (p *Parser) listLiteral() (*ast.ListLiteral, error) {
 }
 
 // expression_list ::= [expression] ("," expression)* [","]
+// listComprehension 解析列表推导式，element 为已经解析的 for 前面的表达式
+//
+// list_comprehension ::= "[" expression comprehension "]"
+func (p *Parser) listComprehension(location *ast.FileLocation, tok token.Token, element ast.Expression) (*ast.ListComprehension, error) {
+	comprehension, err := p.comprehension()
+	if err != nil {
+		return nil, err
+	}
+	p.parenCount--
+	err = p.eat(token.RBRACKET)
+	if err != nil {
+		return nil, err
+	}
+	expr := &ast.ListComprehension{
+		Location:      p.locationFrom(location),
+		Token:         tok,
+		Element:       element,
+		Comprehension: comprehension,
+	}
+	return expr, nil
+}
+
+// comprehension 解析推导式中的 for 子句，变量的写法和 for-in 语句相同，但是不需要 var
+//
+// comprehension ::= "for" IDENT ("," IDENT)* "in" or_expression ["if" or_expression]
+func (p *Parser) comprehension() (*ast.Comprehension, error) {
+	err := p.eat(token.FOR)
+	if err != nil {
+		return nil, err
+	}
+	var targets []*ast.Identifier
+	target, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	targets = append(targets, target)
+	for p.currTokenIs(token.COMMA) {
+		p.nextToken()
+		target, err = p.ident()
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	err = p.eat(token.IN)
+	if err != nil {
+		return nil, err
+	}
+	iterable, err := p.orExpression()
+	if err != nil {
+		return nil, err
+	}
+	p.skipIfSemicolon()
+	comprehension := &ast.Comprehension{Targets: targets, Iterable: iterable}
+	if p.currTokenIs(token.IF) {
+		p.nextToken()
+		comprehension.Condition, err = p.orExpression()
+		if err != nil {
+			return nil, err
+		}
+		p.skipIfSemicolon()
+	}
+	return comprehension, nil
+}
+
 func (p *Parser) expressionList(end token.TokenType) ([]ast.Expression, error) {
 	var elements []ast.Expression
 	var ele ast.Expression
@@ -1429,6 +1669,8 @@ func (p *Parser) expressionList(end token.TokenType) ([]ast.Expression, error) {
 			return nil, err
 		}
 		elements = append(elements, ele)
+		// 行末自动插入的分号
+		p.skipIfSemicolon()
 	}
 	for p.currTokenIs(token.COMMA) {
 		p.nextToken()
@@ -1440,6 +1682,8 @@ func (p *Parser) expressionList(end token.TokenType) ([]ast.Expression, error) {
 			return nil, err
 		}
 		elements = append(elements, ele)
+		// 行末自动插入的分号
+		p.skipIfSemicolon()
 	}
 	if p.currTokenIs(token.COMMA) {
 		p.nextToken()
@@ -1447,12 +1691,14 @@ func (p *Parser) expressionList(end token.TokenType) ([]ast.Expression, error) {
 	return elements, nil
 }
 
-// dictLiteral 解析字典字面量
+// dictLiteral 解析字典、集合字面量，第一个元素后面没有 ":" 时为集合，
+// 第一个元素或者键值对后面是 for 时为推导式
 //
 // dict_literal ::= "{" [ pairs ] "}"
 // pairs        ::= [pair ("," pair)* [","]
 // pair         ::= expression ":" expression
-func (p *Parser) dictLiteral() (*ast.DictLiteral, error) {
+// set_literal  ::= "{" expression ("," expression)* [","] "}"
+func (p *Parser) dictLiteral() (ast.Expression, error) {
 	location := p.currFileLocation()
 	tok := p.currToken
 	p.parenCount++
@@ -1463,11 +1709,15 @@ func (p *Parser) dictLiteral() (*ast.DictLiteral, error) {
 	var key ast.Expression
 	var val ast.Expression
 	pairs := make(map[ast.Expression]ast.Expression)
+	var keys []ast.Expression
 	if !p.currTokenIs(token.RBRACE) {
 		key, err = p.expression()
 		if err != nil {
 			return nil, err
 		}
+		if p.currTokenNotIs(token.COLON) {
+			return p.setLiteral(location, tok, key)
+		}
 		err = p.eat(token.COLON)
 		if err != nil {
 			return nil, err
@@ -1476,7 +1726,13 @@ func (p *Parser) dictLiteral() (*ast.DictLiteral, error) {
 		if err != nil {
 			return nil, err
 		}
+		// 行末自动插入的分号
+		p.skipIfSemicolon()
+		if p.currTokenIs(token.FOR) {
+			return p.dictComprehension(location, tok, key, val)
+		}
 		pairs[key] = val
+		keys = append(keys, key)
 	}
 	for p.currTokenIs(token.COMMA) {
 		_ = p.eat(token.COMMA)
@@ -1496,6 +1752,9 @@ func (p *Parser) dictLiteral() (*ast.DictLiteral, error) {
 			return nil, err
 		}
 		pairs[key] = val
+		keys = append(keys, key)
+		// 行末自动插入的分号
+		p.skipIfSemicolon()
 	}
 	if p.currTokenIs(token.COMMA) {
 		_ = p.eat(token.COMMA)
@@ -1506,9 +1765,84 @@ func (p *Parser) dictLiteral() (*ast.DictLiteral, error) {
 		return nil, err
 	}
 	expr := &ast.DictLiteral{
-		Location: location,
+		Location: p.locationFrom(location),
 		Token:    tok,
 		Pairs:    pairs,
+		Keys:     keys,
+	}
+	return expr, nil
+}
+
+// setLiteral 解析集合字面量中第一个元素之后的部分
+func (p *Parser) setLiteral(location *ast.FileLocation, tok token.Token, first ast.Expression) (ast.Expression, error) {
+	elements := []ast.Expression{first}
+	// 行末自动插入的分号
+	p.skipIfSemicolon()
+	if p.currTokenIs(token.FOR) {
+		return p.setComprehension(location, tok, first)
+	}
+	if p.currTokenIs(token.COMMA) {
+		p.nextToken()
+		rest, err := p.expressionList(token.RBRACE)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, rest...)
+	}
+	p.parenCount--
+	err := p.eat(token.RBRACE)
+	if err != nil {
+		return nil, err
+	}
+	expr := &ast.SetLiteral{
+		Location: p.locationFrom(location),
+		Token:    tok,
+		Elements: elements,
+	}
+	return expr, nil
+}
+
+// setComprehension 解析集合推导式，element 为已经解析的 for 前面的表达式
+//
+// set_comprehension ::= "{" expression comprehension "}"
+func (p *Parser) setComprehension(location *ast.FileLocation, tok token.Token, element ast.Expression) (*ast.SetComprehension, error) {
+	comprehension, err := p.comprehension()
+	if err != nil {
+		return nil, err
+	}
+	p.parenCount--
+	err = p.eat(token.RBRACE)
+	if err != nil {
+		return nil, err
+	}
+	expr := &ast.SetComprehension{
+		Location:      p.locationFrom(location),
+		Token:         tok,
+		Element:       element,
+		Comprehension: comprehension,
+	}
+	return expr, nil
+}
+
+// dictComprehension 解析字典推导式，key value 为已经解析的 for 前面的键值对
+//
+// dict_comprehension ::= "{" expression ":" expression comprehension "}"
+func (p *Parser) dictComprehension(location *ast.FileLocation, tok token.Token, key, value ast.Expression) (*ast.DictComprehension, error) {
+	comprehension, err := p.comprehension()
+	if err != nil {
+		return nil, err
+	}
+	p.parenCount--
+	err = p.eat(token.RBRACE)
+	if err != nil {
+		return nil, err
+	}
+	expr := &ast.DictComprehension{
+		Location:      p.locationFrom(location),
+		Token:         tok,
+		Key:           key,
+		Value:         value,
+		Comprehension: comprehension,
 	}
 	return expr, nil
 }
@@ -1538,21 +1872,48 @@ func (p *Parser) functionLiteral() (*ast.FunctionLiteral, error) {
 		return nil, err
 	}
 	p.whileStack = append(p.whileStack, 0)
-	block, err := p.statementBlock()
+	block, err := p.functionBody()
 	if err != nil {
 		return nil, err
 	}
 	p.whileStack = p.whileStack[:len(p.whileStack)-1]
 	fl := &ast.FunctionLiteral{
-		Location:   location,
-		Token:      tok,
-		Name:       "<anonymous>",
-		Parameters: paramters,
-		Body:       block,
+		Location:    p.locationFrom(location),
+		Token:       tok,
+		Name:        "<anonymous>",
+		Parameters:  paramters,
+		Body:        block,
+		IsGenerator: containsYield(block),
 	}
 	return fl, nil
 }
 
+// functionBody 解析函数体，函数体中可以使用 yield
+func (p *Parser) functionBody() (*ast.BlockStatement, error) {
+	p.functionDepth++
+	block, err := p.statementBlock()
+	if err != nil {
+		return nil, err
+	}
+	p.functionDepth--
+	return block, nil
+}
+
+// containsYield 判断函数体中是否含有 yield ，嵌套的函数中的 yield 属于嵌套的函数
+func containsYield(block *ast.BlockStatement) bool {
+	found := false
+	ast.Inspect(block, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.YieldExpression:
+			found = true
+		case *ast.FunctionLiteral:
+			return false
+		}
+		return !found
+	})
+	return found
+}
+
 // parameter_list ::= [IDENT] ("," IDENT)* [","]
 func (p *Parser) parameterList() ([]*ast.Identifier, error) {
 	// 主要有下面这些情况
@@ -1617,7 +1978,7 @@ func (p *Parser) weiExpression() (ast.Expression, error) {
 			return nil, err
 		}
 		expr := &ast.WeiImportExpression{
-			Location: location,
+			Location: p.locationFrom(location),
 			Token:    tk,
 			Filename: filename,
 		}
@@ -1628,7 +1989,7 @@ func (p *Parser) weiExpression() (ast.Expression, error) {
 		return nil, err
 	}
 	expr := &ast.WeiAttributeExpression{
-		Location:  location,
+		Location:  p.locationFrom(location),
 		Token:     tk,
 		Attribute: attribute,
 	}
@@ -1685,18 +2046,27 @@ func (p *Parser) dump() *dumpInfo {
 	stack := make([]int, len(p.whileStack))
 	copy(stack, p.whileStack)
 	return &dumpInfo{
-		parenCount: p.parenCount,
-		whileStack: stack,
-		index:      p.l.Dump(),
-		token:      p.currToken,
+		parenCount:    p.parenCount,
+		whileStack:    stack,
+		functionDepth: p.functionDepth,
+		braceDepth:    p.braceDepth,
+		index:         p.l.Dump(),
+		token:         p.currToken,
+		prevEnd:       p.prevEnd,
+		errorCount:    len(p.errors),
 	}
 }
 
 func (p *Parser) restore(info *dumpInfo) {
 	p.l.Restore(info.index)
 	p.currToken = info.token
+	p.prevEnd = info.prevEnd
 	p.parenCount = info.parenCount
 	p.whileStack = info.whileStack
+	p.functionDepth = info.functionDepth
+	p.braceDepth = info.braceDepth
+	// 回溯时丢弃尝试解析过程中记录的错误
+	p.errors = p.errors[:info.errorCount]
 }
 
 func (p *Parser) nextToken() {
@@ -1707,6 +2077,15 @@ func (p *Parser) nextToken() {
 }
 
 func (p *Parser) doNextToken() {
+	if p.currTokenNotIs(token.SEMICOLON) {
+		p.prevEnd = p.currToken.End
+	}
+	switch p.currToken.Type {
+	case token.LBRACE:
+		p.braceDepth++
+	case token.RBRACE:
+		p.braceDepth--
+	}
 	p.currToken = p.l.NextToken()
 	//fmt.Printf("%d,%d-%d,%d:\t%s\t%q\n",
 	//	p.currToken.Start.Line, p.currToken.Start.Column,
@@ -1744,8 +2123,22 @@ func (p *Parser) currTokenNotIs(t token.TokenType) bool {
 	return p.currToken.TypeNotIs(t)
 }
 
+// currFileLocation 返回当前 token 的位置
 func (p *Parser) currFileLocation() *ast.FileLocation {
-	return ast.NewFileLocation(p.filename, p.currToken.Start.Line)
+	return &ast.FileLocation{
+		Filename: p.filename,
+		Lineno:   p.currToken.Start.Line,
+		Column:   p.currToken.Start.Column,
+		End:      p.currToken.End,
+		Lines:    p.lines,
+	}
+}
+
+// locationFrom 返回从 start 开始，到已经解析的最后一个 token 结束的位置
+func (p *Parser) locationFrom(start *ast.FileLocation) *ast.FileLocation {
+	location := *start
+	location.End = p.prevEnd
+	return &location
 }
 
 func (p *Parser) peekTokenIs(t token.TokenType) bool {
@@ -1761,6 +2154,10 @@ func (p *Parser) skipIfSemicolon() {
 }
 
 func (p *Parser) expectError(expected token.TokenType) error {
+	// 非法 token 直接报告词法错误
+	if p.currTokenIs(token.ILLEGAL) {
+		return p.syntaxError(p.currToken.Literal)
+	}
 	msg := fmt.Sprintf("expected %q, but got %q", expected, p.currToken.Type)
 	return p.syntaxError(msg)
 }
@@ -1774,20 +2171,16 @@ func (p *Parser) invalidError() error {
 }
 
 func (p *Parser) syntaxError(msg string) error {
-	// 标注错误的位置
-	template := `
-File "%s", line %d
-  %s
-  %s
-SyntaxError: %s`
 	line := p.currToken.Start.Line
-	column := p.currToken.Start.Column
-	return fmt.Errorf(template, p.filename, line+1, p.lines[line], strRjust("^", column+1), msg)
-}
-
-func strRjust(s string, n int) string {
-	if len(s) >= n {
-		return s
+	var source string
+	if line < len(p.lines) {
+		source = p.lines[line]
+	}
+	return &SyntaxError{
+		Filename: p.filename,
+		Start:    p.currToken.Start,
+		End:      p.currToken.End,
+		Msg:      msg,
+		line:     source,
 	}
-	return strings.Repeat(" ", n-len(s)) + s
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"weilang/ast"
+	"weilang/diagnostic"
 	"weilang/lexer"
 )
 
@@ -23,6 +24,8 @@ func TestSyntaxOk(t *testing.T) {
 		{"if (a) {a} \n else \n if (b) {b}"},
 		{"while \n(a) {a}"},
 		{"fn \n(a) {a}"},
+		{"fn f() {\n  yield\n}"},
+		{"fn f() { var a = yield; print(yield a, (yield)) }"},
 	}
 	for _, tt := range tests {
 		l := lexer.New(tt.input)
@@ -46,6 +49,14 @@ func TestSyntaxError(t *testing.T) {
 		{"break"},
 		{"var a = 1; break; a = 2"},
 		{"var a = 1; continue; a = 2"},
+		{"yield 1"},
+		{"fn f() {}; yield"},
+		{"class A { var a = yield 1 }"},
+		{"[x for x]"},
+		{"[x for 1 in l]"},
+		{"[x for x in l if]"},
+		{"[x for x in l, 1]"},
+		{"{k: v for k in d, 1: 2}"},
 		{`
 while (1) {
   var foo = fn() {
@@ -104,6 +115,87 @@ var class.d
 	}
 }
 
+func TestSyntaxErrorRecovery(t *testing.T) {
+	type position struct {
+		line, column int
+	}
+	tests := []struct {
+		input    string
+		expected []position
+	}{
+		{"var a = 1", nil},
+		{"var = 1\nvar b = 2\ncon = 3", []position{{0, 4}, {2, 4}}},
+		{"fn f() {\n  var = 1\n  return 1\n}\nvar c = )", []position{{1, 6}, {4, 8}}},
+		{"if (a b) {\n  var = 1\n}\nvar c = 1\n}\nbreak", []position{{0, 6}, {4, 0}, {5, 0}}},
+		{"while (1) {\n  var a = (1 +\n}", []position{{2, 0}}},
+		{"{\n  var = 1\n}\nvar c = )", []position{{1, 2}, {3, 8}}},
+		{"{ var = 1 }\nvar b = 2", []position{{0, 2}}},
+		{"var a = {1: {\n  var\n}}\nbreak", []position{{1, 2}, {3, 0}}},
+		{"}\n}\n", []position{{0, 0}, {1, 0}}},
+		{"print(1)\n}\n}\nprint(2", []position{{1, 0}, {2, 0}, {3, 7}}},
+		{"print(1 2)", []position{{0, 8}}},
+		{"print([1 2])\nvar = 1", []position{{0, 9}, {1, 4}}},
+		{"var a = \"\\q\"\nvar b = 1 /* x", []position{{0, 9}, {1, 10}}},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_, err := p.ParseProgram()
+		if tt.expected == nil {
+			if err != nil {
+				t.Errorf("got error\n%s\n%v", tt.input, err)
+			}
+			continue
+		}
+		errs, ok := err.(ErrorList)
+		if !ok {
+			t.Errorf("err is not ErrorList. got=%T (%v)", err, err)
+			continue
+		}
+		var got []position
+		for _, e := range errs {
+			got = append(got, position{e.Start.Line, e.Start.Column})
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("wrong error positions for\n%s\nwant=%v, got=%v%v", tt.input, tt.expected, got, err)
+		}
+		diagnostics := errs.Diagnostics()
+		if len(diagnostics) != len(errs) {
+			t.Errorf("wrong number of diagnostics. want=%d, got=%d", len(errs), len(diagnostics))
+			continue
+		}
+		for i, d := range diagnostics {
+			if d.Code != diagnostic.CodeSyntaxError || d.Message != errs[i].Msg || d.End != errs[i].End {
+				t.Errorf("wrong diagnostic. got=%+v", d)
+			}
+		}
+	}
+}
+
+// TestSyntaxErrorFurthestAttempt 语句可以按多种规则解析时，报告解析得更远的规则的错误
+func TestSyntaxErrorFurthestAttempt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print(1 2)", `expected ")", but got "INT"`},
+		{"print([1 2])", `expected "]", but got "INT"`},
+		{"a = )", `invalid syntax with token ")"`},
+		{"fn f() { var = 1 }", `expected "IDENT", but got "="`},
+	}
+	for _, tt := range tests {
+		_, err := New(lexer.New(tt.input)).ParseProgram()
+		errs, ok := err.(ErrorList)
+		if !ok || len(errs) != 1 {
+			t.Errorf("%q: want 1 error, got %v", tt.input, err)
+			continue
+		}
+		if errs[0].Msg != tt.expected {
+			t.Errorf("%q: wrong message. want=%q, got=%q", tt.input, tt.expected, errs[0].Msg)
+		}
+	}
+}
+
 func testVarStatement(t *testing.T, s ast.Statement, name string) bool {
 	t.Helper()
 	if s.TokenLiteral() != "var" {
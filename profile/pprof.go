@@ -0,0 +1,180 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+	"strings"
+)
+
+// pprof 格式为 gzip 压缩的 protobuf ，这里只编码用到的字段
+// https://github.com/google/pprof/blob/main/proto/profile.proto
+
+// protobuf 字段编号
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+)
+
+// buffer protobuf 编码
+type buffer struct {
+	data []byte
+}
+
+func (b *buffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+// uint64 编码 varint 类型的字段，值为 0 时省略
+func (b *buffer) uint64(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.varint(uint64(field)<<3 | 0)
+	b.varint(x)
+}
+
+func (b *buffer) int64(field int, x int64) {
+	b.uint64(field, uint64(x))
+}
+
+// bytes 编码 length-delimited 类型的字段
+func (b *buffer) bytes(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+// packed 编码 packed repeated 的 varint 字段
+func (b *buffer) packed(field int, values []uint64) {
+	var p buffer
+	for _, v := range values {
+		p.varint(v)
+	}
+	b.bytes(field, p.data)
+}
+
+// message 编码嵌套的消息
+func (b *buffer) message(field int, encode func(m *buffer)) {
+	var m buffer
+	encode(&m)
+	b.bytes(field, m.data)
+}
+
+// stringTable pprof 的字符串表，第一个字符串必须为空字符串
+type stringTable struct {
+	strings []string
+	index   map[string]int64
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{strings: []string{""}, index: map[string]int64{"": 0}}
+}
+
+func (t *stringTable) id(s string) int64 {
+	if i, ok := t.index[s]; ok {
+		return i
+	}
+	i := int64(len(t.strings))
+	t.strings = append(t.strings, s)
+	t.index[s] = i
+	return i
+}
+
+// pprofName pprof 显示函数名时会去掉 <> 中的内容，模块的 <module> 改为 module
+func pprofName(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(name, "<"), ">")
+}
+
+// WritePprof 以 pprof 格式输出调用栈和时间，每个样本有两个值：次数和纳秒
+func (p *Profiler) WritePprof(w io.Writer) error {
+	strs := newStringTable()
+	var b buffer
+	valueType := func(field int, typ, unit string) {
+		b.message(field, func(m *buffer) {
+			m.int64(valueTypeType, strs.id(typ))
+			m.int64(valueTypeUnit, strs.id(unit))
+		})
+	}
+	valueType(profileSampleType, "samples", "count")
+	valueType(profileSampleType, "time", "nanoseconds")
+
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := p.samples[key]
+		b.message(profileSample, func(m *buffer) {
+			m.packed(sampleLocationID, s.locations)
+			m.packed(sampleValue, []uint64{uint64(s.count), uint64(s.time.Nanoseconds())})
+		})
+	}
+
+	for i, loc := range p.locOrder {
+		b.message(profileLocation, func(m *buffer) {
+			m.uint64(locationID, uint64(i+1))
+			m.message(locationLine, func(l *buffer) {
+				l.uint64(lineFunctionID, uint64(loc.fn.id))
+				// 还没有执行语句时没有行号
+				if loc.line >= 0 {
+					l.int64(lineLine, int64(loc.line+1))
+				}
+			})
+		})
+	}
+	for _, fn := range p.funcOrder {
+		b.message(profileFunction, func(m *buffer) {
+			m.uint64(functionID, uint64(fn.id))
+			name := pprofName(fn.Name)
+			m.int64(functionName, strs.id(name))
+			m.int64(functionSystemName, strs.id(name))
+			m.int64(functionFilename, strs.id(fn.Filename))
+		})
+	}
+
+	b.int64(profileTimeNanos, p.start.UnixNano())
+	b.int64(profileDurationNanos, p.duration.Nanoseconds())
+	valueType(profilePeriodType, "time", "nanoseconds")
+	b.int64(profilePeriod, 1)
+
+	// 字符串表最后编码，前面的字段可能会加入新的字符串
+	for _, s := range strs.strings {
+		b.bytes(profileStringTable, []byte(s))
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
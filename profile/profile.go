@@ -0,0 +1,287 @@
+// Package profile 实现 Weilang 代码的性能分析
+//
+// Profiler 实现 evaluator.FrameTracer ，在执行每条语句、创建和销毁栈帧时记录时间，
+// 两次记录之间的时间计入当时的调用栈：栈顶的函数和正在执行的行计入自身时间，调用栈上的每个函数计入总时间。
+// 结果可以输出为文本表格，也可以输出为 pprof 格式，用 go tool pprof 查看火焰图
+package profile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"weilang/ast"
+	"weilang/evaluator"
+	"weilang/object"
+)
+
+// FunctionStat 函数的统计信息，函数由文件名和函数名区分
+type FunctionStat struct {
+	Filename string
+	Name     string
+	// Calls 调用次数，模块的代码也算一次调用，生成器恢复执行不算新的调用
+	Calls int
+	// Total 包括调用其他函数在内的时间，递归调用只计算一次
+	Total time.Duration
+	// Self 不包括调用其他函数的时间
+	Self time.Duration
+
+	id int
+	// mark 等于 Profiler.epoch 时表示这一次已经计入了 Total
+	mark int
+}
+
+// LineStat 行的统计信息
+type LineStat struct {
+	Filename string
+	// Line 从 0 开始的行号
+	Line int
+	// Hits 从这一行开始的语句执行的次数
+	Hits int
+	// Self 执行这一行的时间，不包括调用其他函数的时间
+	Self time.Duration
+	// Source 这一行的源码
+	Source string
+}
+
+type funcKey struct {
+	filename string
+	name     string
+}
+
+type lineKey struct {
+	filename string
+	line     int
+}
+
+// entry 调用栈中的一个栈帧，line 为正在执行的行，还没有执行语句时为 -1
+type entry struct {
+	fn   *FunctionStat
+	line int
+}
+
+// sample 调用栈相同的时间合并在一起，locations 从栈顶开始
+type sample struct {
+	locations []uint64
+	count     int64
+	time      time.Duration
+}
+
+// Profiler 记录函数和行的执行时间
+type Profiler struct {
+	// clock 当前时间，测试时可以替换
+	clock func() time.Time
+
+	start    time.Time
+	last     time.Time
+	duration time.Duration
+	stopped  bool
+
+	stack     []entry
+	functions map[funcKey]*FunctionStat
+	funcOrder []*FunctionStat
+	lines     map[lineKey]*LineStat
+	epoch     int
+
+	// locations pprof 中的位置，由函数和行号区分，编号从 1 开始
+	locations map[location]uint64
+	locOrder  []location
+	samples   map[string]*sample
+}
+
+type location struct {
+	fn   *FunctionStat
+	line int
+}
+
+// New 创建 Profiler ，从创建时开始计时
+func New() *Profiler {
+	p := &Profiler{
+		clock:     time.Now,
+		functions: map[funcKey]*FunctionStat{},
+		lines:     map[lineKey]*LineStat{},
+		locations: map[location]uint64{},
+		samples:   map[string]*sample{},
+	}
+	p.start = p.clock()
+	p.last = p.start
+	return p
+}
+
+// Trace 实现 evaluator.Tracer ，开始执行新的一行
+func (p *Profiler) Trace(
+	ctx context.Context,
+	state *evaluator.WeiState,
+	stmt ast.Statement,
+	env *object.Environment,
+) *object.Error {
+	p.tick()
+	if len(p.stack) == 0 {
+		return nil
+	}
+	location := stmt.GetFileLocation()
+	top := &p.stack[len(p.stack)-1]
+	top.line = location.Lineno
+	key := lineKey{top.fn.Filename, location.Lineno}
+	stat, ok := p.lines[key]
+	if !ok {
+		stat = &LineStat{Filename: key.filename, Line: key.line, Source: strings.TrimSpace(location.Line(location.Lineno))}
+		p.lines[key] = stat
+	}
+	stat.Hits++
+	return nil
+}
+
+// Breakpoint 实现 evaluator.Tracer ，性能分析时忽略 breakpoint()
+func (p *Profiler) Breakpoint() {}
+
+// EnterFrame 实现 evaluator.FrameTracer ，开始执行函数
+func (p *Profiler) EnterFrame(frame *object.Frame) {
+	p.tick()
+	key := funcKey{frame.GetFilename(), frame.GetFuncName()}
+	fn, ok := p.functions[key]
+	if !ok {
+		fn = &FunctionStat{Filename: key.filename, Name: key.name, id: len(p.funcOrder) + 1}
+		p.functions[key] = fn
+		p.funcOrder = append(p.funcOrder, fn)
+	}
+	if !frame.IsResumed() {
+		fn.Calls++
+	}
+	p.stack = append(p.stack, entry{fn: fn, line: -1})
+}
+
+// ExitFrame 实现 evaluator.FrameTracer ，函数返回
+func (p *Profiler) ExitFrame(frame *object.Frame) {
+	p.tick()
+	if len(p.stack) > 0 {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+}
+
+// Stop 结束计时，程序结束之后调用
+func (p *Profiler) Stop() {
+	if p.stopped {
+		return
+	}
+	p.tick()
+	p.stopped = true
+	p.duration = p.last.Sub(p.start)
+}
+
+// tick 把上一次记录之后的时间计入当前的调用栈
+func (p *Profiler) tick() {
+	now := p.clock()
+	elapsed := now.Sub(p.last)
+	p.last = now
+	if p.stopped || len(p.stack) == 0 || elapsed <= 0 {
+		return
+	}
+	top := p.stack[len(p.stack)-1]
+	top.fn.Self += elapsed
+	if top.line >= 0 {
+		if stat, ok := p.lines[lineKey{top.fn.Filename, top.line}]; ok {
+			stat.Self += elapsed
+		}
+	}
+	p.epoch++
+	var key strings.Builder
+	locations := make([]uint64, 0, len(p.stack))
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		e := p.stack[i]
+		if e.fn.mark != p.epoch {
+			e.fn.mark = p.epoch
+			e.fn.Total += elapsed
+		}
+		id := p.location(location{e.fn, e.line})
+		locations = append(locations, id)
+		key.WriteString(strconv.FormatUint(id, 10))
+		key.WriteByte(',')
+	}
+	s, ok := p.samples[key.String()]
+	if !ok {
+		s = &sample{locations: locations}
+		p.samples[key.String()] = s
+	}
+	s.count++
+	s.time += elapsed
+}
+
+func (p *Profiler) location(loc location) uint64 {
+	if id, ok := p.locations[loc]; ok {
+		return id
+	}
+	p.locOrder = append(p.locOrder, loc)
+	id := uint64(len(p.locOrder))
+	p.locations[loc] = id
+	return id
+}
+
+// Duration 从创建到 Stop 的时间
+func (p *Profiler) Duration() time.Duration {
+	return p.duration
+}
+
+// Functions 返回所有函数的统计信息，按总时间从大到小排序
+func (p *Profiler) Functions() []*FunctionStat {
+	functions := append([]*FunctionStat{}, p.funcOrder...)
+	sort.SliceStable(functions, func(i, j int) bool {
+		return functions[i].Total > functions[j].Total
+	})
+	return functions
+}
+
+// Lines 返回所有行的统计信息，按自身时间从大到小排序，时间相同时按执行次数排序
+func (p *Profiler) Lines() []*LineStat {
+	lines := make([]*LineStat, 0, len(p.lines))
+	for _, stat := range p.lines {
+		lines = append(lines, stat)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		a, b := lines[i], lines[j]
+		if a.Self != b.Self {
+			return a.Self > b.Self
+		}
+		if a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Line < b.Line
+	})
+	return lines
+}
+
+// WriteText 输出函数和行的统计表格，maxLines 为输出的行数，小于等于 0 时输出所有行
+func (p *Profiler) WriteText(w io.Writer, maxLines int) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Functions (total %s)\n", formatDuration(p.duration))
+	fmt.Fprintf(&b, "%8s %10s %10s  %s\n", "calls", "total", "self", "function")
+	for _, fn := range p.Functions() {
+		fmt.Fprintf(&b, "%8d %10s %10s  %s (%s)\n",
+			fn.Calls, formatDuration(fn.Total), formatDuration(fn.Self), fn.Name, filepath.Base(fn.Filename))
+	}
+	lines := p.Lines()
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+	fmt.Fprintf(&b, "\nHot lines\n")
+	fmt.Fprintf(&b, "%8s %10s  %s\n", "hits", "self", "line")
+	for _, stat := range lines {
+		fmt.Fprintf(&b, "%8d %10s  %s:%d  %s\n",
+			stat.Hits, formatDuration(stat.Self), filepath.Base(stat.Filename), stat.Line+1, stat.Source)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatDuration 以毫秒为单位输出时间
+func formatDuration(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64) + "ms"
+}
@@ -0,0 +1,303 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"weilang/interpreter"
+)
+
+const testProgram = `fn fib(n) {
+    if (n < 2) {
+        return n
+    }
+    return fib(n - 1) + fib(n - 2)
+}
+
+fn loop(n) {
+    var i = 0
+    while (i < n) {
+        i = i + 1
+    }
+    return i
+}
+
+print(fib(5))
+print(loop(10))
+`
+
+// profileProgram 执行程序，每次读取时间都前进 1 毫秒
+func profileProgram(t *testing.T, source string) *Profiler {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "main.wei")
+	if err := os.WriteFile(filename, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := New()
+	now := time.Unix(0, 0)
+	p.clock = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	p.start, p.last = now, now
+	var stderr bytes.Buffer
+	code := interpreter.Run(filename, interpreter.Options{Stdout: io.Discard, Stderr: &stderr, Tracer: p})
+	if code != interpreter.ExitOK {
+		t.Fatalf("exit code = %d, stderr: %s", code, stderr.String())
+	}
+	p.Stop()
+	return p
+}
+
+func TestFunctions(t *testing.T) {
+	p := profileProgram(t, testProgram)
+	functions := map[string]*FunctionStat{}
+	var self time.Duration
+	for _, fn := range p.Functions() {
+		functions[fn.Name] = fn
+		self += fn.Self
+		if fn.Self > fn.Total {
+			t.Errorf("%s: self %v > total %v", fn.Name, fn.Self, fn.Total)
+		}
+	}
+	tests := []struct {
+		name  string
+		calls int
+	}{
+		{"<module>", 1},
+		{"fib", 15},
+		{"loop", 1},
+	}
+	for _, tt := range tests {
+		fn, ok := functions[tt.name]
+		if !ok {
+			t.Errorf("no stats for %s", tt.name)
+			continue
+		}
+		if fn.Calls != tt.calls {
+			t.Errorf("%s: calls = %d, want %d", tt.name, fn.Calls, tt.calls)
+		}
+	}
+	module := functions["<module>"]
+	if p.Functions()[0] != module {
+		t.Errorf("functions not sorted by total: %+v", p.Functions())
+	}
+	// 除了进入模块之前的 1 毫秒，所有时间都计入模块
+	if module.Total != p.Duration()-time.Millisecond || self != module.Total {
+		t.Errorf("module total = %v, sum of self = %v, duration = %v", module.Total, self, p.Duration())
+	}
+	// 递归调用的时间只计算一次
+	if fib := functions["fib"]; fib.Total != fib.Self {
+		t.Errorf("fib: total = %v, self = %v", fib.Total, fib.Self)
+	}
+}
+
+func TestGeneratorCalls(t *testing.T) {
+	p := profileProgram(t, `fn count(n) {
+    var i = 0
+    while (i < n) {
+        yield i
+        i = i + 1
+    }
+}
+
+for (var x in count(3)) {
+    print(x)
+}
+for (var x in count(2)) {
+    print(x)
+}
+`)
+	for _, fn := range p.Functions() {
+		// 每次恢复执行生成器都会创建栈帧，只有第一次算作调用
+		if fn.Name == "count" && fn.Calls != 2 {
+			t.Errorf("count: calls = %d, want 2", fn.Calls)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	p := profileProgram(t, testProgram)
+	hits := map[int]int{}
+	for _, stat := range p.Lines() {
+		hits[stat.Line+1] = stat.Hits
+	}
+	expected := map[int]int{2: 15, 3: 8, 5: 7, 9: 1, 10: 1, 11: 10, 13: 1, 16: 1, 17: 1}
+	for line, want := range expected {
+		if hits[line] != want {
+			t.Errorf("line %d: hits = %d, want %d", line, hits[line], want)
+		}
+	}
+	lines := p.Lines()
+	for i := 1; i < len(lines); i++ {
+		if lines[i].Self > lines[i-1].Self {
+			t.Errorf("lines not sorted by self time: %+v", lines)
+			break
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	p := profileProgram(t, testProgram)
+	var out bytes.Buffer
+	if err := p.WriteText(&out, 2); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		"Functions (total ",
+		"      15 ",
+		"fib (main.wei)\n",
+		"<module> (main.wei)\n",
+		"\nHot lines\n",
+		"main.wei:5  return fib(n - 1) + fib(n - 2)\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output does not contain %q\n%s", want, text)
+		}
+	}
+	if n := strings.Count(text[strings.Index(text, "Hot lines"):], "\n"); n != 4 {
+		t.Errorf("want 2 hot lines, got output:\n%s", text)
+	}
+}
+
+// field protobuf 字段，varint 类型的值在 value 中，length-delimited 类型的值在 data 中
+type field struct {
+	num   int
+	value uint64
+	data  []byte
+}
+
+// varints 解码 packed 的 varint
+func varints(t *testing.T, data []byte) []uint64 {
+	t.Helper()
+	var values []uint64
+	for len(data) > 0 {
+		var x uint64
+		shift := 0
+		for {
+			if len(data) == 0 {
+				t.Fatal("truncated varint")
+			}
+			b := data[0]
+			data = data[1:]
+			x |= uint64(b&0x7f) << shift
+			shift += 7
+			if b < 0x80 {
+				break
+			}
+		}
+		values = append(values, x)
+	}
+	return values
+}
+
+func decode(t *testing.T, data []byte) []field {
+	t.Helper()
+	var fields []field
+	varint := func() uint64 {
+		var x uint64
+		for shift := 0; ; shift += 7 {
+			if len(data) == 0 {
+				t.Fatal("truncated varint")
+			}
+			b := data[0]
+			data = data[1:]
+			x |= uint64(b&0x7f) << shift
+			if b < 0x80 {
+				return x
+			}
+		}
+	}
+	for len(data) > 0 {
+		tag := varint()
+		f := field{num: int(tag >> 3)}
+		switch tag & 7 {
+		case 0:
+			f.value = varint()
+		case 2:
+			n := varint()
+			f.data, data = data[:n], data[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func TestWritePprof(t *testing.T) {
+	p := profileProgram(t, testProgram)
+	var out bytes.Buffer
+	if err := p.WritePprof(&out); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		strs      []string
+		samples   int
+		locations int
+		functions int
+		total     uint64
+		sampled   uint64
+	)
+	for _, f := range decode(t, data) {
+		switch f.num {
+		case profileStringTable:
+			strs = append(strs, string(f.data))
+		case profileSample:
+			samples++
+			for _, sf := range decode(t, f.data) {
+				if sf.num == sampleValue {
+					values := varints(t, sf.data)
+					if len(values) != 2 {
+						t.Fatalf("want 2 sample values, got %d", len(values))
+					}
+					// 第二个值为纳秒
+					sampled += values[1]
+				}
+			}
+		case profileLocation:
+			locations++
+		case profileFunction:
+			functions++
+		case profileDurationNanos:
+			total = f.value
+		}
+	}
+	if len(strs) == 0 || strs[0] != "" {
+		t.Fatalf("string table must start with an empty string: %q", strs)
+	}
+	joined := strings.Join(strs, ",")
+	for _, want := range []string{"samples", "count", "time", "nanoseconds", "fib", "loop", "module"} {
+		if !strings.Contains(joined, ","+want) {
+			t.Errorf("string table %q does not contain %q", strs, want)
+		}
+	}
+	if samples != len(p.samples) || locations != len(p.locOrder) || functions != 3 {
+		t.Errorf("samples = %d, locations = %d, functions = %d", samples, locations, functions)
+	}
+	var self time.Duration
+	for _, fn := range p.Functions() {
+		self += fn.Self
+	}
+	if sampled != uint64(self.Nanoseconds()) {
+		t.Errorf("sum of samples = %d, want %d", sampled, self.Nanoseconds())
+	}
+	if total != uint64(p.Duration().Nanoseconds()) {
+		t.Errorf("duration = %d, want %d", total, p.Duration().Nanoseconds())
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"weilang/interpreter"
+	"weilang/profile"
+)
+
+// profileLines 文本表格中输出的最耗时的行数
+const profileLines = 20
+
+// runProfile 执行 weilang --profile ，执行文件之后把统计表格输出到标准错误，pprof 格式的结果写入 output
+func runProfile(filename string, opts interpreter.Options, output string) int {
+	p := profile.New()
+	opts.Tracer = p
+	code := interpreter.Run(filename, opts)
+	p.Stop()
+	// 文件不存在或者有语法错误时没有执行任何代码，不输出统计结果，也不覆盖之前的结果文件
+	if len(p.Functions()) == 0 {
+		return code
+	}
+	_, _ = fmt.Fprintln(os.Stderr)
+	_ = p.WriteText(os.Stderr, profileLines)
+	f, err := os.Create(output)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "weilang: %v\n", err)
+		return interpreter.ExitRuntimeError
+	}
+	if err := p.WritePprof(f); err != nil {
+		_ = f.Close()
+		_, _ = fmt.Fprintf(os.Stderr, "weilang: %v\n", err)
+		return interpreter.ExitRuntimeError
+	}
+	if err := f.Close(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "weilang: %v\n", err)
+		return interpreter.ExitRuntimeError
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "\npprof profile written to %s, view it with: go tool pprof -http=: %s\n", output, output)
+	return code
+}
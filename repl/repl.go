@@ -20,18 +20,22 @@ const (
 
 var PROMPT = START_PROMPT
 
-func Start(in io.Reader, out io.Writer) {
+// Start 启动 repl ，返回进程退出码
+func Start(in io.Reader, out io.Writer) int {
 	scanner := bufio.NewScanner(in)
 	mod := object.NewModule("")
 	state := evaluator.NewWeiState(mod)
-	ctx := context.Background()
+	state.CreateFrame("<input>", "<module>")
+	// 退出 repl 时取消上下文，结束还阻塞在 yield 的生成器
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	var buffer bytes.Buffer
 	for {
 		_, _ = fmt.Fprintf(out, PROMPT)
 		scanned := scanner.Scan()
 		if !scanned {
-			return
+			return 0
 		}
 
 		line := scanner.Text()
@@ -48,6 +52,17 @@ func Start(in io.Reader, out io.Writer) {
 		}
 
 		evaluated := evaluator.Eval(ctx, state, program, mod.GetEnv())
+		// os.exit 结束 repl
+		if exit, ok := evaluated.(*object.Error); ok && exit.Exit {
+			return exit.ExitCode
+		}
+		// 输出错误栈，错误栈中包含出错的源码
+		if evaluator.IsError(evaluated) && state.HasExc() {
+			state.PrintExc(out)
+			state.ClearExc()
+			continue
+		}
+		state.ClearExc()
 		if evaluated != nil {
 			if !evaluator.IsError(evaluated) {
 				n := len(program.Statements)
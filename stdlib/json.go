@@ -0,0 +1,289 @@
+package stdlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"weilang/object"
+)
+
+// jsonEncoder 将对象编码为 JSON 字符串
+type jsonEncoder struct {
+	rt       object.Runtime
+	out      bytes.Buffer
+	indent   int
+	sortKeys bool
+	// visited 正在编码中的容器对象，用来检测循环引用
+	visited map[object.Object]bool
+}
+
+func (e *jsonEncoder) newline(level int) {
+	if e.indent <= 0 {
+		return
+	}
+	e.out.WriteByte('\n')
+	e.out.WriteString(strings.Repeat(" ", e.indent*level))
+}
+
+func (e *jsonEncoder) itemSeparator() {
+	if e.indent > 0 {
+		e.out.WriteByte(',')
+	} else {
+		e.out.WriteString(", ")
+	}
+}
+
+func (e *jsonEncoder) enter(obj object.Object) *object.Error {
+	if e.visited[obj] {
+		return object.NewError("circular reference detected")
+	}
+	e.visited[obj] = true
+	return nil
+}
+
+func (e *jsonEncoder) leave(obj object.Object) {
+	delete(e.visited, obj)
+}
+
+func (e *jsonEncoder) encode(obj object.Object, level int) object.Object {
+	switch obj := obj.(type) {
+	case *object.Null:
+		e.out.WriteString("null")
+	case *object.Boolean:
+		e.out.WriteString(obj.String())
+	case *object.Integer:
+		e.out.WriteString(obj.String())
+	case *object.String:
+		e.out.WriteString(jsonQuote(obj.Value))
+	case *object.List:
+		return e.encodeArray(obj, obj.Elements, level)
+	case *object.Tuple:
+		return e.encodeArray(obj, obj.Elements, level)
+	case *object.Dict:
+		return e.encodeDict(obj, level)
+	case *object.Instance:
+		method := obj.GetMethod("__json__")
+		if method == nil {
+			return object.NewError("object of type '%s' is not JSON serializable", obj.ClassName())
+		}
+		if err := e.enter(obj); err != nil {
+			return err
+		}
+		val := e.rt.CallFunction(method)
+		if val.TypeIs(object.ERROR_OBJ) {
+			return val
+		}
+		ret := e.encode(val, level)
+		e.leave(obj)
+		return ret
+	default:
+		return object.NewError("object of type '%s' is not JSON serializable", obj.Type())
+	}
+	return nil
+}
+
+func (e *jsonEncoder) encodeArray(obj object.Object, elements []object.Object, level int) object.Object {
+	if err := e.enter(obj); err != nil {
+		return err
+	}
+	e.out.WriteByte('[')
+	for i, element := range elements {
+		if i > 0 {
+			e.itemSeparator()
+		}
+		e.newline(level + 1)
+		ret := e.encode(element, level+1)
+		if ret != nil {
+			return ret
+		}
+	}
+	if len(elements) > 0 {
+		e.newline(level)
+	}
+	e.out.WriteByte(']')
+	e.leave(obj)
+	return nil
+}
+
+func (e *jsonEncoder) encodeDict(obj *object.Dict, level int) object.Object {
+	if err := e.enter(obj); err != nil {
+		return err
+	}
+	var pairs []object.HashPair
+	for _, pair := range obj.Pairs() {
+		if pair.Key.TypeNotIs(object.STRING_OBJ) {
+			return object.NewError("dict keys must be str, not '%s'", pair.Key.Type())
+		}
+		pairs = append(pairs, pair)
+	}
+	if e.sortKeys {
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i].Key.(*object.String).Value < pairs[j].Key.(*object.String).Value
+		})
+	}
+	e.out.WriteByte('{')
+	for i, pair := range pairs {
+		if i > 0 {
+			e.itemSeparator()
+		}
+		e.newline(level + 1)
+		e.out.WriteString(jsonQuote(pair.Key.(*object.String).Value))
+		e.out.WriteString(": ")
+		ret := e.encode(pair.Value, level+1)
+		if ret != nil {
+			return ret
+		}
+	}
+	if len(pairs) > 0 {
+		e.newline(level)
+	}
+	e.out.WriteByte('}')
+	e.leave(obj)
+	return nil
+}
+
+func jsonQuote(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	// Encode 会在末尾加上换行符
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// jsonDumps 将对象转化为 JSON 字符串
+// json.dumps(obj[, indent[, sort_keys]])
+//
+// 例子
+//
+//	json.dumps({"a": [1, 2]}) => '{"a": [1, 2]}'
+//	json.dumps([1], 2) => '[\n  1\n]'
+func jsonDumps(rt object.Runtime, args ...object.Object) object.Object {
+	argc := len(args)
+	if argc < 1 || argc > 3 {
+		return object.WrongNumberArgument2(argc, 1, 3)
+	}
+	encoder := &jsonEncoder{
+		rt:      rt,
+		visited: make(map[object.Object]bool),
+	}
+	if argc > 1 && args[1] != object.NULL {
+		indent, ok := args[1].(*object.Integer)
+		if !ok {
+			return object.WrongArgumentTypeAt(args[1].Type(), 2)
+		}
+		encoder.indent = int(indent.Value)
+	}
+	if argc > 2 {
+		sortKeys, ok := args[2].(*object.Boolean)
+		if !ok {
+			return object.WrongArgumentTypeAt(args[2].Type(), 3)
+		}
+		encoder.sortKeys = sortKeys.Value
+	}
+	ret := encoder.encode(args[0], 0)
+	if ret != nil {
+		return ret
+	}
+	return object.NewString(encoder.out.String())
+}
+
+// jsonDecoder 基于 encoding/json 的 token 流解析，保留字典键的出现顺序
+type jsonDecoder struct {
+	decoder *json.Decoder
+}
+
+func (d *jsonDecoder) decode() (object.Object, error) {
+	tok, err := d.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '[':
+			var elements []object.Object
+			for d.decoder.More() {
+				element, err := d.decode()
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, element)
+			}
+			// 跳过 ']'
+			if _, err := d.decoder.Token(); err != nil {
+				return nil, err
+			}
+			return object.NewList(elements), nil
+		case '{':
+			dict := object.NewDict()
+			for d.decoder.More() {
+				keyTok, err := d.decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := d.decode()
+				if err != nil {
+					return nil, err
+				}
+				dict.SetItem(object.NewString(keyTok.(string)), value)
+			}
+			// 跳过 '}'
+			if _, err := d.decoder.Token(); err != nil {
+				return nil, err
+			}
+			return dict, nil
+		}
+		return nil, errors.New("unexpected delimiter " + tok.String())
+	case bool:
+		return object.NativeBoolToBooleanObject(tok), nil
+	case json.Number:
+		n, err := strconv.ParseInt(tok.String(), 10, 64)
+		if err != nil {
+			return nil, errors.New("unsupported number " + tok.String())
+		}
+		return object.NewInteger(n), nil
+	case string:
+		return object.NewString(tok), nil
+	case nil:
+		return object.NULL, nil
+	}
+	return nil, errors.New("unexpected token")
+}
+
+// jsonLoads 解析 JSON 字符串
+// json.loads(s)
+//
+// 对象转化为 dict ，数组转化为 list ，数字只支持整数
+func jsonLoads(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.WrongNumberArgument(len(args), 1)
+	}
+	s, ok := args[0].(*object.String)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	decoder := json.NewDecoder(strings.NewReader(s.Value))
+	decoder.UseNumber()
+	d := &jsonDecoder{decoder: decoder}
+	obj, err := d.decode()
+	if err != nil {
+		return object.NewError("invalid json: %s", err.Error())
+	}
+	// 确保后面没有多余的内容
+	if _, err := decoder.Token(); err != io.EOF {
+		return object.NewError("invalid json: extra data")
+	}
+	return obj
+}
+
+func init() {
+	register("json", map[string]object.Object{
+		"dumps": &object.Builtin{Name: "dumps", RuntimeFn: jsonDumps},
+		"loads": &object.Builtin{Name: "loads", Fn: jsonLoads},
+	})
+}
@@ -0,0 +1,146 @@
+package stdlib
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"weilang/object"
+)
+
+// SetArgs 设置命令行参数，第一个为脚本文件名，脚本中通过 os.args 访问
+func SetArgs(args []string) {
+	var elements []object.Object
+	for _, arg := range args {
+		elements = append(elements, object.NewString(arg))
+	}
+	modules["os"].GetEnv().Pass("args", object.NewList(elements), true)
+}
+
+// osGetenv 获取环境变量，不存在时返回 defaultValue
+// os.getenv(name[, defaultValue])
+func osGetenv(args ...object.Object) object.Object {
+	argc := len(args)
+	if argc < 1 || argc > 2 {
+		return object.WrongNumberArgument2(argc, 1, 2)
+	}
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	if value, ok := os.LookupEnv(name.Value); ok {
+		return object.NewString(value)
+	}
+	if argc == 2 {
+		return args[1]
+	}
+	return object.NULL
+}
+
+// osSetenv 设置环境变量，子进程也会继承
+// os.setenv(name, value)
+func osSetenv(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.WrongNumberArgument(len(args), 2)
+	}
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	value, ok := args[1].(*object.String)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[1].Type(), 2)
+	}
+	if err := os.Setenv(name.Value, value.Value); err != nil {
+		return object.NewError("%s", err.Error())
+	}
+	return object.NULL
+}
+
+// osExit 结束程序
+// os.exit([code])
+func osExit(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return object.WrongNumberArgument2(len(args), 0, 1)
+	}
+	code := 0
+	if len(args) == 1 {
+		codeObj, ok := args[0].(*object.Integer)
+		if !ok {
+			return object.WrongArgumentTypeAt(args[0].Type(), 1)
+		}
+		code = int(codeObj.Value)
+	}
+	return object.NewExit(code)
+}
+
+// osCwd 返回当前工作目录
+// os.cwd()
+func osCwd(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return object.WrongNumberArgument(len(args), 0)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return object.NewError("%s", err.Error())
+	}
+	return object.NewString(dir)
+}
+
+// osRun 执行命令并等待结束，返回 {"stdout": str, "stderr": str, "status": int}
+// 执行被取消时会结束子进程
+// os.run(cmd[, args])
+func osRun(rt object.Runtime, args ...object.Object) object.Object {
+	argc := len(args)
+	if argc < 1 || argc > 2 {
+		return object.WrongNumberArgument2(argc, 1, 2)
+	}
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	var cmdArgs []string
+	if argc == 2 {
+		list, ok := args[1].(*object.List)
+		if !ok {
+			return object.WrongArgumentTypeAt(args[1].Type(), 2)
+		}
+		for _, element := range list.Elements {
+			arg, ok := element.(*object.String)
+			if !ok {
+				return object.NewError("command argument must be str, not '%s'", element.Type())
+			}
+			cmdArgs = append(cmdArgs, arg.Value)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(rt.Context(), name.Value, cmdArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	status := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return object.NewError("%s", err.Error())
+		}
+		status = exitErr.ExitCode()
+	}
+	result := object.NewDict()
+	result.SetItem(object.NewString("stdout"), object.NewString(stdout.String()))
+	result.SetItem(object.NewString("stderr"), object.NewString(stderr.String()))
+	result.SetItem(object.NewString("status"), object.NewInteger(int64(status)))
+	return result
+}
+
+func init() {
+	register("os", map[string]object.Object{
+		"args":   object.NewList(nil),
+		"getenv": &object.Builtin{Name: "getenv", Fn: osGetenv},
+		"setenv": &object.Builtin{Name: "setenv", Fn: osSetenv},
+		"exit":   &object.Builtin{Name: "exit", Fn: osExit},
+		"cwd":    &object.Builtin{Name: "cwd", Fn: osCwd},
+		"run":    &object.Builtin{Name: "run", RuntimeFn: osRun},
+	})
+}
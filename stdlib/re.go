@@ -0,0 +1,462 @@
+package stdlib
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+	"weilang/object"
+)
+
+const (
+	PATTERN_OBJ = "re.Pattern"
+	MATCH_OBJ   = "re.Match"
+)
+
+// Pattern 编译后的正则表达式，使用 Go regexp 的语法
+type Pattern struct {
+	re *regexp.Regexp
+	// anchored 只从字符串开头匹配的版本，用于 match
+	anchored *regexp.Regexp
+}
+
+func compilePattern(expr string) (*Pattern, *object.Error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, object.NewError("invalid pattern: %s", err.Error())
+	}
+	anchored := regexp.MustCompile(`^(?:` + expr + `)`)
+	return &Pattern{re: re, anchored: anchored}, nil
+}
+
+func (p *Pattern) Type() object.ObjectType {
+	return PATTERN_OBJ
+}
+
+func (p *Pattern) TypeIs(objectType object.ObjectType) bool {
+	return p.Type() == objectType
+}
+
+func (p *Pattern) TypeNotIs(objectType object.ObjectType) bool {
+	return p.Type() != objectType
+}
+
+func (p *Pattern) String() string {
+	return fmt.Sprintf("re.compile(%q)", p.re.String())
+}
+
+func (p *Pattern) GetAttribute(name string) object.Object {
+	switch name {
+	case "pattern":
+		return object.NewString(p.re.String())
+	case "match", "search", "findall", "split":
+		fn := patternMethods[name]
+		return &object.Builtin{
+			Name: name,
+			Fn: func(args ...object.Object) object.Object {
+				return fn(p, args...)
+			},
+		}
+	case "sub":
+		return &object.Builtin{
+			Name: name,
+			RuntimeFn: func(rt object.Runtime, args ...object.Object) object.Object {
+				return patternSub(rt, p, args...)
+			},
+		}
+	}
+	return attributeError(p, name)
+}
+
+//goland:noinspection GoUnusedParameter
+func (p *Pattern) SetAttribute(name string, value object.Object) object.Object {
+	return attributeError(p, name)
+}
+
+// Match 一次匹配的结果，位置都以 rune 为单位，与字符串下标保持一致
+type Match struct {
+	pattern *Pattern
+	s       string
+	// indexes 每个分组的字节位置，与 regexp.FindStringSubmatchIndex 返回值一致
+	indexes []int
+}
+
+func newMatch(p *Pattern, s string, indexes []int) object.Object {
+	if indexes == nil {
+		return object.NULL
+	}
+	return &Match{pattern: p, s: s, indexes: indexes}
+}
+
+func (m *Match) Type() object.ObjectType {
+	return MATCH_OBJ
+}
+
+func (m *Match) TypeIs(objectType object.ObjectType) bool {
+	return m.Type() == objectType
+}
+
+func (m *Match) TypeNotIs(objectType object.ObjectType) bool {
+	return m.Type() != objectType
+}
+
+func (m *Match) String() string {
+	start, end := m.span(0)
+	return fmt.Sprintf("<re.Match object; span=(%d, %d), match=%q>", start, end, m.group(0).String())
+}
+
+// groupIndex 将分组序号或者分组名转化为分组序号
+func (m *Match) groupIndex(arg object.Object) (int, *object.Error) {
+	switch arg := arg.(type) {
+	case *object.Integer:
+		n := int(arg.Value)
+		if n < 0 || n > m.pattern.re.NumSubexp() {
+			return 0, object.NewError("no such group: %d", n)
+		}
+		return n, nil
+	case *object.String:
+		n := m.pattern.re.SubexpIndex(arg.Value)
+		if n == -1 {
+			return 0, object.NewError("no such group: '%s'", arg.Value)
+		}
+		return n, nil
+	default:
+		return 0, object.NewError("group index expect 'int' or 'str', got '%s'", arg.Type())
+	}
+}
+
+// group 返回分组匹配的字符串，分组未参与匹配时返回 null
+func (m *Match) group(n int) object.Object {
+	start, end := m.indexes[2*n], m.indexes[2*n+1]
+	if start == -1 {
+		return object.NULL
+	}
+	return object.NewString(m.s[start:end])
+}
+
+// span 返回分组的 rune 位置，分组未参与匹配时返回 (-1, -1)
+func (m *Match) span(n int) (int, int) {
+	start, end := m.indexes[2*n], m.indexes[2*n+1]
+	if start == -1 {
+		return -1, -1
+	}
+	return runeOffset(m.s, start), runeOffset(m.s, end)
+}
+
+// optionalGroup 解析 group(n) span(n) 这类方法的可选分组参数
+func (m *Match) optionalGroup(args []object.Object) (int, *object.Error) {
+	if len(args) > 1 {
+		return 0, object.WrongNumberArgument2(len(args), 0, 1)
+	}
+	if len(args) == 0 {
+		return 0, nil
+	}
+	return m.groupIndex(args[0])
+}
+
+func (m *Match) GetAttribute(name string) object.Object {
+	var fn object.BuiltinFunction
+	switch name {
+	case "string":
+		return object.NewString(m.s)
+	case "group":
+		// match.group([n|name])
+		fn = func(args ...object.Object) object.Object {
+			n, err := m.optionalGroup(args)
+			if err != nil {
+				return err
+			}
+			return m.group(n)
+		}
+	case "groups":
+		// match.groups() 返回所有分组，不包括整个匹配
+		fn = func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.WrongNumberArgument(len(args), 0)
+			}
+			var elements []object.Object
+			for i := 1; i <= m.pattern.re.NumSubexp(); i++ {
+				elements = append(elements, m.group(i))
+			}
+			return object.NewList(elements)
+		}
+	case "groupdict":
+		// match.groupdict() 返回命名分组组成的字典
+		fn = func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.WrongNumberArgument(len(args), 0)
+			}
+			dict := object.NewDict()
+			for i, groupName := range m.pattern.re.SubexpNames() {
+				if groupName != "" {
+					dict.SetItem(object.NewString(groupName), m.group(i))
+				}
+			}
+			return dict
+		}
+	case "span":
+		// match.span([n|name]) 返回元组 (start, end)
+		fn = func(args ...object.Object) object.Object {
+			n, err := m.optionalGroup(args)
+			if err != nil {
+				return err
+			}
+			start, end := m.span(n)
+			return object.NewTuple([]object.Object{
+				object.NewInteger(int64(start)),
+				object.NewInteger(int64(end)),
+			})
+		}
+	case "start", "end":
+		fn = func(args ...object.Object) object.Object {
+			n, err := m.optionalGroup(args)
+			if err != nil {
+				return err
+			}
+			start, end := m.span(n)
+			if name == "start" {
+				return object.NewInteger(int64(start))
+			}
+			return object.NewInteger(int64(end))
+		}
+	default:
+		return attributeError(m, name)
+	}
+	return &object.Builtin{Name: name, Fn: fn}
+}
+
+//goland:noinspection GoUnusedParameter
+func (m *Match) SetAttribute(name string, value object.Object) object.Object {
+	return attributeError(m, name)
+}
+
+// runeOffset 将字节位置转化为 rune 位置
+func runeOffset(s string, byteOffset int) int {
+	return utf8.RuneCountInString(s[:byteOffset])
+}
+
+var patternMethods = map[string]func(p *Pattern, args ...object.Object) object.Object{
+	// pattern.match(s) 从字符串开头匹配，失败返回 null
+	"match": func(p *Pattern, args ...object.Object) object.Object {
+		s, err := oneStringArgument(args)
+		if err != nil {
+			return err
+		}
+		return newMatch(p, s, p.anchored.FindStringSubmatchIndex(s))
+	},
+	// pattern.search(s) 查找第一个匹配的位置，失败返回 null
+	"search": func(p *Pattern, args ...object.Object) object.Object {
+		s, err := oneStringArgument(args)
+		if err != nil {
+			return err
+		}
+		return newMatch(p, s, p.re.FindStringSubmatchIndex(s))
+	},
+	// pattern.findall(s)
+	// 没有分组时返回匹配的字符串列表；只有一个分组时返回分组字符串列表；多个分组时返回分组列表的列表
+	"findall": func(p *Pattern, args ...object.Object) object.Object {
+		s, err := oneStringArgument(args)
+		if err != nil {
+			return err
+		}
+		var elements []object.Object
+		numGroup := p.re.NumSubexp()
+		for _, submatch := range p.re.FindAllStringSubmatch(s, -1) {
+			switch numGroup {
+			case 0:
+				elements = append(elements, object.NewString(submatch[0]))
+			case 1:
+				elements = append(elements, object.NewString(submatch[1]))
+			default:
+				var groups []object.Object
+				for _, group := range submatch[1:] {
+					groups = append(groups, object.NewString(group))
+				}
+				elements = append(elements, object.NewList(groups))
+			}
+		}
+		return object.NewList(elements)
+	},
+	// pattern.split(s[, maxsplit])
+	// maxsplit 为 0 或者不传表示不限制分割次数，模式中有分组时，分组匹配的字符串也会放到结果中
+	"split": func(p *Pattern, args ...object.Object) object.Object {
+		argc := len(args)
+		if argc < 1 || argc > 2 {
+			return object.WrongNumberArgument2(argc, 1, 2)
+		}
+		s, ok := args[0].(*object.String)
+		if !ok {
+			return object.WrongArgumentTypeAt(args[0].Type(), 1)
+		}
+		n := -1
+		if argc == 2 {
+			maxsplit, ok := args[1].(*object.Integer)
+			if !ok {
+				return object.WrongArgumentTypeAt(args[1].Type(), 2)
+			}
+			if maxsplit.Value > 0 {
+				n = int(maxsplit.Value) + 1
+			}
+		}
+		return object.NewList(p.split(s.Value, n))
+	},
+}
+
+// split 和 regexp.Regexp.Split 的规则相同，n 小于 0 时不限制子字符串的数量，
+// 每个分割的位置后面依次加上分组匹配的字符串，分组未参与匹配时为 null
+func (p *Pattern) split(s string, n int) []object.Object {
+	if s == "" {
+		if p.re.String() == "" {
+			return nil
+		}
+		return []object.Object{object.NewString("")}
+	}
+	var elements []object.Object
+	parts := 0
+	begin, end := 0, 0
+	for _, indexes := range p.re.FindAllStringSubmatchIndex(s, n) {
+		if n > 0 && parts == n-1 {
+			break
+		}
+		end = indexes[0]
+		if indexes[1] != 0 {
+			elements = append(elements, object.NewString(s[begin:end]))
+			parts++
+			for i := 2; i < len(indexes); i += 2 {
+				if indexes[i] == -1 {
+					elements = append(elements, object.NULL)
+				} else {
+					elements = append(elements, object.NewString(s[indexes[i]:indexes[i+1]]))
+				}
+			}
+		}
+		begin = indexes[1]
+	}
+	if end != len(s) {
+		elements = append(elements, object.NewString(s[begin:]))
+	}
+	return elements
+}
+
+// patternSub 替换所有匹配的子字符串
+// pattern.sub(repl, s[, count])
+//
+// repl 为字符串时，可以使用 $1 ${name} 引用分组；
+// repl 为函数时，使用匹配对象调用函数，返回值作为替换的字符串
+// count 为 0 或者不传表示全部替换
+func patternSub(rt object.Runtime, p *Pattern, args ...object.Object) object.Object {
+	argc := len(args)
+	if argc < 2 || argc > 3 {
+		return object.WrongNumberArgument2(argc, 2, 3)
+	}
+	repl := args[0]
+	s, ok := args[1].(*object.String)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[1].Type(), 2)
+	}
+	count := -1
+	if argc == 3 {
+		countObj, ok := args[2].(*object.Integer)
+		if !ok {
+			return object.WrongArgumentTypeAt(args[2].Type(), 3)
+		}
+		if countObj.Value > 0 {
+			count = int(countObj.Value)
+		}
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, indexes := range p.re.FindAllStringSubmatchIndex(s.Value, count) {
+		out.WriteString(s.Value[last:indexes[0]])
+		switch repl := repl.(type) {
+		case *object.String:
+			out.Write(p.re.ExpandString(nil, repl.Value, s.Value, indexes))
+		default:
+			ret := rt.CallFunction(repl, newMatch(p, s.Value, indexes))
+			if ret.TypeIs(object.ERROR_OBJ) {
+				return ret
+			}
+			str, ok := ret.(*object.String)
+			if !ok {
+				return object.NewError("expected str from replacement function, got '%s'", ret.Type())
+			}
+			out.WriteString(str.Value)
+		}
+		last = indexes[1]
+	}
+	out.WriteString(s.Value[last:])
+	return object.NewString(out.String())
+}
+
+func oneStringArgument(args []object.Object) (string, *object.Error) {
+	if len(args) != 1 {
+		return "", object.WrongNumberArgument(len(args), 1)
+	}
+	s, ok := args[0].(*object.String)
+	if !ok {
+		return "", object.WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	return s.Value, nil
+}
+
+// toPattern 模块函数的第一个参数可以是字符串，也可以是编译好的 Pattern
+func toPattern(arg object.Object) (*Pattern, *object.Error) {
+	switch arg := arg.(type) {
+	case *Pattern:
+		return arg, nil
+	case *object.String:
+		return compilePattern(arg.Value)
+	default:
+		return nil, object.WrongArgumentTypeAt(arg.Type(), 1)
+	}
+}
+
+// reFunction 将 Pattern 的方法包装成模块函数 re.xxx(pattern, ...)
+// min max 为包括 pattern 在内的参数数量范围
+func reFunction(name string, min, max int) *object.Builtin {
+	return &object.Builtin{
+		Name: name,
+		RuntimeFn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if argc := len(args); argc < min || argc > max {
+				if min == max {
+					return object.WrongNumberArgument(argc, min)
+				}
+				return object.WrongNumberArgument2(argc, min, max)
+			}
+			p, err := toPattern(args[0])
+			if err != nil {
+				return err
+			}
+			if name == "sub" {
+				return patternSub(rt, p, args[1:]...)
+			}
+			return patternMethods[name](p, args[1:]...)
+		},
+	}
+}
+
+func init() {
+	register("re", map[string]object.Object{
+		// re.compile(pattern)
+		"compile": &object.Builtin{
+			Name: "compile",
+			Fn: func(args ...object.Object) object.Object {
+				expr, err := oneStringArgument(args)
+				if err != nil {
+					return err
+				}
+				p, err := compilePattern(expr)
+				if err != nil {
+					return err
+				}
+				return p
+			},
+		},
+		"match":   reFunction("match", 2, 2),
+		"search":  reFunction("search", 2, 2),
+		"findall": reFunction("findall", 2, 2),
+		"sub":     reFunction("sub", 3, 4),
+		"split":   reFunction("split", 2, 3),
+	})
+}
@@ -0,0 +1,20 @@
+// Package stdlib Go 实现的内置模块，通过 wei.import("name") 导入
+package stdlib
+
+import "weilang/object"
+
+var modules = map[string]*object.Module{}
+
+func register(name string, members map[string]object.Object) {
+	modules[name] = object.NewBuiltinModule(name, members)
+}
+
+// Get 根据名字获取内置模块
+func Get(name string) (*object.Module, bool) {
+	mod, ok := modules[name]
+	return mod, ok
+}
+
+func attributeError(obj object.Object, name string) *object.Error {
+	return object.NewError("'%s' object has not attribute '%s'", obj.Type(), name)
+}
@@ -0,0 +1,399 @@
+package stdlib
+
+import (
+	"time"
+	"weilang/object"
+)
+
+const (
+	DATETIME_OBJ = "time.DateTime"
+	DURATION_OBJ = "time.Duration"
+)
+
+// processStart 进程启动时间，monotonic 以它为起点计算单调时钟
+var processStart = time.Now()
+
+// DateTime 时间点，保存时区信息
+type DateTime struct {
+	t time.Time
+}
+
+func NewDateTime(t time.Time) *DateTime {
+	return &DateTime{t: t}
+}
+
+func (d *DateTime) Type() object.ObjectType {
+	return DATETIME_OBJ
+}
+
+func (d *DateTime) TypeIs(objectType object.ObjectType) bool {
+	return d.Type() == objectType
+}
+
+func (d *DateTime) TypeNotIs(objectType object.ObjectType) bool {
+	return d.Type() != objectType
+}
+
+func (d *DateTime) String() string {
+	return d.t.Format(time.RFC3339Nano)
+}
+
+func (d *DateTime) GetAttribute(name string) object.Object {
+	switch name {
+	case "year":
+		return object.NewInteger(int64(d.t.Year()))
+	case "month":
+		return object.NewInteger(int64(d.t.Month()))
+	case "day":
+		return object.NewInteger(int64(d.t.Day()))
+	case "hour":
+		return object.NewInteger(int64(d.t.Hour()))
+	case "minute":
+		return object.NewInteger(int64(d.t.Minute()))
+	case "second":
+		return object.NewInteger(int64(d.t.Second()))
+	case "nanosecond":
+		return object.NewInteger(int64(d.t.Nanosecond()))
+	case "weekday":
+		// 0 表示星期天
+		return object.NewInteger(int64(d.t.Weekday()))
+	case "unix":
+		return object.NewInteger(d.t.Unix())
+	case "unix_ms":
+		return object.NewInteger(d.t.UnixMilli())
+	case "format":
+		// datetime.format(layout)
+		return &object.Builtin{
+			Name: name,
+			Fn: func(args ...object.Object) object.Object {
+				layout, err := oneStringArgument(args)
+				if err != nil {
+					return err
+				}
+				return object.NewString(d.t.Format(layout))
+			},
+		}
+	case "utc", "local":
+		return &object.Builtin{
+			Name: name,
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return object.WrongNumberArgument(len(args), 0)
+				}
+				if name == "utc" {
+					return NewDateTime(d.t.UTC())
+				}
+				return NewDateTime(d.t.Local())
+			},
+		}
+	}
+	return attributeError(d, name)
+}
+
+//goland:noinspection GoUnusedParameter
+func (d *DateTime) SetAttribute(name string, value object.Object) object.Object {
+	return attributeError(d, name)
+}
+
+// BinaryOp 支持的运算
+//
+//	datetime + duration => datetime
+//	datetime - duration => datetime
+//	datetime - datetime => duration
+//	datetime 之间的比较
+func (d *DateTime) BinaryOp(operator string, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *Duration:
+		switch operator {
+		case "+":
+			return NewDateTime(d.t.Add(right.d))
+		case "-":
+			return NewDateTime(d.t.Add(-right.d))
+		}
+	case *DateTime:
+		switch operator {
+		case "-":
+			return NewDuration(d.t.Sub(right.t))
+		case "==":
+			return object.NativeBoolToBooleanObject(d.t.Equal(right.t))
+		case "!=":
+			return object.NativeBoolToBooleanObject(!d.t.Equal(right.t))
+		case "<":
+			return object.NativeBoolToBooleanObject(d.t.Before(right.t))
+		case "<=":
+			return object.NativeBoolToBooleanObject(!d.t.After(right.t))
+		case ">":
+			return object.NativeBoolToBooleanObject(d.t.After(right.t))
+		case ">=":
+			return object.NativeBoolToBooleanObject(!d.t.Before(right.t))
+		}
+	}
+	return nil
+}
+
+// Duration 时间间隔，精度为纳秒
+type Duration struct {
+	d time.Duration
+}
+
+func NewDuration(d time.Duration) *Duration {
+	return &Duration{d: d}
+}
+
+func (d *Duration) Type() object.ObjectType {
+	return DURATION_OBJ
+}
+
+func (d *Duration) TypeIs(objectType object.ObjectType) bool {
+	return d.Type() == objectType
+}
+
+func (d *Duration) TypeNotIs(objectType object.ObjectType) bool {
+	return d.Type() != objectType
+}
+
+func (d *Duration) String() string {
+	return d.d.String()
+}
+
+func (d *Duration) GetAttribute(name string) object.Object {
+	switch name {
+	case "hours":
+		return object.NewInteger(int64(d.d / time.Hour))
+	case "minutes":
+		return object.NewInteger(int64(d.d / time.Minute))
+	case "seconds":
+		return object.NewInteger(int64(d.d / time.Second))
+	case "milliseconds":
+		return object.NewInteger(d.d.Milliseconds())
+	case "microseconds":
+		return object.NewInteger(d.d.Microseconds())
+	case "nanoseconds":
+		return object.NewInteger(d.d.Nanoseconds())
+	}
+	return attributeError(d, name)
+}
+
+//goland:noinspection GoUnusedParameter
+func (d *Duration) SetAttribute(name string, value object.Object) object.Object {
+	return attributeError(d, name)
+}
+
+// BinaryOp 支持的运算
+//
+//	duration + duration => duration
+//	duration - duration => duration
+//	duration * int => duration
+//	duration / int => duration
+//	duration / duration => int
+//	duration 之间的比较
+func (d *Duration) BinaryOp(operator string, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *Duration:
+		switch operator {
+		case "+":
+			return NewDuration(d.d + right.d)
+		case "-":
+			return NewDuration(d.d - right.d)
+		case "/":
+			if right.d == 0 {
+				return object.NewError("division by zero")
+			}
+			return object.NewInteger(int64(d.d / right.d))
+		case "==":
+			return object.NativeBoolToBooleanObject(d.d == right.d)
+		case "!=":
+			return object.NativeBoolToBooleanObject(d.d != right.d)
+		case "<":
+			return object.NativeBoolToBooleanObject(d.d < right.d)
+		case "<=":
+			return object.NativeBoolToBooleanObject(d.d <= right.d)
+		case ">":
+			return object.NativeBoolToBooleanObject(d.d > right.d)
+		case ">=":
+			return object.NativeBoolToBooleanObject(d.d >= right.d)
+		}
+	case *object.Integer:
+		switch operator {
+		case "*":
+			return NewDuration(d.d * time.Duration(right.Value))
+		case "/":
+			if right.Value == 0 {
+				return object.NewError("division by zero")
+			}
+			return NewDuration(d.d / time.Duration(right.Value))
+		}
+	}
+	return nil
+}
+
+func integerArguments(args []object.Object) ([]int, *object.Error) {
+	var values []int
+	for i, arg := range args {
+		n, ok := arg.(*object.Integer)
+		if !ok {
+			return nil, object.WrongArgumentTypeAt(arg.Type(), i+1)
+		}
+		values = append(values, int(n.Value))
+	}
+	return values, nil
+}
+
+// timeSleep 暂停执行 ms 毫秒，执行上下文取消时提前结束并报错
+// time.sleep(ms)
+func timeSleep(rt object.Runtime, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.WrongNumberArgument(len(args), 1)
+	}
+	var d time.Duration
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		d = time.Duration(arg.Value) * time.Millisecond
+	case *Duration:
+		d = arg.d
+	default:
+		return object.WrongArgumentTypeAt(arg.Type(), 1)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	ctx := rt.Context()
+	select {
+	case <-timer.C:
+		return object.NULL
+	case <-ctx.Done():
+		return object.NewError("sleep interrupted: %s", ctx.Err().Error())
+	}
+}
+
+// timeDate 根据年月日时分秒创建本地时间
+// time.date(year, month, day[, hour[, minute[, second]]])
+func timeDate(args ...object.Object) object.Object {
+	argc := len(args)
+	if argc < 3 || argc > 6 {
+		return object.WrongNumberArgument2(argc, 3, 6)
+	}
+	values, err := integerArguments(args)
+	if err != nil {
+		return err
+	}
+	// 补齐没有传入的时分秒
+	for len(values) < 6 {
+		values = append(values, 0)
+	}
+	t := time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], 0, time.Local)
+	return NewDateTime(t)
+}
+
+// timeParse 按照 layout 解析时间字符串，layout 使用 Go 的格式
+// time.parse(s, layout)
+func timeParse(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.WrongNumberArgument(len(args), 2)
+	}
+	s, ok := args[0].(*object.String)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	layout, ok := args[1].(*object.String)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[1].Type(), 2)
+	}
+	t, err := time.ParseInLocation(layout.Value, s.Value, time.Local)
+	if err != nil {
+		return object.NewError("%s", err.Error())
+	}
+	return NewDateTime(t)
+}
+
+// timeFormat 按照 layout 格式化时间
+// time.format(datetime, layout)
+func timeFormat(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.WrongNumberArgument(len(args), 2)
+	}
+	d, ok := args[0].(*DateTime)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[0].Type(), 1)
+	}
+	layout, ok := args[1].(*object.String)
+	if !ok {
+		return object.WrongArgumentTypeAt(args[1].Type(), 2)
+	}
+	return object.NewString(d.t.Format(layout.Value))
+}
+
+// timeDuration 创建时间间隔
+// time.duration(ms) 或者 time.duration("1h30m")
+func timeDuration(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.WrongNumberArgument(len(args), 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return NewDuration(time.Duration(arg.Value) * time.Millisecond)
+	case *object.String:
+		d, err := time.ParseDuration(arg.Value)
+		if err != nil {
+			return object.NewError("%s", err.Error())
+		}
+		return NewDuration(d)
+	default:
+		return object.WrongArgumentTypeAt(arg.Type(), 1)
+	}
+}
+
+func init() {
+	register("time", map[string]object.Object{
+		// time.now() 当前本地时间
+		"now": &object.Builtin{
+			Name: "now",
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return object.WrongNumberArgument(len(args), 0)
+				}
+				return NewDateTime(time.Now())
+			},
+		},
+		// time.monotonic() 单调时钟，单位为纳秒，只适合用来计算时间差
+		"monotonic": &object.Builtin{
+			Name: "monotonic",
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return object.WrongNumberArgument(len(args), 0)
+				}
+				return object.NewInteger(int64(time.Since(processStart)))
+			},
+		},
+		// time.unix(seconds) 根据 Unix 时间戳创建本地时间
+		"unix": &object.Builtin{
+			Name: "unix",
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return object.WrongNumberArgument(len(args), 1)
+				}
+				values, err := integerArguments(args)
+				if err != nil {
+					return err
+				}
+				return NewDateTime(time.Unix(int64(values[0]), 0))
+			},
+		},
+		"sleep":    &object.Builtin{Name: "sleep", RuntimeFn: timeSleep},
+		"date":     &object.Builtin{Name: "date", Fn: timeDate},
+		"parse":    &object.Builtin{Name: "parse", Fn: timeParse},
+		"format":   &object.Builtin{Name: "format", Fn: timeFormat},
+		"duration": &object.Builtin{Name: "duration", Fn: timeDuration},
+
+		"NANOSECOND":  NewDuration(time.Nanosecond),
+		"MICROSECOND": NewDuration(time.Microsecond),
+		"MILLISECOND": NewDuration(time.Millisecond),
+		"SECOND":      NewDuration(time.Second),
+		"MINUTE":      NewDuration(time.Minute),
+		"HOUR":        NewDuration(time.Hour),
+
+		"RFC3339":  object.NewString(time.RFC3339),
+		"DATETIME": object.NewString(time.DateTime),
+		"DATE":     object.NewString(time.DateOnly),
+		"TIME":     object.NewString(time.TimeOnly),
+	})
+}
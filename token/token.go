@@ -1,5 +1,7 @@
 package token
 
+import "sort"
+
 type TokenType string
 
 const (
@@ -70,14 +72,15 @@ const (
 	FOR      = "for"
 	IN       = "in"
 	WEI      = "wei"
+	YIELD    = "yield"
 
 	// NEWLINE 换行 token 用来保证一行一条语句
 	NEWLINE = "newline"
 )
 
 type Position struct {
-	Line   int
-	Column int
+	Line   int `json:"line"`
+	Column int `json:"column"`
 }
 
 func (p *Position) Equal(other *Position) bool {
@@ -136,6 +139,17 @@ var keywords = map[string]TokenType{
 	"for":      FOR,
 	"in":       IN,
 	"wei":      WEI,
+	"yield":    YIELD,
+}
+
+// Keywords 返回所有关键字，按字母排序
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // LookupIdent 确定 ident 是否关键字